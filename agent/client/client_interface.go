@@ -20,6 +20,10 @@ type TaskManager interface {
 	Ping() (string, error)
 	GetTask(string) (interface{}, error)
 	CancelTask(string) (string, error)
+
+	// WithTaskProgressFunc returns a client that periodically reports
+	// progress of long running agent tasks to a given function.
+	WithTaskProgressFunc(TaskProgressFunc) Client
 }
 
 // VMAdministrator provides administrative API for the agent
@@ -36,9 +40,13 @@ type StateManager interface {
 }
 
 type JobManager interface {
+	// RunScript runs named script (e.g. bin/pre-start) for all applied jobs
+	RunScript(scriptName string) error
+
 	PreStart() error
 	Start() (string, error)
 	PostStart() error
+	PostDeploy() error
 	Stop() (string, error)
 	Drain(boshaction.DrainType, ...boshas.V1ApplySpec) (int, error)
 	RunErrand() (boshaction.ErrandResult, error)
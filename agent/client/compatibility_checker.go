@@ -0,0 +1,58 @@
+package client
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const compatibilityCheckerLogTag = "CompatibilityChecker"
+
+// Agents that do not report protocol version predate bosh_protocol field.
+var SupportedProtocols = []string{"", "1"}
+
+// CompatibilityChecker determines if agent can be used by provisioner.
+// Optional actions (e.g. run_script used for pre-start and post-start)
+// are not checked; their callers skip them if agent does not support them.
+type CompatibilityChecker struct {
+	logger boshlog.Logger
+}
+
+func NewCompatibilityChecker(logger boshlog.Logger) CompatibilityChecker {
+	return CompatibilityChecker{logger: logger}
+}
+
+// Check returns an error if agent cannot be used with this client.
+// It should be used only after agent responds to a ping.
+func (c CompatibilityChecker) Check(client Client, expectedAgentID string) error {
+	state, err := client.GetState()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting agent state")
+	}
+
+	if state.AgentID != expectedAgentID {
+		return bosherr.Errorf(
+			"Expected agent '%s' but communicating with agent '%s' (vm %s)",
+			expectedAgentID, state.AgentID, state.VM.Name)
+	}
+
+	if !c.isSupportedProtocol(state.BoshProtocol) {
+		return bosherr.Errorf(
+			"Agent protocol '%s' is not supported (supported: %v)",
+			state.BoshProtocol, SupportedProtocols)
+	}
+
+	c.logger.Debug(compatibilityCheckerLogTag,
+		"Agent '%s' with protocol '%s' is compatible", state.AgentID, state.BoshProtocol)
+
+	return nil
+}
+
+func (c CompatibilityChecker) isSupportedProtocol(protocol string) bool {
+	for _, p := range SupportedProtocols {
+		if p == protocol {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package client_test
+
+import (
+	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/agent/client"
+	fakebpagclient "github.com/cppforlife/bosh-provisioner/agent/client/fakes"
+)
+
+var _ = Describe("CompatibilityChecker", func() {
+	var (
+		agentClient *fakebpagclient.FakeClient
+		checker     CompatibilityChecker
+	)
+
+	BeforeEach(func() {
+		agentClient = &fakebpagclient.FakeClient{
+			GetStateState: boshaction.GetStateV1ApplySpec{
+				AgentID:      "fake-agent-id",
+				BoshProtocol: "1",
+				VM:           boshsettings.VM{Name: "fake-vm"},
+			},
+		}
+
+		checker = NewCompatibilityChecker(boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Check", func() {
+		It("does not return error if agent ID and protocol are expected", func() {
+			err := checker.Check(agentClient, "fake-agent-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not return error if agent does not report protocol", func() {
+			agentClient.GetStateState.BoshProtocol = ""
+
+			err := checker.Check(agentClient, "fake-agent-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not probe optional actions", func() {
+			err := checker.Check(agentClient, "fake-agent-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentClient.RunScriptNames).To(BeEmpty())
+		})
+
+		It("returns error if agent state cannot be retrieved", func() {
+			agentClient.GetStateErr = bosherr.Error("fake-get-state-err")
+
+			err := checker.Check(agentClient, "fake-agent-id")
+			Expect(err).To(MatchError("Getting agent state: fake-get-state-err"))
+		})
+
+		It("returns error if communicating with unexpected agent", func() {
+			agentClient.GetStateState.AgentID = "other-agent-id"
+
+			err := checker.Check(agentClient, "fake-agent-id")
+			Expect(err).To(MatchError("Expected agent 'fake-agent-id' but communicating with agent 'other-agent-id' (vm fake-vm)"))
+		})
+
+		It("returns error if agent protocol is not supported", func() {
+			agentClient.GetStateState.BoshProtocol = "2"
+
+			err := checker.Check(agentClient, "fake-agent-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Agent protocol '2' is not supported"))
+		})
+	})
+})
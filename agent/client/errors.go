@@ -0,0 +1,34 @@
+package client
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// UnsupportedActionError is returned when agent
+// does not recognize requested action (e.g. older agent).
+type UnsupportedActionError struct {
+	Method string
+}
+
+func (e UnsupportedActionError) Error() string {
+	return fmt.Sprintf("Agent does not support action '%s'", e.Method)
+}
+
+// IsUnsupportedActionError returns true if error or any of its causes
+// indicates that agent does not support requested action.
+func IsUnsupportedActionError(err error) bool {
+	for err != nil {
+		switch typedErr := err.(type) {
+		case UnsupportedActionError:
+			return true
+		case bosherr.ComplexError:
+			err = typedErr.Cause
+		default:
+			return false
+		}
+	}
+
+	return false
+}
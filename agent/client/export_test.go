@@ -0,0 +1,12 @@
+package client
+
+import (
+	"time"
+)
+
+// WithTaskIntervals allows tests to poll long running tasks quickly
+func (ac HTTPClient) WithTaskIntervals(pollInterval, progressInterval time.Duration) HTTPClient {
+	ac.taskPollInterval = pollInterval
+	ac.taskProgressInterval = progressInterval
+	return ac.withRequestAliases()
+}
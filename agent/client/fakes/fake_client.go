@@ -13,8 +13,13 @@ type FakeClient struct {
 	GetStateState  boshaction.GetStateV1ApplySpec
 	GetStateStates []boshaction.GetStateV1ApplySpec
 	GetStateErr    error
+	RunScriptNames []string
+	RunScriptErr   error
 	PreStartErr    error
 	PostStartErr   error
+	PostDeployErr  error
+
+	TaskProgressFunc bpagclient.TaskProgressFunc
 }
 
 func (c *FakeClient) Ping() (string, error) {
@@ -29,6 +34,11 @@ func (c *FakeClient) CancelTask(string) (string, error) {
 	return "", bosherr.Error("fake-cancel-task-err")
 }
 
+func (c *FakeClient) WithTaskProgressFunc(f bpagclient.TaskProgressFunc) bpagclient.Client {
+	c.TaskProgressFunc = f
+	return c
+}
+
 func (c *FakeClient) SSH(cmd string, params boshaction.SSHParams) (map[string]interface{}, error) {
 	return nil, bosherr.Error("fake-ssh-err")
 }
@@ -56,6 +66,11 @@ func (c *FakeClient) GetState(filters ...string) (boshaction.GetStateV1ApplySpec
 	return state, c.GetStateErr
 }
 
+func (c *FakeClient) RunScript(scriptName string) error {
+	c.RunScriptNames = append(c.RunScriptNames, scriptName)
+	return c.RunScriptErr
+}
+
 func (c *FakeClient) PreStart() error {
 	return c.PreStartErr
 }
@@ -68,6 +83,10 @@ func (c *FakeClient) PostStart() error {
 	return c.PostStartErr
 }
 
+func (c *FakeClient) PostDeploy() error {
+	return c.PostDeployErr
+}
+
 func (c *FakeClient) Stop() (string, error) {
 	return "", bosherr.Error("fake-stop-err")
 }
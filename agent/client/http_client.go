@@ -18,6 +18,14 @@ import (
 
 const httpClientLogTag = "HTTPClient"
 
+const (
+	// defaultTaskPollInterval is how often state of a long running task is checked
+	defaultTaskPollInterval = 1 * time.Second
+
+	// defaultTaskProgressInterval limits how often progress of a long running task is reported
+	defaultTaskProgressInterval = 10 * time.Second
+)
+
 type HTTPRequester interface {
 	Do(*http.Request) (*http.Response, error)
 }
@@ -27,6 +35,12 @@ type HTTPClient struct {
 	requester HTTPRequester
 	logger    boshlog.Logger
 
+	// Optionally notified while long running tasks are polled
+	taskProgressFunc TaskProgressFunc
+
+	taskPollInterval     time.Duration
+	taskProgressInterval time.Duration
+
 	// Alias to method calls for convenience
 	quickRequest requestFunc
 	longRequest  requestFunc
@@ -55,12 +69,25 @@ func NewHTTPClient(url *url.URL, requester HTTPRequester, logger boshlog.Logger)
 		url:       url,
 		requester: requester,
 		logger:    logger,
+
+		taskPollInterval:     defaultTaskPollInterval,
+		taskProgressInterval: defaultTaskProgressInterval,
 	}
 
-	client.quickRequest = client.makeQuickRequest
-	client.longRequest = client.makeLongRequest
+	return client.withRequestAliases()
+}
+
+func (ac HTTPClient) WithTaskProgressFunc(taskProgressFunc TaskProgressFunc) Client {
+	ac.taskProgressFunc = taskProgressFunc
+
+	// Aliases are bound to a copy of the client; hence, rebind them
+	return ac.withRequestAliases()
+}
 
-	return client
+func (ac HTTPClient) withRequestAliases() HTTPClient {
+	ac.quickRequest = ac.makeQuickRequest
+	ac.longRequest = ac.makeLongRequest
+	return ac
 }
 
 func (ac HTTPClient) Ping() (string, error) {
@@ -106,9 +133,18 @@ func (ac HTTPClient) Apply(desiredSpec boshas.V1ApplySpec) (string, error) {
 	return ac.makeStringRequest(ac.longRequest, "apply", reqArgs{desiredSpec})
 }
 
+// RunScript waits for the script to finish since run_script is an asynchronous action.
+func (ac HTTPClient) RunScript(scriptName string) error {
+	_, err := ac.longRequest("run_script", reqArgs{scriptName, make(map[string]interface{})})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running script %s", scriptName)
+	}
+
+	return nil
+}
+
 func (ac HTTPClient) PreStart() error {
-	_, err := ac.makeQuickRequest("run_script", reqArgs{"pre-start", make(map[string]interface{})})
-	return err
+	return ac.RunScript("pre-start")
 }
 
 func (ac HTTPClient) Start() (string, error) {
@@ -116,8 +152,11 @@ func (ac HTTPClient) Start() (string, error) {
 }
 
 func (ac HTTPClient) PostStart() error {
-	_, err := ac.makeQuickRequest("run_script", reqArgs{"post-start", make(map[string]interface{})})
-	return err
+	return ac.RunScript("post-start")
+}
+
+func (ac HTTPClient) PostDeploy() error {
+	return ac.RunScript("post-deploy")
 }
 
 func (ac HTTPClient) Stop() (string, error) {
@@ -220,13 +259,27 @@ func (ac HTTPClient) makeLongRequest(method reqMethod, args reqArgs) (responseEn
 		return responseEnvelope{}, bosherr.WrapError(err, "makeRequest")
 	}
 
+	startedAt := time.Now()
+	reportedAt := startedAt
+
 	for {
 		taskID, found := val.TaskID()
 		if !found {
 			return val, nil
 		}
 
-		time.Sleep(1 * time.Second)
+		if ac.taskProgressFunc != nil && time.Since(reportedAt) >= ac.taskProgressInterval {
+			reportedAt = time.Now()
+
+			ac.taskProgressFunc(TaskProgress{
+				Method:  string(method),
+				TaskID:  taskID,
+				State:   val.TaskState(),
+				Elapsed: reportedAt.Sub(startedAt),
+			})
+		}
+
+		time.Sleep(ac.taskPollInterval)
 
 		val, err = ac.makeQuickRequest("get_task", reqArgs{taskID})
 		if err != nil {
@@ -260,6 +313,10 @@ func (ac HTTPClient) makeQuickRequest(method reqMethod, args reqArgs) (responseE
 	}
 
 	if responseBody.HasException() {
+		if responseBody.Exception.IsUnknownMessage(method) {
+			return responseBody, UnsupportedActionError{Method: string(method)}
+		}
+
 		return responseBody, bosherr.Errorf("Ended with exception %#v", responseBody.Exception)
 	}
 
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
 
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -33,6 +34,12 @@ type compiledPackageEnvelope struct {
 	Result CompiledPackage `json:"result"`
 }
 
+// IsUnknownMessage returns true if agent did not recognize requested method.
+// (Agent's action dispatcher responds with 'unknown message <method>')
+func (e respException) IsUnknownMessage(method reqMethod) bool {
+	return e.Message == fmt.Sprintf("unknown message %s", method)
+}
+
 func (re responseEnvelope) HasException() bool {
 	return len(re.Exception.Message) > 0
 }
@@ -95,6 +102,23 @@ func (re responseEnvelope) TaskID() (string, bool) {
 	return taskID.(string), true
 }
 
+// TaskState returns state of a task if value looks like a task status.
+func (re responseEnvelope) TaskState() string {
+	value, err := re.interfaceValue()
+	if err != nil {
+		return ""
+	}
+
+	result, ok := value.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	state, _ := result["state"].(string)
+
+	return state
+}
+
 func (re responseEnvelope) interfaceValue() (interface{}, error) {
 	var value interface{}
 
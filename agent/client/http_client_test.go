@@ -0,0 +1,108 @@
+package client_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/agent/client"
+)
+
+var _ = Describe("HTTPClient", func() {
+	var (
+		server *httptest.Server
+
+		lock            sync.Mutex
+		requestMethods  []string
+		runningGetTasks int
+	)
+
+	BeforeEach(func() {
+		requestMethods = nil
+		runningGetTasks = 6
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var request struct {
+				Method string `json:"method"`
+			}
+
+			err := json.NewDecoder(req.Body).Decode(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			requestMethods = append(requestMethods, request.Method)
+
+			switch {
+			case request.Method == "apply":
+				fmt.Fprint(w, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`)
+			case request.Method == "get_task" && runningGetTasks > 0:
+				runningGetTasks--
+				fmt.Fprint(w, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`)
+			default:
+				fmt.Fprint(w, `{"value":"applied"}`)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	buildClient := func(progressInterval time.Duration) HTTPClient {
+		client, err := NewHTTPClientWithURI(server.URL, http.DefaultClient, boshlog.NewLogger(boshlog.LevelNone))
+		Expect(err).ToNot(HaveOccurred())
+
+		return client.WithTaskIntervals(10*time.Millisecond, progressInterval)
+	}
+
+	Describe("WithTaskProgressFunc", func() {
+		It("reports progress of long running tasks while they are polled", func() {
+			var progresses []TaskProgress
+
+			client := buildClient(25 * time.Millisecond).WithTaskProgressFunc(func(progress TaskProgress) {
+				progresses = append(progresses, progress)
+			})
+
+			value, err := client.Apply(boshas.V1ApplySpec{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("applied"))
+
+			Expect(requestMethods).To(Equal([]string{
+				"apply", "get_task", "get_task", "get_task", "get_task", "get_task", "get_task", "get_task",
+			}))
+
+			// Progress is reported at most once per progress interval instead of every poll
+			Expect(len(progresses)).To(BeNumerically(">=", 1))
+			Expect(len(progresses)).To(BeNumerically("<", 6))
+
+			for _, progress := range progresses {
+				Expect(progress.Method).To(Equal("apply"))
+				Expect(progress.TaskID).To(Equal("fake-task-id"))
+				Expect(progress.State).To(Equal("running"))
+				Expect(progress.Elapsed).To(BeNumerically(">=", 25*time.Millisecond))
+			}
+		})
+
+		It("does not report progress of tasks that finish within progress interval", func() {
+			var progresses []TaskProgress
+
+			client := buildClient(time.Hour).WithTaskProgressFunc(func(progress TaskProgress) {
+				progresses = append(progresses, progress)
+			})
+
+			_, err := client.Apply(boshas.V1ApplySpec{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(progresses).To(BeEmpty())
+		})
+	})
+})
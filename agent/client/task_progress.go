@@ -0,0 +1,33 @@
+package client
+
+import (
+	"time"
+
+	bpeventlog "github.com/cppforlife/bosh-provisioner/eventlog"
+)
+
+// TaskProgress describes a long running agent task
+// at the time when its state was last checked.
+type TaskProgress struct {
+	Method string
+	TaskID string
+
+	// e.g. running
+	State string
+
+	Elapsed time.Duration
+}
+
+type TaskProgressFunc func(TaskProgress)
+
+// NewEventLogTaskProgressFunc returns progress function
+// that records heartbeats for a given event log task.
+func NewEventLogTaskProgressFunc(task bpeventlog.Task) TaskProgressFunc {
+	return func(progress TaskProgress) {
+		task.Heartbeat(map[string]interface{}{
+			"agent_task_id": progress.TaskID,
+			"state":         progress.State,
+			"elapsed":       progress.Elapsed.Round(time.Second).String(),
+		})
+	}
+}
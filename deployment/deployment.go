@@ -3,14 +3,22 @@ package deployment
 import (
 	gonet "net"
 
+	"github.com/cloudfoundry-incubator/candiedyaml"
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	bpdepman "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
 )
 
 type Deployment struct {
 	Manifest bpdepman.Manifest
 
+	// Manifest and runtime config as written by the user (with ops applied)
+	// before variables were interpolated; used where values must not be exposed
+	SourceManifest      []byte
+	SourceRuntimeConfig []byte
+
 	Name string
 
 	Releases []Release
@@ -28,6 +36,9 @@ type Release struct {
 
 	// Not offical BOSH manifest construct
 	URL string
+
+	// Empty if release tarball should not be verified
+	SHA1 bputil.Digest
 }
 
 const (
@@ -41,6 +52,18 @@ var NetworkTypes = []string{NetworkTypeManual, NetworkTypeDynamic, NetworkTypeVi
 type Network struct {
 	Name string
 	Type string
+
+	// Only used by manual networks
+	Subnets []Subnet
+}
+
+type Subnet struct {
+	Range   *gonet.IPNet
+	Gateway gonet.IP
+	DNS     []gonet.IP
+
+	Reserved []bpdepman.IPRange
+	Static   []bpdepman.IPRange
 }
 
 type Job struct {
@@ -55,6 +78,20 @@ type Template struct {
 	Name string
 
 	Release *Release
+
+	// Template specific properties (v2 manifests);
+	// when set they are used instead of instance properties
+	Properties Properties
+
+	// Link name -> alias under which link is provided
+	ProvidesAs map[string]string
+
+	// Link name -> alias of the provided link to consume
+	ConsumesFrom map[string]string
+
+	// Consumed links keyed by link name.
+	// Populated once release job specs are available.
+	Links map[string]Link
 }
 
 type Instance struct {
@@ -85,6 +122,56 @@ type NetworkAssociation struct {
 	// StaticIP might equal to nil, though that does not indicate
 	// that this instance does not need a static IP
 	MustHaveStaticIP bool
+
+	// Dynamically allocated IP from one of the manual network subnets;
+	// only used when static IP is not assigned
+	AllocatedIP gonet.IP
+
+	// Subnet that includes static or allocated IP; only used by manual networks
+	Subnet *Subnet
+}
+
+// IP returns static or allocated IP if either was assigned
+func (na NetworkAssociation) IP() gonet.IP {
+	if na.StaticIP != nil {
+		return na.StaticIP
+	}
+
+	return na.AllocatedIP
+}
+
+// ResolvedSourceManifest returns source manifest with release versions
+// that were resolved after the manifest was read (e.g. latest -> 0+dev.3).
+// Variables are kept as placeholders so that their values are not exposed.
+func (d Deployment) ResolvedSourceManifest() ([]byte, error) {
+	var manifest map[interface{}]interface{}
+
+	err := candiedyaml.Unmarshal(d.SourceManifest, &manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing source manifest")
+	}
+
+	manReleases, _ := manifest["releases"].([]interface{})
+
+	for _, manRelease := range manReleases {
+		manReleaseMap, ok := manRelease.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, release := range d.Releases {
+			if release.Name == manReleaseMap["name"] {
+				manReleaseMap["version"] = release.Version
+			}
+		}
+	}
+
+	bytes, err := candiedyaml.Marshal(manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling source manifest")
+	}
+
+	return bytes, nil
 }
 
 // populateFromManifest populates deployment information
@@ -94,15 +181,29 @@ func (d *Deployment) populateFromManifest(manifest bpdepman.Manifest) {
 	d.populateReleases(manifest)
 	d.populateCompilationInstances(manifest)
 	d.populateJobs(manifest)
+	d.allocateIPs()
 	d.Manifest = manifest
 }
 
 func (d *Deployment) populateNetworks(manifest bpdepman.Manifest) {
 	for _, manNet := range manifest.Deployment.Networks {
-		d.Networks = append(d.Networks, Network{
+		network := Network{
 			Name: manNet.Name,
 			Type: manNet.Type,
-		})
+		}
+
+		for _, manSubnet := range manNet.Subnets {
+			network.Subnets = append(network.Subnets, Subnet{
+				Range:   manSubnet.Range,
+				Gateway: manSubnet.Gateway,
+				DNS:     manSubnet.DNS,
+
+				Reserved: manSubnet.Reserved,
+				Static:   manSubnet.Static,
+			})
+		}
+
+		d.Networks = append(d.Networks, network)
 	}
 }
 
@@ -112,6 +213,7 @@ func (d *Deployment) populateReleases(manifest bpdepman.Manifest) {
 			Name:    manRelease.Name,
 			Version: manRelease.Version,
 			URL:     manRelease.URL,
+			SHA1:    manRelease.SHA1,
 		})
 	}
 }
@@ -162,10 +264,24 @@ func (d *Deployment) buildJob(manDep bpdepman.Deployment, manJob bpdepman.Job) J
 	for _, manTemplate := range manJob.Templates {
 		release := d.findReleaseOrDefault(manTemplate.ReleaseName)
 
-		job.Templates = append(job.Templates, Template{
-			Name:    manTemplate.Name,
-			Release: release,
-		})
+		template := Template{
+			Name:       manTemplate.Name,
+			Release:    release,
+			Properties: Properties(manTemplate.Properties),
+
+			ProvidesAs:   map[string]string{},
+			ConsumesFrom: map[string]string{},
+		}
+
+		for name, provides := range manTemplate.Provides {
+			template.ProvidesAs[name] = provides.As
+		}
+
+		for name, consumes := range manTemplate.Consumes {
+			template.ConsumesFrom[name] = consumes.From
+		}
+
+		job.Templates = append(job.Templates, template)
 	}
 
 	return job
@@ -196,9 +312,9 @@ func (d *Deployment) buildNetworkAssociations(manJob bpdepman.Job, i int) []Netw
 
 func (d *Deployment) findReleaseOrDefault(name string) *Release {
 	// todo check against empty release name
-	for _, release := range d.Releases {
+	for i, release := range d.Releases {
 		if release.Name == name {
-			return &release
+			return &d.Releases[i]
 		}
 	}
 
@@ -211,11 +327,56 @@ func (d *Deployment) findReleaseOrDefault(name string) *Release {
 }
 
 func (d *Deployment) findNetworkOrDefault(name string) *Network {
-	for _, net := range d.Networks {
+	for i, net := range d.Networks {
 		if net.Name == name {
-			return &net
+			return &d.Networks[i]
 		}
 	}
 
 	return nil
 }
+
+// allocateIPs assigns subnets to manual network associations
+// and allocates IPs to instances that do not have static IPs.
+// Network associations that could not be satisfied are left
+// without IPs and are reported by the semantic validator.
+func (d *Deployment) allocateIPs() {
+	var instances []*Instance
+
+	for i := range d.Jobs {
+		for j := range d.Jobs[i].Instances {
+			instances = append(instances, &d.Jobs[i].Instances[j])
+		}
+	}
+
+	instances = append(instances, &d.CompilationInstance)
+
+	allocators := map[*Network]*ipAllocator{}
+
+	for i := range d.Networks {
+		if d.Networks[i].Type == NetworkTypeManual {
+			allocators[&d.Networks[i]] = newIPAllocator(&d.Networks[i])
+		}
+	}
+
+	// Static IPs must be known before handing out dynamic IPs
+	for _, instance := range instances {
+		for i, na := range instance.NetworkAssociations {
+			allocator, found := allocators[na.Network]
+			if found && na.StaticIP != nil {
+				instance.NetworkAssociations[i].Subnet = allocator.TakeStatic(na.StaticIP)
+			}
+		}
+	}
+
+	for _, instance := range instances {
+		for i, na := range instance.NetworkAssociations {
+			allocator, found := allocators[na.Network]
+			if found && !na.MustHaveStaticIP {
+				ip, subnet := allocator.AllocateDynamic()
+				instance.NetworkAssociations[i].AllocatedIP = ip
+				instance.NetworkAssociations[i].Subnet = subnet
+			}
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package diff compares manifest-like structures
+// (maps, lists of named items and scalar values).
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ChangeKindAdded   = "added"
+	ChangeKindRemoved = "removed"
+	ChangeKindChanged = "changed"
+)
+
+const redactedValue = "<redacted>"
+
+// Change describes difference found at a given path,
+// e.g. jobs[web].properties.nats.port
+type Change struct {
+	Path string
+	Kind string
+
+	// Old is nil for added values; New is nil for removed values
+	Old interface{}
+	New interface{}
+
+	// Values look like secrets and should not be shown
+	Redacted bool
+}
+
+type Changes []Change
+
+var (
+	secretKeyRegexp   = regexp.MustCompile(`(?i)(password|secret|token|private|credential|(^|_)key$|cert)`)
+	secretValueRegexp = regexp.MustCompile(`-----BEGIN [A-Z ]*(PRIVATE KEY|CERTIFICATE)-----`)
+)
+
+// Diff returns changes between old and new values.
+// Maps are compared key by key and lists of maps with 'name' keys
+// are compared item by item (e.g. jobs, templates, releases);
+// any other values are compared as a whole.
+func Diff(old, new interface{}) Changes {
+	var changes Changes
+
+	diffValues("", old, new, &changes)
+
+	return changes
+}
+
+func diffValues(path string, old, new interface{}, changes *Changes) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+
+	if oldIsMap && newIsMap {
+		diffMaps(path, oldMap, newMap, changes)
+		return
+	}
+
+	oldNamed, oldIsNamed := namedItems(old)
+	newNamed, newIsNamed := namedItems(new)
+
+	if oldIsNamed && newIsNamed {
+		diffNamedItems(path, oldNamed, newNamed, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, newChange(path, ChangeKindChanged, old, new))
+	}
+}
+
+func diffMaps(path string, old, new map[string]interface{}, changes *Changes) {
+	for _, key := range sortedKeys(old, new) {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		oldValue, inOld := old[key]
+		newValue, inNew := new[key]
+
+		switch {
+		case inOld && !inNew:
+			*changes = append(*changes, newChange(keyPath, ChangeKindRemoved, oldValue, nil))
+		case !inOld && inNew:
+			*changes = append(*changes, newChange(keyPath, ChangeKindAdded, nil, newValue))
+		default:
+			diffValues(keyPath, oldValue, newValue, changes)
+		}
+	}
+}
+
+func diffNamedItems(path string, old, new map[string]interface{}, changes *Changes) {
+	for _, name := range sortedKeys(old, new) {
+		itemPath := fmt.Sprintf("%s[%s]", path, name)
+
+		oldItem, inOld := old[name]
+		newItem, inNew := new[name]
+
+		switch {
+		case inOld && !inNew:
+			*changes = append(*changes, newChange(itemPath, ChangeKindRemoved, oldItem, nil))
+		case !inOld && inNew:
+			*changes = append(*changes, newChange(itemPath, ChangeKindAdded, nil, newItem))
+		default:
+			diffValues(itemPath, oldItem, newItem, changes)
+		}
+	}
+}
+
+// namedItems returns list items keyed by their names if all items are maps
+// with unique string 'name' keys. Empty list is considered to be named.
+func namedItems(value interface{}) (map[string]interface{}, bool) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	items := map[string]interface{}{}
+
+	for _, item := range list {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		name, ok := itemMap["name"].(string)
+		if !ok {
+			return nil, false
+		}
+
+		if _, found := items[name]; found {
+			return nil, false
+		}
+
+		items[name] = itemMap
+	}
+
+	return items, true
+}
+
+func newChange(path, kind string, old, new interface{}) Change {
+	return Change{
+		Path: path,
+		Kind: kind,
+		Old:  old,
+		New:  new,
+
+		Redacted: looksSecret(path, old) || looksSecret(path, new),
+	}
+}
+
+// looksSecret checks last path segment and all nested keys and values
+func looksSecret(path string, value interface{}) bool {
+	segments := strings.Split(path, ".")
+
+	if secretKeyRegexp.MatchString(segments[len(segments)-1]) {
+		return true
+	}
+
+	switch typedValue := value.(type) {
+	case string:
+		return secretValueRegexp.MatchString(typedValue)
+
+	case map[string]interface{}:
+		for key, nestedValue := range typedValue {
+			if looksSecret(key, nestedValue) {
+				return true
+			}
+		}
+
+	case []interface{}:
+		for _, nestedValue := range typedValue {
+			if looksSecret("", nestedValue) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(a, b map[string]interface{}) []string {
+	var keys []string
+
+	for key := range a {
+		keys = append(keys, key)
+	}
+
+	for key := range b {
+		if _, found := a[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// String returns change in '+ path: value', '- path: value'
+// or '~ path: old -> new' format; secret-looking values are redacted.
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeKindAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, c.formatValue(c.New))
+	case ChangeKindRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, c.formatValue(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, c.formatValue(c.Old), c.formatValue(c.New))
+	}
+}
+
+func (c Change) formatValue(value interface{}) string {
+	if c.Redacted {
+		return redactedValue
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(bytes)
+}
+
+func (cs Changes) String() string {
+	var lines []string
+
+	for _, change := range cs {
+		lines = append(lines, change.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,92 @@
+package diff_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/deployment/diff"
+)
+
+var _ = Describe("DiffYAML", func() {
+	It("returns changes matching list items by name", func() {
+		changes, err := DiffYAML([]byte(`
+name: dep
+releases:
+- {name: rel1, version: 1}
+- {name: rel2, version: 1}
+jobs:
+- name: web
+  instances: 1
+  properties:
+    port: 80
+    users: [a, b]
+`), []byte(`
+name: dep
+releases:
+- {name: rel2, version: 1}
+- {name: rel3, version: 2}
+jobs:
+- name: web
+  instances: 1
+  properties:
+    port: 8080
+    users: [a, c]
+    new-prop: val
+`))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(changes.String()).To(Equal(`+ jobs[web].properties.new-prop: "val"
+~ jobs[web].properties.port: 80 -> 8080
+~ jobs[web].properties.users: ["a","b"] -> ["a","c"]
+- releases[rel1]: {"name":"rel1","version":1}
++ releases[rel3]: {"name":"rel3","version":2}`))
+	})
+
+	It("returns no changes for equal documents", func() {
+		changes, err := DiffYAML([]byte("a: {b: 1}\n"), []byte("a:\n  b: 1\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("returns whole document as added if old document is empty", func() {
+		changes, err := DiffYAML(nil, []byte("a: 1\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes.String()).To(Equal("+ a: 1"))
+	})
+
+	It("redacts secret-looking values", func() {
+		changes, err := DiffYAML([]byte(`
+properties:
+  admin_password: old-pass
+  tls: {certificate: old-cert}
+  ca: "-----BEGIN CERTIFICATE-----\nold"
+  port: 80
+`), []byte(`
+properties:
+  admin_password: new-pass
+  tls: {certificate: new-cert}
+  ca: "-----BEGIN CERTIFICATE-----\nnew"
+  port: 81
+`))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(changes.String()).To(Equal(`~ properties.admin_password: <redacted> -> <redacted>
+~ properties.ca: <redacted> -> <redacted>
+~ properties.port: 80 -> 81
+~ properties.tls.certificate: <redacted> -> <redacted>`))
+	})
+
+	It("redacts added sections that include secrets", func() {
+		changes, err := DiffYAML([]byte("properties: {}\n"), []byte("properties: {db: {password: pass}}\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(changes).To(Equal(Changes{{
+			Path:     "properties.db",
+			Kind:     ChangeKindAdded,
+			New:      map[string]interface{}{"password": "pass"},
+			Redacted: true,
+		}}))
+
+		Expect(changes.String()).To(Equal("+ properties.db: <redacted>"))
+	})
+})
@@ -0,0 +1,39 @@
+package diff
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+// DiffYAML returns changes between two YAML documents (e.g. manifests).
+// Empty document is treated as an empty map.
+func DiffYAML(old, new []byte) (Changes, error) {
+	oldValue, err := valueFromYAML(old)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing old YAML")
+	}
+
+	newValue, err := valueFromYAML(new)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing new YAML")
+	}
+
+	return Diff(oldValue, newValue), nil
+}
+
+func valueFromYAML(bytes []byte) (interface{}, error) {
+	if len(bytes) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	var value interface{}
+
+	err := candiedyaml.Unmarshal(bytes, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	return bputil.NewStringKeyed().ConvertInterface(value)
+}
@@ -2,15 +2,22 @@ package deployment
 
 import (
 	"fmt"
+	gonet "net"
 )
 
 type NetworkConfiguration struct {
 	IP      string
 	Netmask string
 	Gateway string
+	DNS     []string
 }
 
 func (i Instance) NetworkConfigurationForNetworkAssociation(na NetworkAssociation) NetworkConfiguration {
+	// Manual network information is fully determined by the manifest
+	if na.Network.Type == NetworkTypeManual {
+		return i.manualNetworkConfiguration(na)
+	}
+
 	// If instance is always has static ip assigned there is nothing to resolve
 	if na.MustHaveStaticIP {
 		return NetworkConfiguration{IP: na.StaticIP.String()}
@@ -49,6 +56,25 @@ func (i Instance) NetworkConfigurationForNetworkAssociation(na NetworkAssociatio
 	return NetworkConfiguration{}
 }
 
+func (i Instance) manualNetworkConfiguration(na NetworkAssociation) NetworkConfiguration {
+	if na.Subnet == nil || na.IP() == nil {
+		return NetworkConfiguration{}
+	}
+
+	var dns []string
+
+	for _, ip := range na.Subnet.DNS {
+		dns = append(dns, ip.String())
+	}
+
+	return NetworkConfiguration{
+		IP:      na.IP().String(),
+		Netmask: gonet.IP(na.Subnet.Range.Mask).String(),
+		Gateway: na.Subnet.Gateway.String(),
+		DNS:     dns,
+	}
+}
+
 func (i Instance) DNDRecordName(na NetworkAssociation) string {
 	return fmt.Sprintf("%d.%s.%s.%s.bosh", i.Index, i.JobName, na.Network.Name, i.DeploymentName)
 }
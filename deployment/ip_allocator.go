@@ -0,0 +1,103 @@
+package deployment
+
+import (
+	gonet "net"
+
+	bpdepman "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+)
+
+// ipAllocator keeps track of IPs used on a single manual network.
+// Dynamic IPs are handed out from subnet ranges excluding
+// network, broadcast, gateway, reserved and static IPs.
+type ipAllocator struct {
+	network *Network
+	taken   map[string]struct{}
+}
+
+func newIPAllocator(network *Network) *ipAllocator {
+	return &ipAllocator{
+		network: network,
+		taken:   map[string]struct{}{},
+	}
+}
+
+// TakeStatic marks static IP as used and returns a subnet
+// that includes it in its static ranges.
+func (a *ipAllocator) TakeStatic(ip gonet.IP) *Subnet {
+	a.taken[ip.String()] = struct{}{}
+
+	for i, subnet := range a.network.Subnets {
+		if a.inRanges(ip, subnet.Static) {
+			return &a.network.Subnets[i]
+		}
+	}
+
+	return nil
+}
+
+// AllocateDynamic returns next available IP and its subnet.
+// Nil IP is returned when all subnets are exhausted.
+func (a *ipAllocator) AllocateDynamic() (gonet.IP, *Subnet) {
+	for i, subnet := range a.network.Subnets {
+		broadcastIP := a.broadcastIP(subnet.Range)
+
+		ip := a.nextIP(subnet.Range.IP)
+
+		for subnet.Range.Contains(ip) && !ip.Equal(broadcastIP) {
+			if a.isAvailable(ip, subnet) {
+				a.taken[ip.String()] = struct{}{}
+				return ip, &a.network.Subnets[i]
+			}
+
+			ip = a.nextIP(ip)
+		}
+	}
+
+	return nil, nil
+}
+
+func (a *ipAllocator) isAvailable(ip gonet.IP, subnet Subnet) bool {
+	if _, found := a.taken[ip.String()]; found {
+		return false
+	}
+
+	if ip.Equal(subnet.Gateway) {
+		return false
+	}
+
+	return !a.inRanges(ip, subnet.Reserved) && !a.inRanges(ip, subnet.Static)
+}
+
+func (a *ipAllocator) inRanges(ip gonet.IP, ranges []bpdepman.IPRange) bool {
+	for _, ipRange := range ranges {
+		if ipRange.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *ipAllocator) nextIP(ip gonet.IP) gonet.IP {
+	next := make(gonet.IP, len(ip))
+	copy(next, ip)
+
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+
+	return next
+}
+
+func (a *ipAllocator) broadcastIP(ipNet *gonet.IPNet) gonet.IP {
+	broadcast := make(gonet.IP, len(ipNet.IP))
+
+	for i := range ipNet.IP {
+		broadcast[i] = ipNet.IP[i] | ^ipNet.Mask[i]
+	}
+
+	return broadcast
+}
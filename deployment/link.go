@@ -0,0 +1,35 @@
+package deployment
+
+// Link is information shared by a providing job template
+// with consuming job templates in the same deployment.
+type Link struct {
+	// Alias under which link was provided
+	Name string
+	Type string
+
+	Deployment string
+
+	Instances []LinkInstance
+
+	// Only includes properties listed by the providing job spec
+	Properties Properties
+}
+
+type LinkInstance struct {
+	Name  string
+	Index int
+	ID    string
+
+	Address string
+
+	Bootstrap bool
+}
+
+// Address returns address of the first instance; empty if there are no instances.
+func (l Link) Address() string {
+	if len(l.Instances) == 0 {
+		return ""
+	}
+
+	return l.Instances[0].Address
+}
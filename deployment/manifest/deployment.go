@@ -52,6 +52,11 @@ func (d Deployment) InstanceProperties(job Job, i int) Properties {
 		panic("Deep copying job properties")
 	}
 
+	if d.PropertiesMerge == PropertiesMergeDeep {
+		deepMergeProperties(result, d.Properties)
+		return result
+	}
+
 	for name, value := range d.Properties {
 		if _, ok := result[name]; !ok {
 			result[name] = value
@@ -61,6 +66,25 @@ func (d Deployment) InstanceProperties(job Job, i int) Properties {
 	return result
 }
 
+// deepMergeProperties fills in dst with values from src that are not already set.
+// Nested sections present in both are merged so that dst values win at the leaf level.
+func deepMergeProperties(dst, src map[string]interface{}) {
+	for name, srcValue := range src {
+		dstValue, ok := dst[name]
+		if !ok {
+			dst[name] = srcValue
+			continue
+		}
+
+		dstMap, dstIsMap := dstValue.(map[string]interface{})
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+
+		if dstIsMap && srcIsMap {
+			deepMergeProperties(dstMap, srcMap)
+		}
+	}
+}
+
 // deepCopyJobProperties makes a deep copy of job properties.
 // Always returns an initialized map even if job properties are nil.
 func (j Job) deepCopyProperties() (Properties, error) {
@@ -0,0 +1,96 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+)
+
+var _ = Describe("Deployment", func() {
+	Describe("InstanceProperties", func() {
+		manifestBytes := func(merge string) []byte {
+			return []byte(`
+name: fake-deployment
+
+networks:
+- name: net1
+  type: dynamic
+
+compilation:
+  network: net1
+
+properties_merge: ` + merge + `
+
+properties:
+  nats:
+    user: global-user
+    port: 4222
+    tls:
+      enabled: true
+  other: global-other
+
+jobs:
+- name: job1
+  properties:
+    nats:
+      port: 4223
+      tls: disabled
+`)
+		}
+
+		instanceProperties := func(merge string) Properties {
+			manifest, err := NewManifestFromBytes(manifestBytes(merge))
+			Expect(err).ToNot(HaveOccurred())
+
+			dep := manifest.Deployment
+
+			return dep.InstanceProperties(dep.Jobs[0], 0)
+		}
+
+		It("uses global properties only for top-level keys not set by job when merge is not specified", func() {
+			Expect(instanceProperties(`""`)).To(Equal(Properties{
+				"nats": map[string]interface{}{
+					"port": float64(4223),
+					"tls":  "disabled",
+				},
+				"other": "global-other",
+			}))
+		})
+
+		It("uses global properties only for top-level keys not set by job when merge is shallow", func() {
+			Expect(instanceProperties("shallow")).To(Equal(instanceProperties(`""`)))
+		})
+
+		It("overrides global properties with job properties at the leaf level when merge is deep", func() {
+			Expect(instanceProperties("deep")).To(Equal(Properties{
+				"nats": map[string]interface{}{
+					"user": "global-user",
+					"port": float64(4223),
+					"tls":  "disabled",
+				},
+				"other": "global-other",
+			}))
+		})
+
+		It("does not modify global properties when merge is deep", func() {
+			manifest, err := NewManifestFromBytes(manifestBytes("deep"))
+			Expect(err).ToNot(HaveOccurred())
+
+			dep := manifest.Deployment
+			dep.InstanceProperties(dep.Jobs[0], 0)
+
+			Expect(dep.Properties["nats"]).To(Equal(map[string]interface{}{
+				"user": "global-user",
+				"port": int64(4222),
+				"tls":  map[string]interface{}{"enabled": true},
+			}))
+		})
+
+		It("returns error if merge is unknown", func() {
+			_, err := NewManifestFromBytes(manifestBytes("wide"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("properties_merge (line 11): Unknown properties merge wide"))
+		})
+	})
+})
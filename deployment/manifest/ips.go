@@ -1,12 +1,19 @@
 package manifest
 
 import (
+	"bytes"
 	gonet "net"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
+// IPRange represents inclusive range of IPs, e.g. 10.0.0.2 - 10.0.0.10
+type IPRange struct {
+	First gonet.IP
+	Last  gonet.IP
+}
+
 func NewIPsFromStrings(strs []string) ([]gonet.IP, error) {
 	var ips []gonet.IP
 
@@ -21,3 +28,54 @@ func NewIPsFromStrings(strs []string) ([]gonet.IP, error) {
 
 	return ips, nil
 }
+
+// NewIPRangesFromStrings parses single IPs ('10.0.0.2')
+// and IP ranges ('10.0.0.2 - 10.0.0.10').
+func NewIPRangesFromStrings(strs []string) ([]IPRange, error) {
+	var ranges []IPRange
+
+	for _, str := range strs {
+		parts := strings.Split(str, "-")
+		if len(parts) > 2 {
+			return ranges, bosherr.Errorf("Invalid IP range %s", str)
+		}
+
+		ips, err := NewIPsFromStrings(parts)
+		if err != nil {
+			return ranges, bosherr.WrapErrorf(err, "Parsing IP range %s", str)
+		}
+
+		ipRange := IPRange{First: ips[0], Last: ips[len(ips)-1]}
+
+		if CompareIPs(ipRange.First, ipRange.Last) > 0 {
+			return ranges, bosherr.Errorf(
+				"IP range must have last IP greater than or equal first IP %s", str)
+		}
+
+		ranges = append(ranges, ipRange)
+	}
+
+	return ranges, nil
+}
+
+func (r IPRange) Contains(ip gonet.IP) bool {
+	return CompareIPs(r.First, ip) <= 0 && CompareIPs(ip, r.Last) <= 0
+}
+
+func (r IPRange) String() string {
+	if r.First.Equal(r.Last) {
+		return r.First.String()
+	}
+
+	return r.First.String() + " - " + r.Last.String()
+}
+
+// CompareIPs returns an integer comparing two IPs of the same family.
+// The result will be 0 if a==b, -1 if a < b, and +1 if a > b.
+func CompareIPs(a, b gonet.IP) int {
+	if a4, b4 := a.To4(), b.To4(); a4 != nil && b4 != nil {
+		return bytes.Compare(a4, b4)
+	}
+
+	return bytes.Compare(a.To16(), b.To16())
+}
@@ -8,10 +8,16 @@ import (
 	"github.com/cloudfoundry-incubator/candiedyaml"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
 )
 
 type Manifest struct {
 	Deployment Deployment
+
+	// Deployment structure is mapped from v2 format
+	isV2 bool
 }
 
 type Deployment struct {
@@ -29,10 +35,21 @@ type Deployment struct {
 
 	Jobs []Job `yaml:"jobs"`
 
+	// Not used for VM creation since there is only one VM;
+	// kept so that v2 manifests can be fully represented
+	Stemcells []Stemcell `yaml:"stemcells"`
+
+	Variables []Variable `yaml:"variables"`
+
 	// Global properties.
 	// Non-raw field is populated by the validator.
 	PropertiesRaw map[interface{}]interface{} `yaml:"properties"`
 	Properties    Properties
+
+	// Not offical BOSH manifest construct.
+	// Determines how global properties are combined with job properties
+	// (e.g. shallow, deep); shallow is used if not specified.
+	PropertiesMerge string `yaml:"properties_merge"`
 }
 
 type Release struct {
@@ -41,8 +58,41 @@ type Release struct {
 
 	// Not offical BOSH manifest construct
 	URL string `yaml:"url"`
+
+	// e.g. 'abc...' for SHA1 or 'sha256:abc...'.
+	// Non-raw field is populated by the validator.
+	SHA1Raw string `yaml:"sha1"`
+	SHA1    bputil.Digest
+}
+
+type Stemcell struct {
+	Alias   string `yaml:"alias"`
+	OS      string `yaml:"os"`
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+type Variable struct {
+	Name string `yaml:"name"`
+
+	// e.g. password, certificate, ssh, rsa
+	Type string `yaml:"type"`
+
+	// Non-raw field is populated by the validator.
+	OptionsRaw map[interface{}]interface{} `yaml:"options"`
+	Options    map[string]interface{}
 }
 
+const (
+	// Global property is used only if job does not specify property with the same top-level key
+	PropertiesMergeShallow = "shallow"
+
+	// Job properties override global properties at the leaf level
+	PropertiesMergeDeep = "deep"
+)
+
+var PropertiesMerges = []string{PropertiesMergeShallow, PropertiesMergeDeep}
+
 const (
 	NetworkTypeManual  = "manual"
 	NetworkTypeDynamic = "dynamic"
@@ -56,6 +106,29 @@ type Network struct {
 
 	// e.g. manual, dynamic, vip
 	Type string `yaml:"type"`
+
+	// Only used by manual networks
+	Subnets []Subnet `yaml:"subnets"`
+}
+
+type Subnet struct {
+	// Non-raw fields are populated by the validator.
+	RangeRaw string `yaml:"range"`
+	Range    *gonet.IPNet
+
+	GatewayRaw string `yaml:"gateway"`
+	Gateway    gonet.IP
+
+	DNSRaw []string `yaml:"dns"`
+	DNS    []gonet.IP
+
+	// IPs that should never be assigned to instances
+	ReservedRaw []string `yaml:"reserved"`
+	Reserved    []IPRange
+
+	// IPs that can only be assigned to instances via static_ips
+	StaticRaw []string `yaml:"static"`
+	Static    []IPRange
 }
 
 type Compilation struct {
@@ -84,6 +157,26 @@ type Job struct {
 type Template struct {
 	Name        string `yaml:"name"`
 	ReleaseName string `yaml:"release"`
+
+	// Template specific properties that are used instead of job properties.
+	// Nil if not specified. Non-raw field is populated by the validator.
+	PropertiesRaw map[interface{}]interface{} `yaml:"properties"`
+	Properties    Properties
+
+	// Keyed by link names from release job spec
+	Provides map[string]LinkProvides `yaml:"provides"`
+	Consumes map[string]LinkConsumes `yaml:"consumes"`
+}
+
+type LinkProvides struct {
+	// Name under which link can be consumed; link name is used if not specified
+	As string `yaml:"as"`
+}
+
+type LinkConsumes struct {
+	// Name of the provided link; if not specified
+	// link is found by the type of consumed link
+	From string `yaml:"from"`
 }
 
 type Properties map[string]interface{}
@@ -122,22 +215,71 @@ func NewManifestFromPath(path string, fs boshsys.FileSystem) (Manifest, error) {
 }
 
 // NewManifestFromBytes returns manifest built from given bytes.
+// Both v1 (jobs) and v2 (instance_groups) manifest formats are accepted.
 // Before returning manifest is syntactically validated.
 func NewManifestFromBytes(bytes []byte) (Manifest, error) {
+	return NewManifestFromSourceBytes(bytes, bytes)
+}
+
+// NewManifestFromSourceBytes is like NewManifestFromBytes but line numbers
+// of validation errors are looked up in srcBytes (e.g. manifest as written by the user
+// before it was interpolated). Line numbers are omitted if srcBytes is nil.
+func NewManifestFromSourceBytes(bytes, srcBytes []byte) (Manifest, error) {
 	var manifest Manifest
-	var deployment Deployment
 
-	err := candiedyaml.Unmarshal(bytes, &deployment)
+	isV2, err := isV2Manifest(bytes)
 	if err != nil {
-		return manifest, bosherr.WrapError(err, "Parsing deployment")
+		return manifest, bosherr.WrapError(err, "Detecting manifest format")
 	}
 
-	manifest.Deployment = deployment
+	if isV2 {
+		deployment, err := newDeploymentFromV2Bytes(bytes, srcBytes)
+		if err != nil {
+			return manifest, err
+		}
+
+		manifest.Deployment = deployment
+		manifest.isV2 = true
+	} else {
+		var deployment Deployment
+
+		err := candiedyaml.Unmarshal(bytes, &deployment)
+		if err != nil {
+			return manifest, bosherr.WrapError(err, "Parsing deployment")
+		}
+
+		manifest.Deployment = deployment
+	}
 
 	err = NewSyntaxValidator(&manifest).Validate()
 	if err != nil {
+		err = manifest.AnnotateErrors(err, srcBytes)
 		return manifest, bosherr.WrapError(err, "Validating manifest syntactically")
 	}
 
 	return manifest, nil
 }
+
+// AnnotateErrors updates validation errors' paths to match manifest source
+// (v2 manifests are validated after being mapped onto v1 structure)
+// and adds line numbers based on manifest bytes.
+func (m Manifest) AnnotateErrors(err error, bytes []byte) error {
+	if m.isV2 {
+		return annotateErrors(err, bytes, v2PathFromV1Path)
+	}
+
+	return annotateErrors(err, bytes, nil)
+}
+
+func annotateErrors(err error, bytes []byte, mapFunc func(string) string) error {
+	verrs, ok := err.(bpvalid.Errors)
+	if !ok {
+		return err
+	}
+
+	if mapFunc == nil {
+		mapFunc = func(path string) string { return path }
+	}
+
+	return verrs.MapPaths(mapFunc, bpvalid.NewLineLocator(bytes))
+}
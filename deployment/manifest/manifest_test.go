@@ -1,6 +1,9 @@
 package manifest_test
 
 import (
+	gonet "net"
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -82,5 +85,299 @@ jobs:
 				},
 			)))
 		})
+
+		It("returns manifest with parsed release digests", func() {
+			manifestBytes := []byte(`
+name: fake-deployment
+
+networks:
+- name: net1
+  type: dynamic
+
+compilation:
+  network: net1
+
+releases:
+- name: rel1
+  version: 1
+  url: http://rel1.tgz
+  sha1: 7689AC159807BFCD91B7196B66359BD67B8697AF
+- name: rel2
+  version: 2
+  url: http://rel2.tgz
+  sha1: sha256:611b7976b701f74450e891733541198f8ba26fc05376c02b9f409771522fcd10
+- name: rel3
+  version: 3
+  url: http://rel3.tgz
+  sha1: sha256:abc
+`)
+
+			_, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"releases[2].sha1 (line 23): Expected digest 'sha256:abc' to be a hex encoded sha256 checksum"))
+
+			manifest, err := NewManifestFromBytes(manifestBytes[:strings.Index(string(manifestBytes), "- name: rel3")])
+			Expect(err).ToNot(HaveOccurred())
+
+			releases := manifest.Deployment.Releases
+			Expect(releases[0].SHA1.String()).To(Equal("7689ac159807bfcd91b7196b66359bd67b8697af"))
+			Expect(releases[1].SHA1.String()).To(Equal(
+				"sha256:611b7976b701f74450e891733541198f8ba26fc05376c02b9f409771522fcd10"))
+		})
+
+		It("returns manifest with parsed manual network subnets", func() {
+			manifestBytes := []byte(`
+name: fake-deployment
+
+networks:
+- name: net1
+  type: manual
+  subnets:
+  - range: 10.0.0.0/24
+    gateway: 10.0.0.1
+    dns: [8.8.8.8]
+    reserved: [10.0.0.2 - 10.0.0.10]
+    static: [10.0.0.100, 10.0.0.101 - 10.0.0.110]
+
+compilation:
+  network: net1
+`)
+
+			manifest, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			subnet := manifest.Deployment.Networks[0].Subnets[0]
+
+			Expect(subnet.Range.String()).To(Equal("10.0.0.0/24"))
+			Expect(subnet.Gateway.String()).To(Equal("10.0.0.1"))
+			Expect(subnet.DNS).To(Equal([]gonet.IP{gonet.ParseIP("8.8.8.8")}))
+
+			Expect(subnet.Reserved).To(Equal([]IPRange{
+				{First: gonet.ParseIP("10.0.0.2"), Last: gonet.ParseIP("10.0.0.10")},
+			}))
+
+			Expect(subnet.Static).To(Equal([]IPRange{
+				{First: gonet.ParseIP("10.0.0.100"), Last: gonet.ParseIP("10.0.0.100")},
+				{First: gonet.ParseIP("10.0.0.101"), Last: gonet.ParseIP("10.0.0.110")},
+			}))
+		})
+
+		It("returns error if manual network subnet static range is outside of subnet range", func() {
+			manifestBytes := []byte(`
+name: fake-deployment
+
+networks:
+- name: net1
+  type: manual
+  subnets:
+  - range: 10.0.0.0/24
+    gateway: 10.0.0.1
+    static: [10.0.1.100]
+
+compilation:
+  network: net1
+`)
+
+			_, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("IP range 10.0.1.100 must be within range"))
+		})
+
+		It("returns error if manual network does not have subnets", func() {
+			manifestBytes := []byte(`
+name: fake-deployment
+
+networks:
+- name: net1
+  type: manual
+
+compilation:
+  network: net1
+`)
+
+			_, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Missing subnets"))
+		})
+
+		It("returns all syntax errors with their paths and lines", func() {
+			_, err := NewManifestFromBytes([]byte(`
+name: fake-deployment
+
+networks:
+- name: net1
+  type: unknown
+
+compilation:
+  network: net1
+
+jobs:
+- name: job-1
+  networks:
+  - name: net1
+    static_ips:
+    - 10.0.0.2
+    - not-ip
+- templates: [{name: tpl}]
+`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("3 problems found:"))
+			Expect(err.Error()).To(ContainSubstring("networks[0].type (line 6): Unknown network type unknown"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].networks[0].static_ips[1] (line 17): Parsing IP not-ip"))
+			Expect(err.Error()).To(ContainSubstring("jobs[1].name (line 18): Missing job name"))
+		})
+
+		Context("when manifest is in v2 format", func() {
+			manifestBytes := []byte(`
+name: fake-deployment
+
+releases:
+- name: fake-release
+  version: 1
+  url: dir:///fake-release
+
+stemcells:
+- alias: default
+  os: ubuntu-trusty
+  version: latest
+
+instance_groups:
+- name: ig-1
+  instances: 2
+  azs: [z1]
+  vm_type: default
+  stemcell: default
+  networks:
+  - name: net1
+  jobs:
+  - name: job-1
+    release: fake-release
+    properties:
+      prop: job-val
+  - name: job-2
+    release: fake-release
+
+variables:
+- name: fake-password
+  type: password
+- name: fake-cert
+  type: certificate
+  options:
+    common_name: fake-cn
+`)
+
+			It("maps instance groups onto jobs and referenced networks onto dynamic networks", func() {
+				manifest, err := NewManifestFromBytes(manifestBytes)
+				Expect(err).ToNot(HaveOccurred())
+
+				dep := manifest.Deployment
+
+				Expect(dep.Networks).To(Equal([]Network{{Name: "net1", Type: "dynamic"}}))
+				Expect(dep.Compilation.NetworkName).To(Equal("net1"))
+
+				Expect(dep.Jobs).To(HaveLen(1))
+				Expect(dep.Jobs[0].Name).To(Equal("ig-1"))
+				Expect(dep.Jobs[0].Instances).To(Equal(2))
+				Expect(dep.Jobs[0].NetworkAssociations[0].NetworkName).To(Equal("net1"))
+
+				templates := dep.Jobs[0].Templates
+				Expect(templates).To(HaveLen(2))
+				Expect(templates[0].Name).To(Equal("job-1"))
+				Expect(templates[0].ReleaseName).To(Equal("fake-release"))
+				Expect(templates[0].Properties).To(Equal(Properties{"prop": "job-val"}))
+				Expect(templates[1].Properties).To(BeNil())
+			})
+
+			It("returns manifest with stemcells and variables", func() {
+				manifest, err := NewManifestFromBytes(manifestBytes)
+				Expect(err).ToNot(HaveOccurred())
+
+				dep := manifest.Deployment
+
+				Expect(dep.Stemcells).To(Equal([]Stemcell{
+					{Alias: "default", OS: "ubuntu-trusty", Version: "latest"},
+				}))
+
+				Expect(dep.Variables).To(HaveLen(2))
+				Expect(dep.Variables[0].Name).To(Equal("fake-password"))
+				Expect(dep.Variables[0].Type).To(Equal("password"))
+				Expect(dep.Variables[1].Options).To(Equal(map[string]interface{}{"common_name": "fake-cn"}))
+			})
+
+			It("returns error if instance group references unknown stemcell", func() {
+				_, err := NewManifestFromBytes([]byte(`
+name: fake-deployment
+instance_groups:
+- name: ig-1
+  stemcell: unknown
+  networks: [{name: net1}]
+  jobs: [{name: job-1}]
+`))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].stemcell (line 5): Unknown stemcell alias unknown"))
+			})
+
+			It("returns error if instance group is an errand", func() {
+				_, err := NewManifestFromBytes([]byte(`
+name: fake-deployment
+instance_groups:
+- name: ig-1
+  lifecycle: errand
+  networks: [{name: net1}]
+  jobs: [{name: job-1}]
+`))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].lifecycle (line 5): Errand instance groups are not supported"))
+			})
+
+			It("looks up line numbers of errors in given source bytes", func() {
+				srcBytes := []byte(`
+name: fake-deployment
+instance_groups:
+- name: ig-1
+  stemcell: ((stemcell))
+  networks: [{name: net1}]
+  jobs: [{name: job-1}]
+`)
+
+				_, err := NewManifestFromSourceBytes([]byte(`
+name: fake-deployment
+instance_groups:
+- jobs: [{name: job-1}]
+  name: ig-1
+  networks: [{name: net1}]
+  stemcell: unknown
+`), srcBytes)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].stemcell (line 5): Unknown stemcell alias unknown"))
+			})
+
+			It("does not report line numbers if source bytes are not given", func() {
+				_, err := NewManifestFromSourceBytes([]byte(`
+name: fake-deployment
+instance_groups:
+- name: ig-1
+  stemcell: unknown
+  networks: [{name: net1}]
+  jobs: [{name: job-1}]
+`), nil)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].stemcell: Unknown stemcell alias unknown"))
+			})
+
+			It("returns error if instance group has unknown lifecycle", func() {
+				_, err := NewManifestFromBytes([]byte(`
+name: fake-deployment
+instance_groups:
+- name: ig-1
+  lifecycle: unknown
+  networks: [{name: net1}]
+  jobs: [{name: job-1}]
+`))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].lifecycle (line 5): Unknown lifecycle unknown"))
+			})
+		})
 	})
 })
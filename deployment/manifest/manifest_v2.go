@@ -0,0 +1,170 @@
+package manifest
+
+import (
+	"regexp"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// DeploymentV2 represents v2 manifest format that uses
+// instance groups instead of jobs and relies on cloud config
+// for networks, VM types, AZs and compilation settings.
+type DeploymentV2 struct {
+	Name string `yaml:"name"`
+
+	Releases []Release `yaml:"releases"`
+
+	Stemcells []Stemcell `yaml:"stemcells"`
+
+	Update Update `yaml:"update"`
+
+	InstanceGroups []InstanceGroup `yaml:"instance_groups"`
+
+	Variables []Variable `yaml:"variables"`
+
+	// Not offical BOSH v2 manifest construct.
+	// Since there is no cloud config, networks referenced
+	// by instance groups are assumed to be dynamic unless specified.
+	Networks []Network `yaml:"networks"`
+
+	// Global properties (deprecated in favor of job properties)
+	PropertiesRaw map[interface{}]interface{} `yaml:"properties"`
+
+	PropertiesMerge string `yaml:"properties_merge"`
+}
+
+type InstanceGroup struct {
+	Name string `yaml:"name"`
+
+	Instances int `yaml:"instances"`
+
+	// Cloud config references are recorded but not used
+	// since all instances are placed on a single VM
+	AZs                []string `yaml:"azs"`
+	VMType             string   `yaml:"vm_type"`
+	VMExtensions       []string `yaml:"vm_extensions"`
+	Stemcell           string   `yaml:"stemcell"`
+	PersistentDiskType string   `yaml:"persistent_disk_type"`
+
+	// Only service (default) instance groups are supported
+	Lifecycle string `yaml:"lifecycle"`
+
+	Update Update `yaml:"update"`
+
+	Jobs []Template `yaml:"jobs"`
+
+	// Instance group properties (deprecated in favor of job properties)
+	PropertiesRaw map[interface{}]interface{} `yaml:"properties"`
+
+	NetworkAssociations []NetworkAssociation `yaml:"networks"`
+}
+
+const defaultV2NetworkName = "default"
+
+// isV2Manifest determines manifest format based on presence of instance groups.
+func isV2Manifest(bytes []byte) (bool, error) {
+	var keys map[interface{}]interface{}
+
+	err := candiedyaml.Unmarshal(bytes, &keys)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Parsing deployment")
+	}
+
+	_, found := keys["instance_groups"]
+
+	return found, nil
+}
+
+// newDeploymentFromV2Bytes parses v2 manifest; srcBytes are used to look up line numbers.
+func newDeploymentFromV2Bytes(bytes, srcBytes []byte) (Deployment, error) {
+	var deploymentV2 DeploymentV2
+
+	err := candiedyaml.Unmarshal(bytes, &deploymentV2)
+	if err != nil {
+		return Deployment{}, bosherr.WrapError(err, "Parsing v2 deployment")
+	}
+
+	err = NewV2SyntaxValidator(deploymentV2).Validate()
+	if err != nil {
+		err = annotateErrors(err, srcBytes, nil)
+		return Deployment{}, bosherr.WrapError(err, "Validating v2 manifest syntactically")
+	}
+
+	return deploymentV2.AsDeployment(), nil
+}
+
+var (
+	v1JobPathRegexp      = regexp.MustCompile(`\Ajobs\[`)
+	v1TemplatePathRegexp = regexp.MustCompile(`\A(instance_groups\[\d+\])\.templates\[`)
+)
+
+// v2PathFromV1Path converts v1 path of a mapped deployment to v2 manifest path,
+// e.g. jobs[1].templates[0] -> instance_groups[1].jobs[0]
+func v2PathFromV1Path(path string) string {
+	path = v1JobPathRegexp.ReplaceAllString(path, "instance_groups[")
+	return v1TemplatePathRegexp.ReplaceAllString(path, "$1.jobs[")
+}
+
+// AsDeployment maps v2 manifest onto v1 deployment structure.
+// Instance groups become jobs and instance group jobs become templates.
+func (d DeploymentV2) AsDeployment() Deployment {
+	deployment := Deployment{
+		Name: d.Name,
+
+		Releases:  d.Releases,
+		Stemcells: d.Stemcells,
+		Variables: d.Variables,
+
+		Networks: d.Networks,
+		Update:   d.Update,
+
+		PropertiesRaw:   d.PropertiesRaw,
+		PropertiesMerge: d.PropertiesMerge,
+	}
+
+	if len(deployment.Networks) == 0 {
+		deployment.Networks = d.referencedNetworks()
+	}
+
+	// Compilation section is part of cloud config
+	deployment.Compilation.NetworkName = deployment.Networks[0].Name
+
+	for _, ig := range d.InstanceGroups {
+		deployment.Jobs = append(deployment.Jobs, Job{
+			Name:      ig.Name,
+			Instances: ig.Instances,
+			Update:    ig.Update,
+			Templates: ig.Jobs,
+
+			PropertiesRaw: ig.PropertiesRaw,
+
+			NetworkAssociations: ig.NetworkAssociations,
+		})
+	}
+
+	return deployment
+}
+
+// referencedNetworks returns dynamic networks for all networks used by instance groups.
+// Default network is returned when instance groups do not reference any networks.
+func (d DeploymentV2) referencedNetworks() []Network {
+	var networks []Network
+
+	seen := map[string]struct{}{}
+
+	for _, ig := range d.InstanceGroups {
+		for _, na := range ig.NetworkAssociations {
+			if _, found := seen[na.NetworkName]; !found {
+				seen[na.NetworkName] = struct{}{}
+				networks = append(networks, Network{Name: na.NetworkName, Type: NetworkTypeDynamic})
+			}
+		}
+	}
+
+	if len(networks) == 0 {
+		networks = append(networks, Network{Name: defaultV2NetworkName, Type: NetworkTypeDynamic})
+	}
+
+	return networks
+}
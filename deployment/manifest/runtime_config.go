@@ -0,0 +1,304 @@
+package manifest
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
+)
+
+// RuntimeConfig lists releases and addon jobs that are colocated
+// with deployment jobs without being specified in the deployment manifest.
+type RuntimeConfig struct {
+	Releases []Release `yaml:"releases"`
+
+	Addons []Addon `yaml:"addons"`
+}
+
+type Addon struct {
+	Name string `yaml:"name"`
+
+	Jobs []Template `yaml:"jobs"`
+
+	// Used by addon jobs that do not specify their own properties.
+	// Non-raw field is populated by the validator.
+	PropertiesRaw map[interface{}]interface{} `yaml:"properties"`
+	Properties    Properties
+
+	// Addon is placed onto all jobs if include rules are not specified
+	Include *AddonFilter `yaml:"include"`
+	Exclude *AddonFilter `yaml:"exclude"`
+}
+
+// AddonFilter matches deployment jobs by deployment name
+// and by job templates they include. All specified rules must match.
+type AddonFilter struct {
+	Deployments []string         `yaml:"deployments"`
+	Jobs        []AddonFilterJob `yaml:"jobs"`
+}
+
+type AddonFilterJob struct {
+	Name        string `yaml:"name"`
+	ReleaseName string `yaml:"release"`
+}
+
+func NewRuntimeConfigFromBytes(bytes []byte) (RuntimeConfig, error) {
+	var config RuntimeConfig
+
+	err := candiedyaml.Unmarshal(bytes, &config)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Parsing runtime config")
+	}
+
+	err = NewRuntimeConfigSyntaxValidator(&config).Validate()
+	if err != nil {
+		if verrs, ok := err.(bpvalid.Errors); ok {
+			err = verrs.WithLines(bpvalid.NewLineLocator(bytes))
+		}
+
+		return config, bosherr.WrapError(err, "Validating runtime config syntactically")
+	}
+
+	return config, nil
+}
+
+// Matches returns true if addon should be colocated with a deployment job
+func (a Addon) Matches(deploymentName string, job Job) bool {
+	if a.Include != nil && !a.Include.Matches(deploymentName, job) {
+		return false
+	}
+
+	if a.Exclude != nil && a.Exclude.Matches(deploymentName, job) {
+		return false
+	}
+
+	return true
+}
+
+// Matches returns true if all specified rules match; empty filter matches everything
+func (f AddonFilter) Matches(deploymentName string, job Job) bool {
+	if len(f.Deployments) > 0 && !f.matchesDeployment(deploymentName) {
+		return false
+	}
+
+	if len(f.Jobs) > 0 && !f.matchesJob(job) {
+		return false
+	}
+
+	return true
+}
+
+func (f AddonFilter) matchesDeployment(deploymentName string) bool {
+	for _, name := range f.Deployments {
+		if name == deploymentName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f AddonFilter) matchesJob(job Job) bool {
+	for _, filterJob := range f.Jobs {
+		for _, template := range job.Templates {
+			if template.Name == filterJob.Name && template.ReleaseName == filterJob.ReleaseName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ApplyRuntimeConfig adds runtime config releases to the deployment
+// and colocates addon jobs with matching deployment jobs.
+// Addon jobs only receive their own or addon level properties.
+func (m *Manifest) ApplyRuntimeConfig(config RuntimeConfig) error {
+	c := bpvalid.NewCollector()
+
+	dep := &m.Deployment
+
+	// Templates of single release deployments might omit release name;
+	// it's resolved before runtime config releases are added
+	// so that such templates keep their release and match addon filters.
+	m.resolveDefaultReleaseNames()
+
+	for i, release := range config.Releases {
+		m.addRuntimeConfigRelease(release, c.Key("releases").Index(i))
+	}
+
+	for i, addon := range config.Addons {
+		addonC := c.Key("addons").Index(i)
+
+		for j := range dep.Jobs {
+			if addon.Matches(dep.Name, dep.Jobs[j]) {
+				m.addAddonJobs(addon, &dep.Jobs[j], addonC)
+			}
+		}
+	}
+
+	err := c.Errors()
+	if err != nil {
+		return bosherr.WrapError(err, "Applying runtime config")
+	}
+
+	return nil
+}
+
+func (m *Manifest) resolveDefaultReleaseNames() {
+	dep := &m.Deployment
+
+	if len(dep.Releases) != 1 {
+		return
+	}
+
+	for i := range dep.Jobs {
+		for j := range dep.Jobs[i].Templates {
+			template := &dep.Jobs[i].Templates[j]
+
+			if template.ReleaseName == "" {
+				template.ReleaseName = dep.Releases[0].Name
+			}
+		}
+	}
+}
+
+func (m *Manifest) addRuntimeConfigRelease(release Release, c bpvalid.Collector) {
+	for _, depRelease := range m.Deployment.Releases {
+		if depRelease.Name != release.Name {
+			continue
+		}
+
+		if depRelease.Version != release.Version || depRelease.URL != release.URL {
+			c.Add(bosherr.Errorf(
+				"Release '%s' does not match deployment release with the same name", release.Name))
+		}
+
+		return
+	}
+
+	m.Deployment.Releases = append(m.Deployment.Releases, release)
+}
+
+func (m *Manifest) addAddonJobs(addon Addon, job *Job, c bpvalid.Collector) {
+	for i, addonJob := range addon.Jobs {
+		if job.hasTemplate(addonJob.Name) {
+			c.Key("jobs").Index(i).Add(bosherr.Errorf(
+				"Job '%s' is already colocated with deployment job '%s'", addonJob.Name, job.Name))
+			continue
+		}
+
+		if addonJob.Properties == nil {
+			addonJob.Properties = addon.Properties
+		}
+
+		// Addon jobs do not receive deployment properties
+		if addonJob.Properties == nil {
+			addonJob.Properties = Properties{}
+		}
+
+		job.Templates = append(job.Templates, addonJob)
+	}
+}
+
+func (j Job) hasTemplate(name string) bool {
+	for _, template := range j.Templates {
+		if template.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RuntimeConfigSyntaxValidator parses and saves all runtime config values.
+type RuntimeConfigSyntaxValidator struct {
+	config *RuntimeConfig
+}
+
+func NewRuntimeConfigSyntaxValidator(config *RuntimeConfig) RuntimeConfigSyntaxValidator {
+	if config == nil {
+		panic("Expected runtime config to not be nil")
+	}
+
+	return RuntimeConfigSyntaxValidator{config: config}
+}
+
+func (v RuntimeConfigSyntaxValidator) Validate() error {
+	c := bpvalid.NewCollector()
+
+	depValidator := SyntaxValidator{deployment: &Deployment{}}
+
+	for i := range v.config.Releases {
+		depValidator.validateRelease(&v.config.Releases[i], c.Key("releases").Index(i))
+	}
+
+	for i := range v.config.Addons {
+		v.validateAddon(&v.config.Addons[i], depValidator, c.Key("addons").Index(i))
+	}
+
+	return c.Errors()
+}
+
+func (v RuntimeConfigSyntaxValidator) validateAddon(addon *Addon, depValidator SyntaxValidator, c bpvalid.Collector) {
+	if addon.Name == "" {
+		c.Key("name").Add(bosherr.Error("Missing addon name"))
+	}
+
+	if len(addon.Jobs) == 0 {
+		c.Key("jobs").Add(bosherr.Error("Missing addon jobs"))
+	}
+
+	for i := range addon.Jobs {
+		jobC := c.Key("jobs").Index(i)
+
+		depValidator.validateTemplate(&addon.Jobs[i], jobC)
+
+		if addon.Jobs[i].ReleaseName == "" {
+			jobC.Key("release").Add(bosherr.Error("Missing release name"))
+		} else if !v.hasRelease(addon.Jobs[i].ReleaseName) {
+			jobC.Key("release").Add(bosherr.Errorf(
+				"Release '%s' must be listed in runtime config releases", addon.Jobs[i].ReleaseName))
+		}
+	}
+
+	if addon.PropertiesRaw != nil {
+		props, err := bputil.NewStringKeyed().ConvertMap(addon.PropertiesRaw)
+		if err != nil {
+			c.Key("properties").Add(err)
+		}
+
+		addon.Properties = props
+	}
+
+	if addon.Include != nil {
+		v.validateFilter(*addon.Include, c.Key("include"))
+	}
+
+	if addon.Exclude != nil {
+		v.validateFilter(*addon.Exclude, c.Key("exclude"))
+	}
+}
+
+func (v RuntimeConfigSyntaxValidator) validateFilter(filter AddonFilter, c bpvalid.Collector) {
+	for i, job := range filter.Jobs {
+		if job.Name == "" {
+			c.Key("jobs").Index(i).Key("name").Add(bosherr.Error("Missing job name"))
+		}
+
+		if job.ReleaseName == "" {
+			c.Key("jobs").Index(i).Key("release").Add(bosherr.Error("Missing release name"))
+		}
+	}
+}
+
+func (v RuntimeConfigSyntaxValidator) hasRelease(name string) bool {
+	for _, release := range v.config.Releases {
+		if release.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,169 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+)
+
+var _ = Describe("RuntimeConfig", func() {
+	manifestBytes := []byte(`
+name: fake-deployment
+
+releases:
+- {name: rel1, version: 1, url: "dir:///rel1"}
+
+networks:
+- {name: net1, type: dynamic}
+
+compilation: {network: net1}
+
+jobs:
+- name: web
+  templates: [{name: nginx, release: rel1}]
+- name: worker
+  templates: [{name: sidekiq, release: rel1}]
+`)
+
+	applyRuntimeConfig := func(configBytes string) (Manifest, error) {
+		manifest, err := NewManifestFromBytes(manifestBytes)
+		Expect(err).ToNot(HaveOccurred())
+
+		config, err := NewRuntimeConfigFromBytes([]byte(configBytes))
+		if err != nil {
+			return manifest, err
+		}
+
+		err = manifest.ApplyRuntimeConfig(config)
+
+		return manifest, err
+	}
+
+	templateNames := func(job Job) []string {
+		var names []string
+
+		for _, template := range job.Templates {
+			names = append(names, template.Name)
+		}
+
+		return names
+	}
+
+	Describe("ApplyRuntimeConfig", func() {
+		It("colocates addon jobs with all deployment jobs if include rules are not specified", func() {
+			manifest, err := applyRuntimeConfig(`
+releases:
+- {name: addon-rel, version: 2, url: "dir:///addon-rel"}
+addons:
+- name: logs
+  jobs:
+  - name: syslog-forwarder
+    release: addon-rel
+    properties: {address: 10.0.0.5}
+  properties: {ignored: true}
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			jobs := manifest.Deployment.Jobs
+			Expect(templateNames(jobs[0])).To(Equal([]string{"nginx", "syslog-forwarder"}))
+			Expect(templateNames(jobs[1])).To(Equal([]string{"sidekiq", "syslog-forwarder"}))
+
+			Expect(jobs[0].Templates[1].ReleaseName).To(Equal("addon-rel"))
+			Expect(jobs[0].Templates[1].Properties).To(Equal(Properties{"address": "10.0.0.5"}))
+
+			Expect(manifest.Deployment.Releases).To(HaveLen(2))
+		})
+
+		It("uses addon properties for addon jobs without properties and never deployment properties", func() {
+			manifest, err := applyRuntimeConfig(`
+releases:
+- {name: addon-rel, version: 2, url: "dir:///addon-rel"}
+addons:
+- name: logs
+  jobs: [{name: syslog-forwarder, release: addon-rel}]
+  properties: {address: 10.0.0.5}
+- name: metrics
+  jobs: [{name: node-exporter, release: addon-rel}]
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			templates := manifest.Deployment.Jobs[0].Templates
+			Expect(templates[1].Properties).To(Equal(Properties{"address": "10.0.0.5"}))
+			Expect(templates[2].Properties).To(Equal(Properties{}))
+		})
+
+		It("colocates addon jobs only with deployment jobs matching include and not matching exclude rules", func() {
+			manifest, err := applyRuntimeConfig(`
+releases:
+- {name: addon-rel, version: 2, url: "dir:///addon-rel"}
+addons:
+- name: included
+  jobs: [{name: included-job, release: addon-rel}]
+  include:
+    deployments: [fake-deployment]
+    jobs: [{name: nginx, release: rel1}]
+- name: excluded
+  jobs: [{name: excluded-job, release: addon-rel}]
+  exclude:
+    jobs: [{name: nginx, release: rel1}]
+- name: other-deployment
+  jobs: [{name: other-job, release: addon-rel}]
+  include:
+    deployments: [other-deployment]
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			jobs := manifest.Deployment.Jobs
+			Expect(templateNames(jobs[0])).To(Equal([]string{"nginx", "included-job"}))
+			Expect(templateNames(jobs[1])).To(Equal([]string{"sidekiq", "excluded-job"}))
+		})
+
+		It("returns error if addon job is already colocated with deployment job", func() {
+			_, err := applyRuntimeConfig(`
+releases:
+- {name: rel1, version: 1, url: "dir:///rel1"}
+addons:
+- name: dup
+  jobs: [{name: nginx, release: rel1}]
+`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"addons[0].jobs[0]: Job 'nginx' is already colocated with deployment job 'web'"))
+		})
+
+		It("returns error if runtime config release conflicts with deployment release", func() {
+			_, err := applyRuntimeConfig(`
+releases:
+- {name: rel1, version: 2, url: "dir:///rel1"}
+addons:
+- name: logs
+  jobs: [{name: syslog-forwarder, release: rel1}]
+`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"releases[0]: Release 'rel1' does not match deployment release with the same name"))
+		})
+	})
+
+	Describe("NewRuntimeConfigFromBytes", func() {
+		It("returns all syntax errors with their paths and lines", func() {
+			_, err := NewRuntimeConfigFromBytes([]byte(`
+releases:
+- {name: addon-rel, version: 2, url: "dir:///addon-rel"}
+addons:
+- jobs:
+  - name: syslog-forwarder
+    release: unknown-rel
+  include:
+    jobs: [{name: nginx}]
+`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("3 problems found"))
+			Expect(err.Error()).To(ContainSubstring("addons[0].name (line 5): Missing addon name"))
+			Expect(err.Error()).To(ContainSubstring(
+				"addons[0].jobs[0].release (line 7): Release 'unknown-rel' must be listed in runtime config releases"))
+			Expect(err.Error()).To(ContainSubstring("addons[0].include.jobs[0].release"))
+		})
+	})
+})
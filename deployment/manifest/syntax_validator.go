@@ -1,15 +1,19 @@
 package manifest
 
 import (
+	gonet "net"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	bputil "github.com/cppforlife/bosh-provisioner/util"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
 )
 
 // SyntaxValidator parses and saves all manifest values to determine
 // their syntactic validity. Determining if individual values make sense
 // in a greater context (within a deployment or a job) is outside of scope.
 // e.g. - can watch time string value be parsed into a time range?
+// All found problems are returned together as bpvalid.Errors.
 type SyntaxValidator struct {
 	deployment *Deployment
 }
@@ -23,164 +27,299 @@ func NewSyntaxValidator(manifest *Manifest) SyntaxValidator {
 }
 
 func (v SyntaxValidator) Validate() error {
+	c := bpvalid.NewCollector()
+
 	if v.deployment.Name == "" {
-		return bosherr.Error("Missing deployment name")
+		c.Key("name").Add(bosherr.Error("Missing deployment name"))
+	}
+
+	v.validateUpdate(&v.deployment.Update, c.Key("update"))
+
+	for i := range v.deployment.Networks {
+		v.validateNetwork(&v.deployment.Networks[i], c.Key("networks").Index(i))
+	}
+
+	for i := range v.deployment.Releases {
+		v.validateRelease(&v.deployment.Releases[i], c.Key("releases").Index(i))
+	}
+
+	v.validateCompilation(&v.deployment.Compilation, c.Key("compilation"))
+
+	for i := range v.deployment.Jobs {
+		v.validateJob(&v.deployment.Jobs[i], c.Key("jobs").Index(i))
+	}
+
+	for i := range v.deployment.Variables {
+		v.validateVariable(&v.deployment.Variables[i], c.Key("variables").Index(i))
 	}
 
-	err := v.validateUpdate(&v.deployment.Update)
+	props, err := bputil.NewStringKeyed().ConvertMap(v.deployment.PropertiesRaw)
 	if err != nil {
-		return bosherr.WrapError(err, "Deployment update")
+		c.Key("properties").Add(err)
 	}
 
-	for i, net := range v.deployment.Networks {
-		err := v.validateNetwork(&v.deployment.Networks[i])
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Network %s (%d)", net.Name, i)
-		}
+	v.deployment.Properties = props
+
+	v.validatePropertiesMerge(v.deployment.PropertiesMerge, c.Key("properties_merge"))
+
+	return c.Errors()
+}
+
+func (v SyntaxValidator) validatePropertiesMerge(merge string, c bpvalid.Collector) {
+	if merge == "" {
+		return
 	}
 
-	for i, release := range v.deployment.Releases {
-		err := v.validateRelease(&v.deployment.Releases[i])
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Release %s (%d)", release.Name, i)
+	for _, m := range PropertiesMerges {
+		if merge == m {
+			return
 		}
 	}
 
-	err = v.validateCompilation(&v.deployment.Compilation)
-	if err != nil {
-		return bosherr.WrapError(err, "Compilation")
+	c.Add(bosherr.Errorf("Unknown properties merge %s", merge))
+}
+
+func (v SyntaxValidator) validateNetwork(network *Network, c bpvalid.Collector) {
+	if network.Name == "" {
+		c.Key("name").Add(bosherr.Error("Missing network name"))
 	}
 
-	for i, job := range v.deployment.Jobs {
-		err := v.validateJob(&v.deployment.Jobs[i])
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Job %s (%d)", job.Name, i)
+	if !v.validateNetworkType(network.Type, c.Key("type")) {
+		return
+	}
+
+	if network.Type != NetworkTypeManual {
+		if len(network.Subnets) > 0 {
+			c.Key("subnets").Add(bosherr.Errorf("Subnets are only allowed for %s networks", NetworkTypeManual))
 		}
+
+		return
 	}
 
-	props, err := bputil.NewStringKeyed().ConvertMap(v.deployment.PropertiesRaw)
+	if len(network.Subnets) == 0 {
+		c.Key("subnets").Add(bosherr.Error("Missing subnets"))
+	}
+
+	for i := range network.Subnets {
+		v.validateSubnet(&network.Subnets[i], c.Key("subnets").Index(i))
+	}
+}
+
+func (v SyntaxValidator) validateSubnet(subnet *Subnet, c bpvalid.Collector) {
+	if subnet.RangeRaw == "" {
+		c.Key("range").Add(bosherr.Error("Missing range"))
+		return
+	}
+
+	_, ipNet, err := gonet.ParseCIDR(subnet.RangeRaw)
 	if err != nil {
-		return bosherr.WrapError(err, "Deployment properties")
+		c.Key("range").Add(bosherr.WrapError(err, "Parsing range"))
+		return
 	}
 
-	v.deployment.Properties = props
+	subnet.Range = ipNet
+
+	if subnet.GatewayRaw == "" {
+		c.Key("gateway").Add(bosherr.Error("Missing gateway"))
+	} else if gateway := gonet.ParseIP(subnet.GatewayRaw); gateway == nil {
+		c.Key("gateway").Add(bosherr.Errorf("Parsing gateway %s", subnet.GatewayRaw))
+	} else if !ipNet.Contains(gateway) {
+		c.Key("gateway").Add(bosherr.Errorf("Gateway %s must be within range", subnet.GatewayRaw))
+	} else {
+		subnet.Gateway = gateway
+	}
 
-	return nil
+	for i, dnsRaw := range subnet.DNSRaw {
+		ips, err := NewIPsFromStrings([]string{dnsRaw})
+		if err != nil {
+			c.Key("dns").Index(i).Add(err)
+		} else {
+			subnet.DNS = append(subnet.DNS, ips...)
+		}
+	}
+
+	subnet.Reserved = v.validateSubnetRanges(ipNet, subnet.ReservedRaw, c.Key("reserved"))
+	subnet.Static = v.validateSubnetRanges(ipNet, subnet.StaticRaw, c.Key("static"))
 }
 
-func (v SyntaxValidator) validateNetwork(network *Network) error {
-	if network.Name == "" {
-		return bosherr.Error("Missing network name")
+func (v SyntaxValidator) validateSubnetRanges(ipNet *gonet.IPNet, rangesRaw []string, c bpvalid.Collector) []IPRange {
+	var result []IPRange
+
+	for i, rangeRaw := range rangesRaw {
+		ranges, err := NewIPRangesFromStrings([]string{rangeRaw})
+		if err != nil {
+			c.Index(i).Add(err)
+			continue
+		}
+
+		ipRange := ranges[0]
+
+		if !ipNet.Contains(ipRange.First) || !ipNet.Contains(ipRange.Last) {
+			c.Index(i).Add(bosherr.Errorf("IP range %s must be within range", ipRange))
+			continue
+		}
+
+		result = append(result, ipRange)
 	}
 
-	return v.validateNetworkType(network.Type)
+	return result
 }
 
-func (v SyntaxValidator) validateNetworkType(networkType string) error {
+func (v SyntaxValidator) validateNetworkType(networkType string, c bpvalid.Collector) bool {
 	if networkType == "" {
-		return bosherr.Error("Missing network type")
+		c.Add(bosherr.Error("Missing network type"))
+		return false
 	}
 
 	for _, t := range NetworkTypes {
 		if networkType == t {
-			return nil
+			return true
 		}
 	}
 
-	return bosherr.Errorf("Unknown network type %s", networkType)
+	c.Add(bosherr.Errorf("Unknown network type %s", networkType))
+
+	return false
 }
 
-func (v SyntaxValidator) validateRelease(release *Release) error {
+func (v SyntaxValidator) validateRelease(release *Release, c bpvalid.Collector) {
 	if release.Name == "" {
-		return bosherr.Error("Missing release name")
+		c.Key("name").Add(bosherr.Error("Missing release name"))
 	}
 
 	if release.Version == "" {
-		return bosherr.Error("Missing release version")
+		c.Key("version").Add(bosherr.Error("Missing release version"))
 	}
 
 	if release.URL == "" {
-		return bosherr.Error("Missing release URL")
+		c.Key("url").Add(bosherr.Error("Missing release URL"))
 	}
 
-	return nil
+	if release.SHA1Raw != "" {
+		digest, err := bputil.NewDigestFromString(release.SHA1Raw)
+		if err != nil {
+			c.Key("sha1").Add(err)
+		} else {
+			release.SHA1 = digest
+		}
+	}
 }
 
-func (v SyntaxValidator) validateCompilation(compilation *Compilation) error {
+func (v SyntaxValidator) validateCompilation(compilation *Compilation, c bpvalid.Collector) {
 	if compilation.NetworkName == "" {
-		return bosherr.Error("Missing network name")
+		c.Key("network").Add(bosherr.Error("Missing network name"))
 	}
-
-	return nil
 }
 
-func (v SyntaxValidator) validateJob(job *Job) error {
+func (v SyntaxValidator) validateJob(job *Job, c bpvalid.Collector) {
 	if job.Name == "" {
-		return bosherr.Error("Missing job name")
+		c.Key("name").Add(bosherr.Error("Missing job name"))
 	}
 
 	if job.Template != nil {
-		return bosherr.Error("'template' is deprecated in favor of 'templates'")
+		c.Key("template").Add(bosherr.Error("'template' is deprecated in favor of 'templates'"))
 	}
 
-	err := v.validateUpdate(&job.Update)
-	if err != nil {
-		return bosherr.WrapError(err, "Update")
-	}
+	v.validateUpdate(&job.Update, c.Key("update"))
 
 	props, err := bputil.NewStringKeyed().ConvertMap(job.PropertiesRaw)
 	if err != nil {
-		return bosherr.WrapError(err, "Properties")
+		c.Key("properties").Add(err)
 	}
 
 	job.Properties = props
 
-	for i, na := range job.NetworkAssociations {
-		err := v.validateNetworkAssociation(&job.NetworkAssociations[i])
+	for i := range job.Templates {
+		v.validateTemplate(&job.Templates[i], c.Key("templates").Index(i))
+	}
+
+	for i := range job.NetworkAssociations {
+		v.validateNetworkAssociation(&job.NetworkAssociations[i], c.Key("networks").Index(i))
+	}
+}
+
+func (v SyntaxValidator) validateTemplate(template *Template, c bpvalid.Collector) {
+	if template.Name == "" {
+		c.Key("name").Add(bosherr.Error("Missing template name"))
+	}
+
+	if template.PropertiesRaw != nil {
+		props, err := bputil.NewStringKeyed().ConvertMap(template.PropertiesRaw)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Network association %s (%d)", na.NetworkName, i)
+			c.Key("properties").Add(err)
+		}
+
+		template.Properties = props
+	}
+
+	for name, provides := range template.Provides {
+		if provides.As == "" {
+			c.Key("provides").Key(name).Key("as").Add(bosherr.Error("Missing link alias"))
+		}
+	}
+
+	for name, consumes := range template.Consumes {
+		if consumes.From == "" {
+			c.Key("consumes").Key(name).Key("from").Add(bosherr.Error("Missing link source"))
 		}
 	}
+}
+
+func (v SyntaxValidator) validateVariable(variable *Variable, c bpvalid.Collector) {
+	if variable.Name == "" {
+		c.Key("name").Add(bosherr.Error("Missing variable name"))
+	}
+
+	if variable.Type == "" {
+		c.Key("type").Add(bosherr.Error("Missing variable type"))
+	}
 
-	return nil
+	options, err := bputil.NewStringKeyed().ConvertMap(variable.OptionsRaw)
+	if err != nil {
+		c.Key("options").Add(err)
+	}
+
+	variable.Options = options
 }
 
 // validateUpdate validates deployment level or job level update section
-func (v SyntaxValidator) validateUpdate(update *Update) error {
+func (v SyntaxValidator) validateUpdate(update *Update, c bpvalid.Collector) {
 	if update.CanaryWatchTimeRaw != nil {
 		watchTime, err := NewWatchTimeFromString(*update.CanaryWatchTimeRaw)
 		if err != nil {
-			return bosherr.WrapError(err, "Canary watch time")
+			c.Key("canary_watch_time").Add(err)
+		} else {
+			update.CanaryWatchTime = &watchTime
 		}
-
-		update.CanaryWatchTime = &watchTime
 	}
 
 	if update.UpdateWatchTimeRaw != nil {
 		watchTime, err := NewWatchTimeFromString(*update.UpdateWatchTimeRaw)
 		if err != nil {
-			return bosherr.WrapError(err, "Update watch time")
+			c.Key("update_watch_time").Add(err)
+		} else {
+			update.UpdateWatchTime = &watchTime
 		}
-
-		update.UpdateWatchTime = &watchTime
 	}
-
-	return nil
 }
 
-func (v SyntaxValidator) validateNetworkAssociation(na *NetworkAssociation) error {
+func (v SyntaxValidator) validateNetworkAssociation(na *NetworkAssociation, c bpvalid.Collector) {
 	if na.NetworkName == "" {
-		return bosherr.Error("Missing network name")
+		c.Key("name").Add(bosherr.Error("Missing network name"))
 	}
 
-	if na.StaticIPsRaw != nil {
-		ips, err := NewIPsFromStrings(na.StaticIPsRaw)
+	if na.StaticIPsRaw == nil {
+		return
+	}
+
+	na.StaticIPs = []gonet.IP{}
+
+	for i, ipRaw := range na.StaticIPsRaw {
+		ips, err := NewIPsFromStrings([]string{ipRaw})
 		if err != nil {
-			return bosherr.WrapError(err, "Static IPs")
+			c.Key("static_ips").Index(i).Add(err)
+		} else {
+			na.StaticIPs = append(na.StaticIPs, ips...)
 		}
-
-		na.StaticIPs = ips
 	}
-
-	return nil
 }
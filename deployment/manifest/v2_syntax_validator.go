@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
+)
+
+// V2SyntaxValidator validates v2 specific manifest sections.
+// Sections shared with v1 manifests (releases, update, properties, etc.)
+// are validated by SyntaxValidator after v2 manifest is mapped onto v1 structure.
+type V2SyntaxValidator struct {
+	deployment DeploymentV2
+}
+
+func NewV2SyntaxValidator(deployment DeploymentV2) V2SyntaxValidator {
+	return V2SyntaxValidator{deployment: deployment}
+}
+
+func (v V2SyntaxValidator) Validate() error {
+	c := bpvalid.NewCollector()
+
+	if v.deployment.Name == "" {
+		c.Key("name").Add(bosherr.Error("Missing deployment name"))
+	}
+
+	for i, stemcell := range v.deployment.Stemcells {
+		v.validateStemcell(stemcell, c.Key("stemcells").Index(i))
+	}
+
+	for i, ig := range v.deployment.InstanceGroups {
+		v.validateInstanceGroup(ig, c.Key("instance_groups").Index(i))
+	}
+
+	return c.Errors()
+}
+
+func (v V2SyntaxValidator) validateStemcell(stemcell Stemcell, c bpvalid.Collector) {
+	if stemcell.Alias == "" {
+		c.Key("alias").Add(bosherr.Error("Missing stemcell alias"))
+	}
+
+	if stemcell.OS == "" && stemcell.Name == "" {
+		c.Add(bosherr.Error("Missing stemcell os or name"))
+	}
+
+	if stemcell.Version == "" {
+		c.Key("version").Add(bosherr.Error("Missing stemcell version"))
+	}
+}
+
+func (v V2SyntaxValidator) validateInstanceGroup(ig InstanceGroup, c bpvalid.Collector) {
+	if ig.Name == "" {
+		c.Key("name").Add(bosherr.Error("Missing instance group name"))
+	}
+
+	if len(ig.Jobs) == 0 {
+		c.Key("jobs").Add(bosherr.Error("Missing jobs"))
+	}
+
+	if len(ig.NetworkAssociations) == 0 {
+		c.Key("networks").Add(bosherr.Error("Missing networks"))
+	}
+
+	if ig.Stemcell != "" && !v.hasStemcell(ig.Stemcell) {
+		c.Key("stemcell").Add(bosherr.Errorf("Unknown stemcell alias %s", ig.Stemcell))
+	}
+
+	// Errands are not run; provisioning them as regular jobs would start their processes
+	switch ig.Lifecycle {
+	case "", "service":
+	case "errand":
+		c.Key("lifecycle").Add(bosherr.Error("Errand instance groups are not supported"))
+	default:
+		c.Key("lifecycle").Add(bosherr.Errorf("Unknown lifecycle %s", ig.Lifecycle))
+	}
+}
+
+func (v V2SyntaxValidator) hasStemcell(alias string) bool {
+	for _, stemcell := range v.deployment.Stemcells {
+		if stemcell.Alias == alias {
+			return true
+		}
+	}
+
+	return false
+}
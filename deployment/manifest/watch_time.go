@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -41,3 +42,6 @@ func NewWatchTimeFromString(str string) (WatchTime, error) {
 
 func (wt WatchTime) Start() int { return wt[0] }
 func (wt WatchTime) End() int   { return wt[1] }
+
+// String returns watch time in the same format as it's specified in the manifest
+func (wt WatchTime) String() string { return fmt.Sprintf("%d-%d", wt[0], wt[1]) }
@@ -0,0 +1,252 @@
+package manifest
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Canonical structures mirror manifest structures
+// but only include values interpreted by the validator.
+type canonicalDeployment struct {
+	Name string `yaml:"name"`
+
+	Releases    []canonicalRelease   `yaml:"releases,omitempty"`
+	Networks    []canonicalNetwork   `yaml:"networks,omitempty"`
+	Compilation canonicalCompilation `yaml:"compilation"`
+	Update      canonicalUpdate      `yaml:"update"`
+	Jobs        []canonicalJob       `yaml:"jobs,omitempty"`
+	Stemcells   []Stemcell           `yaml:"stemcells,omitempty"`
+	Variables   []canonicalVariable  `yaml:"variables,omitempty"`
+
+	Properties      Properties `yaml:"properties"`
+	PropertiesMerge string     `yaml:"properties_merge,omitempty"`
+}
+
+type canonicalRelease struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	URL     string `yaml:"url,omitempty"`
+	SHA1    string `yaml:"sha1,omitempty"`
+}
+
+type canonicalNetwork struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	Subnets []canonicalSubnet `yaml:"subnets,omitempty"`
+}
+
+type canonicalSubnet struct {
+	Range    string   `yaml:"range"`
+	Gateway  string   `yaml:"gateway"`
+	DNS      []string `yaml:"dns,omitempty"`
+	Reserved []string `yaml:"reserved,omitempty"`
+	Static   []string `yaml:"static,omitempty"`
+}
+
+type canonicalCompilation struct {
+	NetworkName string `yaml:"network,omitempty"`
+}
+
+type canonicalUpdate struct {
+	Canaries        *int   `yaml:"canaries,omitempty"`
+	MaxInFlight     *int   `yaml:"max_in_flight,omitempty"`
+	CanaryWatchTime string `yaml:"canary_watch_time,omitempty"`
+	UpdateWatchTime string `yaml:"update_watch_time,omitempty"`
+}
+
+type canonicalJob struct {
+	Name      string `yaml:"name"`
+	Instances int    `yaml:"instances"`
+
+	Update canonicalUpdate `yaml:"update"`
+
+	Templates []canonicalTemplate `yaml:"templates,omitempty"`
+
+	Properties Properties `yaml:"properties,omitempty"`
+
+	NetworkAssociations []canonicalNetworkAssociation `yaml:"networks,omitempty"`
+}
+
+type canonicalTemplate struct {
+	Name        string `yaml:"name"`
+	ReleaseName string `yaml:"release,omitempty"`
+
+	Properties Properties `yaml:"properties,omitempty"`
+
+	Provides map[string]LinkProvides `yaml:"provides,omitempty"`
+	Consumes map[string]LinkConsumes `yaml:"consumes,omitempty"`
+}
+
+type canonicalNetworkAssociation struct {
+	NetworkName string   `yaml:"name"`
+	StaticIPs   []string `yaml:"static_ips,omitempty"`
+}
+
+type canonicalVariable struct {
+	Name    string                 `yaml:"name"`
+	Type    string                 `yaml:"type"`
+	Options map[string]interface{} `yaml:"options,omitempty"`
+}
+
+// Bytes returns manifest as YAML in v1 format (v2 manifests are converted).
+// Watch times are resolved for each job, hence result shows values used by the provisioner
+// and can be read back. Job properties are kept as declared since global properties
+// are merged into them when manifest is read (see Deployment.InstanceProperties).
+func (m Manifest) Bytes() ([]byte, error) {
+	bytes, err := candiedyaml.Marshal(m.canonicalDeployment())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling manifest")
+	}
+
+	return bytes, nil
+}
+
+func (m Manifest) canonicalDeployment() canonicalDeployment {
+	dep := m.Deployment
+
+	result := canonicalDeployment{
+		Name: dep.Name,
+
+		Compilation: canonicalCompilation{NetworkName: dep.Compilation.NetworkName},
+		Update:      canonicalUpdateFromUpdate(dep.Update),
+		Stemcells:   dep.Stemcells,
+
+		Properties:      nonNilProperties(dep.Properties),
+		PropertiesMerge: dep.PropertiesMerge,
+	}
+
+	for _, release := range dep.Releases {
+		result.Releases = append(result.Releases, canonicalRelease{
+			Name:    release.Name,
+			Version: release.Version,
+			URL:     release.URL,
+			SHA1:    release.SHA1.String(),
+		})
+	}
+
+	for _, network := range dep.Networks {
+		result.Networks = append(result.Networks, canonicalNetworkFromNetwork(network))
+	}
+
+	for _, job := range dep.Jobs {
+		result.Jobs = append(result.Jobs, m.canonicalJob(job))
+	}
+
+	for _, variable := range dep.Variables {
+		result.Variables = append(result.Variables, canonicalVariable{
+			Name:    variable.Name,
+			Type:    variable.Type,
+			Options: variable.Options,
+		})
+	}
+
+	return result
+}
+
+func (m Manifest) canonicalJob(job Job) canonicalJob {
+	dep := m.Deployment
+
+	canaryWatchTime := dep.CanaryWatchTime(job)
+	updateWatchTime := dep.UpdateWatchTime(job)
+
+	result := canonicalJob{
+		Name:      job.Name,
+		Instances: job.Instances,
+
+		Update: canonicalUpdate{
+			Canaries:        job.Update.Canaries,
+			MaxInFlight:     job.Update.MaxInFlight,
+			CanaryWatchTime: canaryWatchTime.String(),
+			UpdateWatchTime: updateWatchTime.String(),
+		},
+
+		Properties: job.Properties,
+	}
+
+	if result.Update.Canaries == nil {
+		result.Update.Canaries = dep.Update.Canaries
+	}
+
+	if result.Update.MaxInFlight == nil {
+		result.Update.MaxInFlight = dep.Update.MaxInFlight
+	}
+
+	for _, template := range job.Templates {
+		result.Templates = append(result.Templates, canonicalTemplate{
+			Name:        template.Name,
+			ReleaseName: template.ReleaseName,
+			Properties:  template.Properties,
+			Provides:    template.Provides,
+			Consumes:    template.Consumes,
+		})
+	}
+
+	for _, na := range job.NetworkAssociations {
+		canonicalNA := canonicalNetworkAssociation{NetworkName: na.NetworkName}
+
+		for _, ip := range na.StaticIPs {
+			canonicalNA.StaticIPs = append(canonicalNA.StaticIPs, ip.String())
+		}
+
+		result.NetworkAssociations = append(result.NetworkAssociations, canonicalNA)
+	}
+
+	return result
+}
+
+func canonicalNetworkFromNetwork(network Network) canonicalNetwork {
+	result := canonicalNetwork{Name: network.Name, Type: network.Type}
+
+	for _, subnet := range network.Subnets {
+		canonicalSubnet := canonicalSubnet{}
+
+		if subnet.Range != nil {
+			canonicalSubnet.Range = subnet.Range.String()
+		}
+
+		if subnet.Gateway != nil {
+			canonicalSubnet.Gateway = subnet.Gateway.String()
+		}
+
+		for _, ip := range subnet.DNS {
+			canonicalSubnet.DNS = append(canonicalSubnet.DNS, ip.String())
+		}
+
+		for _, ipRange := range subnet.Reserved {
+			canonicalSubnet.Reserved = append(canonicalSubnet.Reserved, ipRange.String())
+		}
+
+		for _, ipRange := range subnet.Static {
+			canonicalSubnet.Static = append(canonicalSubnet.Static, ipRange.String())
+		}
+
+		result.Subnets = append(result.Subnets, canonicalSubnet)
+	}
+
+	return result
+}
+
+func canonicalUpdateFromUpdate(update Update) canonicalUpdate {
+	result := canonicalUpdate{
+		Canaries:    update.Canaries,
+		MaxInFlight: update.MaxInFlight,
+	}
+
+	if update.CanaryWatchTime != nil {
+		result.CanaryWatchTime = update.CanaryWatchTime.String()
+	}
+
+	if update.UpdateWatchTime != nil {
+		result.UpdateWatchTime = update.UpdateWatchTime.String()
+	}
+
+	return result
+}
+
+func nonNilProperties(props Properties) Properties {
+	if props == nil {
+		return Properties{}
+	}
+
+	return props
+}
@@ -0,0 +1,154 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+)
+
+var _ = Describe("Manifest", func() {
+	Describe("Bytes", func() {
+		manifestBytes := []byte(`
+name: fake-deployment
+
+releases:
+- {name: fake-release, version: 1, url: "file:///release.tgz", sha1: "sha256:611b7976db29e8e4d6ad7c8a5fa2e4ee6e4d0a4a8a8e59e1d5e11b7f4f2f0d3e"}
+
+networks:
+- name: net1
+  type: manual
+  subnets:
+  - range: 10.0.0.0/24
+    gateway: 10.0.0.1
+    reserved: [10.0.0.2-10.0.0.10]
+    static: [10.0.0.20 - 10.0.0.20]
+
+compilation:
+  network: net1
+
+update:
+  canaries: 1
+  canary_watch_time: 1000 - 2000
+
+jobs:
+- name: fake-job
+  instances: 1
+  update:
+    update_watch_time: 3000-4000
+  templates:
+  - {name: fake-template, release: fake-release}
+  networks:
+  - name: net1
+    static_ips: [" 10.0.0.20"]
+  properties:
+    job-prop: job-val
+
+properties:
+  global-prop: global-val
+`)
+
+		It("returns manifest with resolved values", func() {
+			manifest, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes, err := manifest.Bytes()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(bytes)).To(Equal(`name: fake-deployment
+releases:
+- name: fake-release
+  version: "1"
+  url: file:///release.tgz
+  sha1: sha256:611b7976db29e8e4d6ad7c8a5fa2e4ee6e4d0a4a8a8e59e1d5e11b7f4f2f0d3e
+networks:
+- name: net1
+  type: manual
+  subnets:
+  - range: 10.0.0.0/24
+    gateway: 10.0.0.1
+    reserved:
+    - 10.0.0.2 - 10.0.0.10
+    static:
+    - 10.0.0.20
+compilation:
+  network: net1
+update:
+  canaries: 1
+  canary_watch_time: 1000-2000
+jobs:
+- name: fake-job
+  instances: 1
+  update:
+    canaries: 1
+    canary_watch_time: 1000-2000
+    update_watch_time: 3000-4000
+  templates:
+  - name: fake-template
+    release: fake-release
+  properties:
+    job-prop: job-val
+  networks:
+  - name: net1
+    static_ips:
+    - 10.0.0.20
+properties:
+  global-prop: global-val
+`))
+		})
+
+		It("returns manifest that can be read back", func() {
+			manifest, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes, err := manifest.Bytes()
+			Expect(err).ToNot(HaveOccurred())
+
+			readManifest, err := NewManifestFromBytes(bytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			readBytes, err := readManifest.Bytes()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(readBytes)).To(Equal(string(bytes)))
+		})
+
+		It("keeps job properties as declared so that global properties are merged when read back", func() {
+			manifest, err := NewManifestFromBytes([]byte(`
+name: fake-deployment
+releases:
+- {name: fake-release, version: 1, url: "file:///release.tgz"}
+networks:
+- {name: net1, type: dynamic}
+compilation: {network: net1}
+jobs:
+- name: job-without-props
+  instances: 1
+  templates: [{name: fake-template}]
+  networks: [{name: net1}]
+- name: job-with-props
+  instances: 1
+  templates: [{name: fake-template}]
+  networks: [{name: net1}]
+  properties: {nats: {port: 4222}}
+properties: {nats: {user: fake-user}}
+properties_merge: deep
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes, err := manifest.Bytes()
+			Expect(err).ToNot(HaveOccurred())
+
+			readManifest, err := NewManifestFromBytes(bytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			jobs := readManifest.Deployment.Jobs
+			Expect(jobs[0].Properties).To(BeEmpty())
+			Expect(jobs[1].Properties).To(Equal(manifest.Deployment.Jobs[1].Properties))
+			Expect(jobs[1].Properties["nats"]).To(HaveLen(1))
+
+			Expect(readManifest.Deployment.InstanceProperties(jobs[1], 0)).To(Equal(
+				manifest.Deployment.InstanceProperties(manifest.Deployment.Jobs[1], 0)))
+		})
+	})
+})
@@ -6,41 +6,122 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	bpdepman "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+	bpdeppatch "github.com/cppforlife/bosh-provisioner/deployment/patch"
+	bpdepvars "github.com/cppforlife/bosh-provisioner/deployment/variables"
 )
 
 type ManifestReader struct {
-	path   string
-	fs     boshsys.FileSystem
-	logger boshlog.Logger
+	path              string
+	opsPaths          []string
+	runtimeConfigPath string
+
+	interpolator bpdepvars.Interpolator
+	fs           boshsys.FileSystem
+	logger       boshlog.Logger
 }
 
 func NewManifestReader(
 	path string,
+	opsPaths []string,
+	runtimeConfigPath string,
+	interpolator bpdepvars.Interpolator,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) ManifestReader {
-	return ManifestReader{path: path, fs: fs, logger: logger}
+	return ManifestReader{
+		path:              path,
+		opsPaths:          opsPaths,
+		runtimeConfigPath: runtimeConfigPath,
+
+		interpolator: interpolator,
+		fs:           fs,
+		logger:       logger,
+	}
 }
 
 func (r ManifestReader) Read() (Deployment, error) {
 	var deployment Deployment
 
-	manifest, err := bpdepman.NewManifestFromPath(r.path, r.fs)
+	bytes, err := r.fs.ReadFile(r.path)
+	if err != nil {
+		return deployment, bosherr.WrapErrorf(err, "Reading manifest %s", r.path)
+	}
+
+	// Interpolation keeps document structure so line numbers can be looked up
+	// in the original manifest; ops may add, remove or reorder entries
+	// so line numbers are not reported when ops were applied.
+	srcBytes := bytes
+
+	if len(r.opsPaths) > 0 {
+		srcBytes = nil
+	}
+
+	// Ops are applied before interpolation since they may introduce new variables
+	for _, opsPath := range r.opsPaths {
+		ops, err := bpdeppatch.NewOpsFromPath(opsPath, r.fs)
+		if err != nil {
+			return deployment, bosherr.WrapError(err, "Reading ops")
+		}
+
+		bytes, err = ops.ApplyBytes(bytes)
+		if err != nil {
+			return deployment, bosherr.WrapErrorf(err, "Applying ops file %s", opsPath)
+		}
+	}
+
+	deployment.SourceManifest = bytes
+
+	bytes, err = r.interpolator.Interpolate(bytes)
+	if err != nil {
+		return deployment, bosherr.WrapError(err, "Interpolating manifest")
+	}
+
+	manifest, err := bpdepman.NewManifestFromSourceBytes(bytes, srcBytes)
 	if err != nil {
 		return deployment, bosherr.WrapError(err, "Reading manifest")
 	}
 
+	if r.runtimeConfigPath != "" {
+		deployment.SourceRuntimeConfig, err = r.applyRuntimeConfig(&manifest)
+		if err != nil {
+			return deployment, err
+		}
+	}
+
 	deployment.populateFromManifest(manifest)
 
 	// todo pass by ref?
 	err = NewSemanticValidator(deployment).Validate()
 	if err != nil {
+		err = manifest.AnnotateErrors(err, srcBytes)
 		return deployment, bosherr.WrapError(err, "Validating deployment semantically")
 	}
 
 	return deployment, nil
 }
 
+// applyRuntimeConfig colocates addons with deployment jobs.
+// Runtime config is interpolated with the same variables as the manifest.
+// Returns runtime config bytes before interpolation.
+func (r ManifestReader) applyRuntimeConfig(manifest *bpdepman.Manifest) ([]byte, error) {
+	srcBytes, err := r.fs.ReadFile(r.runtimeConfigPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading runtime config %s", r.runtimeConfigPath)
+	}
+
+	bytes, err := r.interpolator.InterpolateWith(srcBytes, manifest.Deployment.Name, manifest.Deployment.Variables)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Interpolating runtime config")
+	}
+
+	config, err := bpdepman.NewRuntimeConfigFromBytes(bytes)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading runtime config")
+	}
+
+	return srcBytes, manifest.ApplyRuntimeConfig(config)
+}
+
 func (r ManifestReader) Close() error {
 	return nil
 }
@@ -0,0 +1,358 @@
+package deployment_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/deployment"
+	bpdepvars "github.com/cppforlife/bosh-provisioner/deployment/variables"
+	bpindex "github.com/cppforlife/bosh-provisioner/index"
+)
+
+var _ = Describe("ManifestReader", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		reader ManifestReader
+	)
+
+	buildReader := func(opsPaths []string, runtimeConfigPath string) ManifestReader {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		credsStore := bpdepvars.NewConcreteCredentialsStore(bpindex.NewFileIndex("/creds.json", fs), logger)
+		interpolator := bpdepvars.NewInterpolator(bpdepvars.StaticVariables{}, credsStore, bpdepvars.NewGenerator(), logger)
+		return NewManifestReader("/manifest.yml", opsPaths, runtimeConfigPath, interpolator, fs, logger)
+	}
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		reader = buildReader(nil, "")
+	})
+
+	Describe("Read", func() {
+		Context("when ops files are specified", func() {
+			BeforeEach(func() {
+				fs.WriteFileString("/manifest.yml", `
+name: fake-deployment
+releases:
+- {name: fake-release, version: 1, url: "dir:///fake-release"}
+networks:
+- {name: net1, type: dynamic}
+compilation: {network: net1}
+jobs:
+- name: job-1
+  templates: [{name: fake-template}]
+  instances: 1
+  networks: [{name: net1}]
+`)
+
+				fs.WriteFileString("/ops1.yml", `
+- type: replace
+  path: /jobs/name=job-1/instances
+  value: 2
+`)
+
+				fs.WriteFileString("/ops2.yml", `
+- type: replace
+  path: /jobs/name=job-1/templates/-
+  value: {name: ((template-name))}
+`)
+			})
+
+			It("applies ops in order before interpolating variables", func() {
+				reader = buildReader([]string{"/ops1.yml", "/ops2.yml"}, "")
+
+				_, err := reader.Read()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected to find variables: template-name"))
+			})
+
+			It("returns manifest with ops applied", func() {
+				reader = buildReader([]string{"/ops1.yml"}, "")
+
+				deployment, err := reader.Read()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deployment.Jobs[0].Instances).To(HaveLen(2))
+			})
+		})
+
+		Context("when runtime config is specified", func() {
+			BeforeEach(func() {
+				fs.WriteFileString("/manifest.yml", `
+name: fake-deployment
+releases:
+- {name: fake-release, version: 1, url: "dir:///fake-release"}
+networks:
+- {name: net1, type: dynamic}
+compilation: {network: net1}
+properties: {deployment-prop: val}
+jobs:
+- name: job-1
+  templates: [{name: fake-template, release: fake-release}]
+  instances: 1
+  networks: [{name: net1}]
+`)
+
+				fs.WriteFileString("/runtime-config.yml", `
+releases:
+- {name: addon-release, version: 2, url: "dir:///addon-release"}
+addons:
+- name: monitoring
+  jobs:
+  - {name: node-exporter, release: addon-release}
+  properties: {port: ((port))}
+`)
+			})
+
+			It("returns deployment with addon releases and jobs colocated with deployment jobs", func() {
+				fs.WriteFileString("/runtime-config.yml", `
+releases:
+- {name: addon-release, version: 2, url: "dir:///addon-release"}
+addons:
+- name: monitoring
+  jobs:
+  - {name: node-exporter, release: addon-release}
+  properties: {port: 9100}
+`)
+
+				reader = buildReader(nil, "/runtime-config.yml")
+
+				deployment, err := reader.Read()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.Releases).To(HaveLen(2))
+				Expect(deployment.Releases[1].Name).To(Equal("addon-release"))
+
+				templates := deployment.Jobs[0].Templates
+				Expect(templates).To(HaveLen(2))
+				Expect(templates[1].Name).To(Equal("node-exporter"))
+				Expect(templates[1].Release).To(Equal(&deployment.Releases[1]))
+				Expect(templates[1].Properties).To(Equal(Properties{"port": int64(9100)}))
+			})
+
+			It("keeps default release of templates that omit release in single release manifests", func() {
+				fs.WriteFileString("/manifest.yml", `
+name: fake-deployment
+releases:
+- {name: fake-release, version: 1, url: "dir:///fake-release"}
+networks:
+- {name: net1, type: dynamic}
+compilation: {network: net1}
+jobs:
+- name: job-1
+  templates: [{name: fake-template}]
+  instances: 1
+  networks: [{name: net1}]
+`)
+
+				fs.WriteFileString("/runtime-config.yml", `
+releases:
+- {name: addon-release, version: 2, url: "dir:///addon-release"}
+addons:
+- name: monitoring
+  jobs:
+  - {name: node-exporter, release: addon-release}
+  include:
+    jobs: [{name: fake-template, release: fake-release}]
+`)
+
+				reader = buildReader(nil, "/runtime-config.yml")
+
+				deployment, err := reader.Read()
+				Expect(err).ToNot(HaveOccurred())
+
+				templates := deployment.Jobs[0].Templates
+				Expect(templates).To(HaveLen(2))
+				Expect(templates[0].Release).To(Equal(&deployment.Releases[0]))
+				Expect(templates[1].Name).To(Equal("node-exporter"))
+				Expect(templates[1].Release).To(Equal(&deployment.Releases[1]))
+			})
+
+			It("interpolates runtime config with manifest variables", func() {
+				reader = buildReader(nil, "/runtime-config.yml")
+
+				_, err := reader.Read()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected to find variables: port"))
+			})
+
+			It("generates variables defined in the manifest and shares their values with the manifest", func() {
+				fs.WriteFileString("/manifest.yml", `
+name: fake-deployment
+releases:
+- {name: fake-release, version: 1, url: "dir:///fake-release"}
+networks:
+- {name: net1, type: dynamic}
+compilation: {network: net1}
+jobs:
+- name: job-1
+  templates: [{name: fake-template, release: fake-release}]
+  instances: 1
+  networks: [{name: net1}]
+  properties: {password: ((password))}
+variables:
+- {name: password, type: password}
+`)
+
+				fs.WriteFileString("/runtime-config.yml", `
+releases:
+- {name: addon-release, version: 2, url: "dir:///addon-release"}
+addons:
+- name: monitoring
+  jobs:
+  - {name: node-exporter, release: addon-release}
+  properties: {password: ((password))}
+`)
+
+				reader = buildReader(nil, "/runtime-config.yml")
+
+				deployment, err := reader.Read()
+				Expect(err).ToNot(HaveOccurred())
+
+				password := deployment.Jobs[0].Instances[0].Properties["password"]
+				Expect(password).To(HaveLen(20))
+
+				templates := deployment.Jobs[0].Templates
+				Expect(templates[1].Properties).To(Equal(Properties{"password": password}))
+			})
+
+			It("keeps variables as placeholders in source manifest and runtime config", func() {
+				fs.WriteFileString("/manifest.yml", `
+name: fake-deployment
+releases:
+- {name: fake-release, version: latest, url: "dir:///fake-release"}
+networks:
+- {name: net1, type: dynamic}
+compilation: {network: net1}
+jobs:
+- name: job-1
+  templates: [{name: fake-template, release: fake-release}]
+  instances: 1
+  networks: [{name: net1}]
+  properties: {password: ((password))}
+variables:
+- {name: password, type: password}
+- {name: port, type: password}
+`)
+
+				reader = buildReader(nil, "/runtime-config.yml")
+
+				deployment, err := reader.Read()
+				Expect(err).ToNot(HaveOccurred())
+
+				password := deployment.Jobs[0].Instances[0].Properties["password"].(string)
+
+				deployment.Releases[0].Version = "0+dev.3"
+
+				manifestBytes, err := deployment.ResolvedSourceManifest()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(manifestBytes)).To(ContainSubstring("((password))"))
+				Expect(string(manifestBytes)).To(ContainSubstring("0+dev.3"))
+				Expect(string(manifestBytes)).ToNot(ContainSubstring(password))
+
+				Expect(string(deployment.SourceRuntimeConfig)).To(ContainSubstring("((port))"))
+			})
+		})
+
+		Context("when deployment uses manual network", func() {
+			manifestWithStaticIPs := func(staticIPs string) string {
+				return `
+name: fake-deployment
+
+releases:
+- name: fake-release
+  version: 1
+  url: dir:///fake-release
+
+networks:
+- name: net1
+  type: manual
+  subnets:
+  - range: 10.0.0.0/28
+    gateway: 10.0.0.1
+    dns: [8.8.8.8]
+    reserved: [10.0.0.2]
+    static: [10.0.0.5 - 10.0.0.6]
+
+compilation:
+  network: net1
+
+jobs:
+- name: job-1
+  templates: [{name: fake-template}]
+  instances: 1
+  networks:
+  - name: net1
+    static_ips: ` + staticIPs + `
+- name: job-2
+  templates: [{name: fake-template}]
+  instances: 2
+  networks:
+  - name: net1
+`
+			}
+
+			It("assigns static IPs and allocates dynamic IPs outside of reserved and static ranges", func() {
+				fs.WriteFileString("/manifest.yml", manifestWithStaticIPs("[10.0.0.6]"))
+
+				deployment, err := reader.Read()
+				Expect(err).ToNot(HaveOccurred())
+
+				job1Instance := deployment.Jobs[0].Instances[0]
+				Expect(job1Instance.NetworkConfigurationForNetworkAssociation(
+					job1Instance.NetworkAssociations[0])).To(Equal(NetworkConfiguration{
+					IP:      "10.0.0.6",
+					Netmask: "255.255.255.240",
+					Gateway: "10.0.0.1",
+					DNS:     []string{"8.8.8.8"},
+				}))
+
+				job2Instance0 := deployment.Jobs[1].Instances[0]
+				Expect(job2Instance0.NetworkAssociations[0].IP().String()).To(Equal("10.0.0.3"))
+
+				job2Instance1 := deployment.Jobs[1].Instances[1]
+				Expect(job2Instance1.NetworkAssociations[0].IP().String()).To(Equal("10.0.0.4"))
+
+				compilationInstance := deployment.CompilationInstance
+				Expect(compilationInstance.NetworkAssociations[0].IP().String()).To(Equal("10.0.0.7"))
+			})
+
+			It("returns error if static IP is not within subnet static ranges", func() {
+				fs.WriteFileString("/manifest.yml", manifestWithStaticIPs("[10.0.0.4]"))
+
+				_, err := reader.Read()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Static IP 10.0.0.4 is not within any subnet static range"))
+			})
+
+			It("reports line numbers of the manifest as written even if it was interpolated", func() {
+				fs.WriteFileString("/manifest.yml", manifestWithStaticIPs("[10.0.0.4]")+`
+properties: {password: ((password))}
+variables:
+- {name: password, type: password}
+`)
+
+				_, err := reader.Read()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("(line 28): Static IP 10.0.0.4 is not within any subnet static range"))
+			})
+
+			It("does not report line numbers if ops were applied", func() {
+				fs.WriteFileString("/manifest.yml", manifestWithStaticIPs("[10.0.0.4]"))
+
+				fs.WriteFileString("/ops.yml", `
+- type: replace
+  path: /jobs/name=job-2/instances
+  value: 1
+`)
+
+				reader = buildReader([]string{"/ops.yml"}, "")
+
+				_, err := reader.Read()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Static IP 10.0.0.4 is not within any subnet static range"))
+				Expect(err.Error()).ToNot(ContainSubstring("(line "))
+			})
+		})
+	})
+})
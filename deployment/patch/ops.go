@@ -0,0 +1,405 @@
+// Package patch applies operations (ops files) to YAML documents
+// before they are interpreted, e.g. to adjust a base manifest
+// for a specific environment.
+package patch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	OpTypeReplace = "replace"
+	OpTypeRemove  = "remove"
+)
+
+type Op struct {
+	Type string `yaml:"type"`
+
+	PathRaw string `yaml:"path"`
+	Path    Pointer
+
+	// Only used by replace operations
+	Value interface{} `yaml:"value"`
+}
+
+type Ops []Op
+
+// NewOpsFromPath returns operations read from the file system.
+func NewOpsFromPath(path string, fs boshsys.FileSystem) (Ops, error) {
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading ops file %s", path)
+	}
+
+	ops, err := NewOpsFromBytes(bytes)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Building ops from file %s", path)
+	}
+
+	return ops, nil
+}
+
+// NewOpsFromBytes returns operations built from given bytes.
+func NewOpsFromBytes(bytes []byte) (Ops, error) {
+	var ops Ops
+
+	err := candiedyaml.Unmarshal(bytes, &ops)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing ops")
+	}
+
+	for i, op := range ops {
+		err := ops[i].validate()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Op %d (%s)", i, op.PathRaw)
+		}
+	}
+
+	return ops, nil
+}
+
+func (o *Op) validate() error {
+	if o.Type != OpTypeReplace && o.Type != OpTypeRemove {
+		return bosherr.Errorf("Unknown op type '%s'", o.Type)
+	}
+
+	if o.PathRaw == "" {
+		return bosherr.Error("Missing path")
+	}
+
+	path, err := NewPointerFromString(o.PathRaw)
+	if err != nil {
+		return err
+	}
+
+	if o.Type == OpTypeRemove && len(path.tokens) == 0 {
+		return bosherr.Error("Cannot remove root path")
+	}
+
+	o.Path = path
+
+	return nil
+}
+
+// ApplyBytes applies operations to a YAML document and returns updated document.
+func (o Ops) ApplyBytes(bytes []byte) ([]byte, error) {
+	if len(o) == 0 {
+		return bytes, nil
+	}
+
+	var doc interface{}
+
+	err := candiedyaml.Unmarshal(bytes, &doc)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing document")
+	}
+
+	doc, err = o.Apply(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err = candiedyaml.Marshal(doc)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling document")
+	}
+
+	return bytes, nil
+}
+
+// Apply applies operations in order; first failing operation stops processing.
+func (o Ops) Apply(doc interface{}) (interface{}, error) {
+	for i, op := range o {
+		var err error
+
+		switch op.Type {
+		case OpTypeReplace:
+			doc, err = replaceOp{op.Path.tokens, op.Value}.apply(doc, 0)
+		case OpTypeRemove:
+			doc, err = removeOp{op.Path.tokens}.apply(doc, 0)
+		default:
+			err = bosherr.Errorf("Unknown op type '%s'", op.Type)
+		}
+
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Applying op %d (%s '%s')", i, op.Type, op.PathRaw)
+		}
+	}
+
+	return doc, nil
+}
+
+type replaceOp struct {
+	tokens []token
+	value  interface{}
+}
+
+// apply returns node with value placed at a path starting from i-th token.
+// Once optional token is seen, all following missing segments are created.
+func (op replaceOp) apply(node interface{}, i int) (interface{}, error) {
+	if i == len(op.tokens) {
+		return op.value, nil
+	}
+
+	tok := op.tokens[i]
+	optional := op.isOptional(i)
+
+	switch tok.kind {
+	case keyTokenKind:
+		if node == nil && optional {
+			node = map[interface{}]interface{}{}
+		}
+
+		nodeMap, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find a map at path '%s' but found '%T'", pathString(op.tokens[:i]), node)
+		}
+
+		child, found := nodeMap[tok.key]
+		if !found && !optional {
+			return nil, bosherr.Errorf("Expected to find a map key '%s' for path '%s'", tok.key, pathString(op.tokens[:i+1]))
+		}
+
+		val, err := op.apply(child, i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeMap[tok.key] = val
+
+		return nodeMap, nil
+
+	case indexTokenKind, appendTokenKind:
+		nodeArr, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at path '%s' but found '%T'", pathString(op.tokens[:i]), node)
+		}
+
+		if tok.kind == appendTokenKind {
+			if i < len(op.tokens)-1 {
+				return nil, bosherr.Errorf("Expected to find '-' only as a last segment for path '%s'", pathString(op.tokens[:i+1]))
+			}
+
+			return append(nodeArr, op.value), nil
+		}
+
+		idx, err := arrayIndex(tok.index, len(nodeArr), op.tokens[:i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := op.apply(nodeArr[idx], i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeArr[idx] = val
+
+		return nodeArr, nil
+
+	case matchingTokenKind:
+		if node == nil && optional {
+			node = []interface{}{}
+		}
+
+		nodeArr, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at path '%s' but found '%T'", pathString(op.tokens[:i]), node)
+		}
+
+		idx, err := matchingIndex(nodeArr, tok, op.tokens[:i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		if idx < 0 {
+			if !optional {
+				return nil, bosherr.Errorf("Expected to find exactly one matching array item for path '%s' but found 0", pathString(op.tokens[:i+1]))
+			}
+
+			var newItem interface{} = map[interface{}]interface{}{tok.key: tok.value}
+
+			if i == len(op.tokens)-1 {
+				newItem = nil
+			}
+
+			val, err := op.apply(newItem, i+1)
+			if err != nil {
+				return nil, err
+			}
+
+			return append(nodeArr, val), nil
+		}
+
+		val, err := op.apply(nodeArr[idx], i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeArr[idx] = val
+
+		return nodeArr, nil
+
+	default:
+		return nil, bosherr.Errorf("Unknown path segment '%s'", tok)
+	}
+}
+
+func (op replaceOp) isOptional(i int) bool {
+	for _, tok := range op.tokens[:i+1] {
+		if tok.optional {
+			return true
+		}
+	}
+
+	return false
+}
+
+type removeOp struct {
+	tokens []token
+}
+
+// apply returns node without value found at a path starting from i-th token.
+// Missing optional segments result in no changes.
+func (op removeOp) apply(node interface{}, i int) (interface{}, error) {
+	tok := op.tokens[i]
+	isLast := i == len(op.tokens)-1
+
+	switch tok.kind {
+	case keyTokenKind:
+		nodeMap, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find a map at path '%s' but found '%T'", pathString(op.tokens[:i]), node)
+		}
+
+		child, found := nodeMap[tok.key]
+		if !found {
+			if op.isOptional(i) {
+				return nodeMap, nil
+			}
+
+			return nil, bosherr.Errorf("Expected to find a map key '%s' for path '%s'", tok.key, pathString(op.tokens[:i+1]))
+		}
+
+		if isLast {
+			delete(nodeMap, tok.key)
+			return nodeMap, nil
+		}
+
+		val, err := op.apply(child, i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeMap[tok.key] = val
+
+		return nodeMap, nil
+
+	case indexTokenKind:
+		nodeArr, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at path '%s' but found '%T'", pathString(op.tokens[:i]), node)
+		}
+
+		idx, err := arrayIndex(tok.index, len(nodeArr), op.tokens[:i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		return op.applyToItem(nodeArr, idx, i)
+
+	case matchingTokenKind:
+		nodeArr, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at path '%s' but found '%T'", pathString(op.tokens[:i]), node)
+		}
+
+		idx, err := matchingIndex(nodeArr, tok, op.tokens[:i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		if idx < 0 {
+			if op.isOptional(i) {
+				return nodeArr, nil
+			}
+
+			return nil, bosherr.Errorf("Expected to find exactly one matching array item for path '%s' but found 0", pathString(op.tokens[:i+1]))
+		}
+
+		return op.applyToItem(nodeArr, idx, i)
+
+	default:
+		return nil, bosherr.Errorf("Cannot remove using path segment '%s' in path '%s'", tok, pathString(op.tokens[:i+1]))
+	}
+}
+
+func (op removeOp) applyToItem(nodeArr []interface{}, idx int, i int) (interface{}, error) {
+	if i == len(op.tokens)-1 {
+		return append(nodeArr[:idx], nodeArr[idx+1:]...), nil
+	}
+
+	val, err := op.apply(nodeArr[idx], i+1)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeArr[idx] = val
+
+	return nodeArr, nil
+}
+
+func (op removeOp) isOptional(i int) bool {
+	return replaceOp{tokens: op.tokens}.isOptional(i)
+}
+
+// arrayIndex supports negative indexes that count from the end of an array
+func arrayIndex(idx, length int, tokens []token) (int, error) {
+	if idx < 0 {
+		idx = length + idx
+	}
+
+	if idx < 0 || idx >= length {
+		return 0, bosherr.Errorf("Expected to find array index %d for path '%s' but array has %d items", tokens[len(tokens)-1].index, pathString(tokens), length)
+	}
+
+	return idx, nil
+}
+
+// matchingIndex returns index of the only item that matches token or -1 if none matches
+func matchingIndex(nodeArr []interface{}, tok token, tokens []token) (int, error) {
+	idx := -1
+
+	for i, item := range nodeArr {
+		itemMap, ok := item.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+
+		val, found := itemMap[tok.key]
+		if found && fmt.Sprintf("%v", val) == tok.value {
+			if idx >= 0 {
+				return 0, bosherr.Errorf("Expected to find exactly one matching array item for path '%s' but found multiple", pathString(tokens))
+			}
+
+			idx = i
+		}
+	}
+
+	return idx, nil
+}
+
+func pathString(tokens []token) string {
+	var pieces []string
+
+	for _, tok := range tokens {
+		pieces = append(pieces, tok.String())
+	}
+
+	return "/" + strings.Join(pieces, "/")
+}
@@ -0,0 +1,135 @@
+package patch_test
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/deployment/patch"
+)
+
+var _ = Describe("Ops", func() {
+	var doc interface{}
+
+	BeforeEach(func() {
+		doc = nil
+
+		err := candiedyaml.Unmarshal([]byte(`
+name: fake-deployment
+instance_groups:
+- name: web
+  instances: 1
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+- name: db
+  instances: 1
+`), &doc)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	apply := func(opsYAML string) (map[interface{}]interface{}, error) {
+		ops, err := NewOpsFromBytes([]byte(opsYAML))
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := ops.Apply(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		return result.(map[interface{}]interface{}), nil
+	}
+
+	instanceGroup := func(result map[interface{}]interface{}, i int) map[interface{}]interface{} {
+		return result["instance_groups"].([]interface{})[i].(map[interface{}]interface{})
+	}
+
+	Describe("Apply", func() {
+		It("replaces values found via matching and index segments", func() {
+			result, err := apply(`
+- type: replace
+  path: /instance_groups/name=web/jobs/0/properties/port
+  value: 8080
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			job := instanceGroup(result, 0)["jobs"].([]interface{})[0].(map[interface{}]interface{})
+			Expect(job["properties"]).To(Equal(map[interface{}]interface{}{"port": int64(8080)}))
+		})
+
+		It("creates missing optional segments", func() {
+			result, err := apply(`
+- type: replace
+  path: /instance_groups/name=db/jobs?/name=postgres?/properties/port
+  value: 5432
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(instanceGroup(result, 1)["jobs"]).To(Equal([]interface{}{
+				map[interface{}]interface{}{
+					"name":       "postgres",
+					"properties": map[interface{}]interface{}{"port": int64(5432)},
+				},
+			}))
+		})
+
+		It("appends values to arrays", func() {
+			result, err := apply(`
+- type: replace
+  path: /instance_groups/-
+  value: {name: worker}
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["instance_groups"]).To(HaveLen(3))
+			Expect(instanceGroup(result, 2)).To(Equal(map[interface{}]interface{}{"name": "worker"}))
+		})
+
+		It("removes values and ignores missing optional values", func() {
+			result, err := apply(`
+- type: remove
+  path: /instance_groups/name=db
+- type: remove
+  path: /instance_groups/name=worker?
+- type: remove
+  path: /instance_groups/0/jobs/0/properties/port
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["instance_groups"]).To(HaveLen(1))
+
+			job := instanceGroup(result, 0)["jobs"].([]interface{})[0].(map[interface{}]interface{})
+			Expect(job["properties"]).To(Equal(map[interface{}]interface{}{}))
+		})
+
+		It("returns error with op index and path when path cannot be found", func() {
+			_, err := apply(`
+- type: replace
+  path: /name
+  value: new-name
+- type: replace
+  path: /instance_groups/name=worker/instances
+  value: 2
+`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Applying op 1 (replace '/instance_groups/name=worker/instances')"))
+			Expect(err.Error()).To(ContainSubstring("Expected to find exactly one matching array item for path '/instance_groups/name=worker'"))
+		})
+
+		It("returns error when required map key is missing", func() {
+			_, err := apply(`
+- type: remove
+  path: /instance_groups/0/missing/key
+`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find a map key 'missing' for path '/instance_groups/0/missing'"))
+		})
+	})
+
+	Describe("NewOpsFromBytes", func() {
+		It("returns error for unknown op type", func() {
+			_, err := NewOpsFromBytes([]byte(`[{type: unknown, path: /name}]`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Op 0 (/name): Unknown op type 'unknown'"))
+		})
+	})
+})
@@ -0,0 +1,103 @@
+package patch
+
+import (
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Pointer represents a path into a YAML document, e.g.
+// /instance_groups/name=web/jobs/0/properties/port?
+// Segments with '?' suffix are optional; missing optional
+// segments are created when replacing and ignored when removing.
+type Pointer struct {
+	tokens []token
+}
+
+type tokenKind int
+
+const (
+	keyTokenKind tokenKind = iota
+	indexTokenKind
+	appendTokenKind
+	matchingTokenKind
+)
+
+type token struct {
+	kind tokenKind
+
+	// Used by key and matching tokens
+	key string
+
+	// Used by matching tokens
+	value string
+
+	// Used by index tokens
+	index int
+
+	optional bool
+}
+
+func NewPointerFromString(str string) (Pointer, error) {
+	if !strings.HasPrefix(str, "/") {
+		return Pointer{}, bosherr.Errorf("Expected path '%s' to start with '/'", str)
+	}
+
+	var tokens []token
+
+	// Root path does not have any tokens
+	if str == "/" {
+		return Pointer{}, nil
+	}
+
+	for _, piece := range strings.Split(str[1:], "/") {
+		tok := token{}
+
+		if strings.HasSuffix(piece, "?") {
+			tok.optional = true
+			piece = strings.TrimSuffix(piece, "?")
+		}
+
+		piece = strings.Replace(strings.Replace(piece, "~1", "/", -1), "~0", "~", -1)
+
+		if piece == "-" {
+			tok.kind = appendTokenKind
+		} else if idx, err := strconv.Atoi(piece); err == nil {
+			tok.kind = indexTokenKind
+			tok.index = idx
+		} else if eqIdx := strings.Index(piece, "="); eqIdx > 0 {
+			tok.kind = matchingTokenKind
+			tok.key = piece[:eqIdx]
+			tok.value = piece[eqIdx+1:]
+		} else {
+			tok.kind = keyTokenKind
+			tok.key = piece
+		}
+
+		tokens = append(tokens, tok)
+	}
+
+	return Pointer{tokens: tokens}, nil
+}
+
+func (t token) String() string {
+	var str string
+
+	switch t.kind {
+	case indexTokenKind:
+		str = strconv.Itoa(t.index)
+	case appendTokenKind:
+		str = "-"
+	case matchingTokenKind:
+		str = t.key + "=" + t.value
+	default:
+		str = t.key
+	}
+
+	if t.optional {
+		str += "?"
+	}
+
+	return str
+}
@@ -3,23 +3,30 @@ package deployment
 import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bpdepvars "github.com/cppforlife/bosh-provisioner/deployment/variables"
 )
 
 type ReaderFactory struct {
-	fs     boshsys.FileSystem
-	logger boshlog.Logger
+	interpolator bpdepvars.Interpolator
+	fs           boshsys.FileSystem
+	logger       boshlog.Logger
 }
 
 func NewReaderFactory(
+	interpolator bpdepvars.Interpolator,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) ReaderFactory {
 	return ReaderFactory{
-		fs:     fs,
-		logger: logger,
+		interpolator: interpolator,
+		fs:           fs,
+		logger:       logger,
 	}
 }
 
-func (rf ReaderFactory) NewManifestReader(path string) ManifestReader {
-	return NewManifestReader(path, rf.fs, rf.logger)
+// NewManifestReader returns reader for a deployment manifest;
+// runtime config path can be empty if there are no addons.
+func (rf ReaderFactory) NewManifestReader(path string, opsPaths []string, runtimeConfigPath string) ManifestReader {
+	return NewManifestReader(path, opsPaths, runtimeConfigPath, rf.interpolator, rf.fs, rf.logger)
 }
@@ -1,12 +1,18 @@
 package deployment
 
 import (
+	"fmt"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
 )
 
 // SemanticValidator validates deployment to determine if it represents a meaningful state.
 // e.g. - is each job template associated with a release?
 //      - are there enough static ips for each job instance?
+// All found problems are returned together as bpvalid.Errors
+// with paths pointing to relevant manifest sections.
 type SemanticValidator struct {
 	deployment Deployment
 }
@@ -16,96 +22,101 @@ func NewSemanticValidator(deployment Deployment) SemanticValidator {
 }
 
 func (v SemanticValidator) Validate() error {
-	for _, net := range v.deployment.Networks {
-		err := v.validateNetwork(net)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Network %s", net.Name)
-		}
-	}
+	c := bpvalid.NewCollector()
 
-	for _, release := range v.deployment.Releases {
-		err := v.validateRelease(release)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Release %s", release.Name)
-		}
-	}
+	v.validateStaticIPsUniqueness(c)
 
-	err := v.validateInstance(v.deployment.CompilationInstance)
-	if err != nil {
-		return bosherr.WrapError(err, "Compilation instance")
-	}
+	v.validateCompilationInstance(v.deployment.CompilationInstance, c.Key("compilation"))
 
-	for _, job := range v.deployment.Jobs {
-		err := v.validateJob(job)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Job %s", job.Name)
-		}
+	for i, job := range v.deployment.Jobs {
+		v.validateJob(job, c.Key("jobs").Index(i))
 	}
 
-	return nil
+	return c.Errors()
 }
 
-func (v SemanticValidator) validateNetwork(network Network) error {
-	if network.Type == NetworkTypeManual {
-		return bosherr.Error("Manual networking is not supported")
+// validateStaticIPsUniqueness makes sure that manual network static IPs
+// are not used by multiple instances
+func (v SemanticValidator) validateStaticIPsUniqueness(c bpvalid.Collector) {
+	usedBy := map[string]string{}
+
+	for i, job := range v.deployment.Jobs {
+		for _, instance := range job.Instances {
+			for j, na := range instance.NetworkAssociations {
+				if na.Network == nil || na.Network.Type != NetworkTypeManual || na.StaticIP == nil {
+					continue
+				}
+
+				key := na.Network.Name + "/" + na.StaticIP.String()
+				instanceName := fmt.Sprintf("%s/%d", job.Name, instance.Index)
+
+				otherInstanceName, found := usedBy[key]
+				if found {
+					ipC := c.Key("jobs").Index(i).Key("networks").Index(j).Key("static_ips").Index(instance.Index)
+					ipC.Add(bosherr.Errorf(
+						"Static IP %s is assigned to both %s and %s",
+						na.StaticIP, otherInstanceName, instanceName))
+				}
+
+				usedBy[key] = instanceName
+			}
+		}
 	}
-
-	return nil
 }
 
-func (v SemanticValidator) validateRelease(release Release) error {
-	if release.Version == "latest" {
-		return bosherr.Error("Version 'latest' is not supported")
+func (v SemanticValidator) validateJob(job Job, c bpvalid.Collector) {
+	for i, template := range job.Templates {
+		v.validateTemplate(template, c.Key("templates").Index(i))
 	}
 
-	return nil
-}
+	for _, instance := range job.Instances {
+		for i, na := range instance.NetworkAssociations {
+			naC := c.Key("networks").Index(i)
 
-func (v SemanticValidator) validateJob(job Job) error {
-	for _, template := range job.Templates {
-		err := v.validateTemplate(template)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Template %s", template.Name)
-		}
-	}
+			// Network is the same for all instances
+			if instance.Index == 0 && na.Network == nil {
+				naC.Key("name").Add(bosherr.Error("Missing associated network"))
+			}
 
-	for _, instance := range job.Instances {
-		err := v.validateInstance(instance)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Instance %d", instance.Index)
+			v.validateNetworkAssociation(instance, na, naC)
 		}
 	}
-
-	return nil
 }
 
-func (v SemanticValidator) validateTemplate(template Template) error {
+func (v SemanticValidator) validateTemplate(template Template, c bpvalid.Collector) {
 	if template.Release == nil {
-		return bosherr.Error("Missing associated release")
+		c.Key("release").Add(bosherr.Error("Missing associated release"))
 	}
-
-	return nil
 }
 
-func (v SemanticValidator) validateInstance(instance Instance) error {
-	for i, na := range instance.NetworkAssociations {
-		err := v.validateNetworkAssociation(na)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Network association %d", i)
+func (v SemanticValidator) validateCompilationInstance(instance Instance, c bpvalid.Collector) {
+	for _, na := range instance.NetworkAssociations {
+		if na.Network == nil {
+			c.Key("network").Add(bosherr.Error("Missing associated network"))
+			continue
 		}
-	}
 
-	return nil
+		v.validateNetworkAssociation(instance, na, c.Key("network"))
+	}
 }
 
-func (v SemanticValidator) validateNetworkAssociation(na NetworkAssociation) error {
+func (v SemanticValidator) validateNetworkAssociation(instance Instance, na NetworkAssociation, c bpvalid.Collector) {
 	if na.Network == nil {
-		return bosherr.Error("Missing associated network")
+		return
 	}
 
 	if na.MustHaveStaticIP && na.StaticIP == nil {
-		return bosherr.Error("Missing static IP assignment")
+		c.Key("static_ips").Add(bosherr.Errorf("Missing static IP assignment for instance %d", instance.Index))
 	}
 
-	return nil
+	if na.Network.Type == NetworkTypeManual {
+		if na.StaticIP != nil && na.Subnet == nil {
+			c.Key("static_ips").Index(instance.Index).Add(
+				bosherr.Errorf("Static IP %s is not within any subnet static range", na.StaticIP))
+		}
+
+		if !na.MustHaveStaticIP && na.IP() == nil {
+			c.Add(bosherr.Errorf("No IPs left to allocate in network %s for instance %d", na.Network.Name, instance.Index))
+		}
+	}
 }
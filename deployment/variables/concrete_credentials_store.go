@@ -0,0 +1,58 @@
+package variables
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bpindex "github.com/cppforlife/bosh-provisioner/index"
+)
+
+type ConcreteCredentialsStore struct {
+	index  bpindex.Index
+	logger boshlog.Logger
+}
+
+type credentialKey struct {
+	DeploymentName string
+	Name           string
+}
+
+type credentialRecord struct {
+	Value interface{}
+}
+
+func NewConcreteCredentialsStore(
+	index bpindex.Index,
+	logger boshlog.Logger,
+) ConcreteCredentialsStore {
+	return ConcreteCredentialsStore{
+		index:  index,
+		logger: logger,
+	}
+}
+
+func (s ConcreteCredentialsStore) Find(deploymentName, name string) (interface{}, bool, error) {
+	var record credentialRecord
+
+	err := s.index.Find(credentialKey{DeploymentName: deploymentName, Name: name}, &record)
+	if err != nil {
+		if err == bpindex.ErrNotFound {
+			return nil, false, nil
+		}
+
+		return nil, false, bosherr.WrapErrorf(err, "Finding credential %s", name)
+	}
+
+	return record.Value, true, nil
+}
+
+func (s ConcreteCredentialsStore) Save(deploymentName, name string, value interface{}) error {
+	record := credentialRecord{Value: value}
+
+	err := s.index.Save(credentialKey{DeploymentName: deploymentName, Name: name}, record)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving credential %s", name)
+	}
+
+	return nil
+}
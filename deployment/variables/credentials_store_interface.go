@@ -0,0 +1,8 @@
+package variables
+
+// CredentialsStore maintains generated variable values
+// so that they are reused when deployment is provisioned again.
+type CredentialsStore interface {
+	Find(deploymentName, name string) (interface{}, bool, error)
+	Save(deploymentName, name string, value interface{}) error
+}
@@ -0,0 +1,347 @@
+package variables
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	gonet "net"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bpdepman "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+)
+
+const (
+	VariableTypePassword    = "password"
+	VariableTypeCertificate = "certificate"
+	VariableTypeSSH         = "ssh"
+	VariableTypeRSA         = "rsa"
+)
+
+const (
+	passwordLength = 20
+	passwordChars  = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	rsaKeyBits = 2048
+
+	defaultCertificateDuration = 365 * 24 * time.Hour
+)
+
+// CAFinderFunc returns value of a certificate variable used as a CA
+type CAFinderFunc func(name string) (interface{}, error)
+
+// Generator produces new values for variables that were not
+// provided by the user and were not previously generated.
+type Generator struct{}
+
+func NewGenerator() Generator { return Generator{} }
+
+func (g Generator) Generate(variable bpdepman.Variable, findCA CAFinderFunc) (interface{}, error) {
+	switch variable.Type {
+	case VariableTypePassword:
+		return g.generatePassword()
+
+	case VariableTypeCertificate:
+		return g.generateCertificate(variable.Options, findCA)
+
+	case VariableTypeSSH:
+		return g.generateSSH()
+
+	case VariableTypeRSA:
+		return g.generateRSA()
+
+	default:
+		return nil, bosherr.Errorf("Unknown variable type %s", variable.Type)
+	}
+}
+
+// generatePassword picks each character uniformly
+// (mapping random bytes with modulo would favor first characters)
+func (g Generator) generatePassword() (interface{}, error) {
+	bytes := make([]byte, passwordLength)
+	numChars := big.NewInt(int64(len(passwordChars)))
+
+	for i := range bytes {
+		idx, err := rand.Int(rand.Reader, numChars)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Reading random number")
+		}
+
+		bytes[i] = passwordChars[idx.Int64()]
+	}
+
+	return string(bytes), nil
+}
+
+type certificateOptions struct {
+	CAName           string
+	IsCA             bool
+	CommonName       string
+	AlternativeNames []string
+	Duration         time.Duration
+}
+
+func (g Generator) generateCertificate(opts map[string]interface{}, findCA CAFinderFunc) (interface{}, error) {
+	certOpts, err := g.certificateOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating private key")
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating serial number")
+	}
+
+	now := time.Now()
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: certOpts.CommonName},
+
+		NotBefore: now.Add(-time.Minute),
+		NotAfter:  now.Add(certOpts.Duration),
+
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	for _, name := range certOpts.AlternativeNames {
+		if ip := gonet.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	if certOpts.IsCA {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		template.ExtKeyUsage = nil
+	}
+
+	// Self-signed unless CA is specified
+	parent := template
+	signingKey := key
+	var caChainPEM string
+
+	if certOpts.CAName != "" {
+		caValue, err := findCA(certOpts.CAName)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Finding CA %s", certOpts.CAName)
+		}
+
+		parent, signingKey, caChainPEM, err = g.parseCA(caValue)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing CA %s", certOpts.CAName)
+		}
+	} else if !certOpts.IsCA {
+		return nil, bosherr.Error("Expected certificate to be a CA or to specify a CA")
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signingKey)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating certificate")
+	}
+
+	certPEM := g.encodePEM("CERTIFICATE", certDER)
+
+	// Self-signed CA is its own CA
+	if caChainPEM == "" {
+		caChainPEM = certPEM
+	}
+
+	return map[string]interface{}{
+		"ca":          caChainPEM,
+		"certificate": certPEM,
+		"private_key": g.encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+	}, nil
+}
+
+func (g Generator) certificateOptions(opts map[string]interface{}) (certificateOptions, error) {
+	certOpts := certificateOptions{Duration: defaultCertificateDuration}
+
+	var ok bool
+
+	if val, found := opts["ca"]; found {
+		certOpts.CAName, ok = val.(string)
+		if !ok {
+			return certOpts, bosherr.Error("Expected option 'ca' to be a string")
+		}
+	}
+
+	if val, found := opts["is_ca"]; found {
+		certOpts.IsCA, ok = val.(bool)
+		if !ok {
+			return certOpts, bosherr.Error("Expected option 'is_ca' to be a boolean")
+		}
+	}
+
+	if val, found := opts["common_name"]; found {
+		certOpts.CommonName, ok = val.(string)
+		if !ok {
+			return certOpts, bosherr.Error("Expected option 'common_name' to be a string")
+		}
+	}
+
+	if val, found := opts["alternative_names"]; found {
+		names, ok := val.([]interface{})
+		if !ok {
+			return certOpts, bosherr.Error("Expected option 'alternative_names' to be an array")
+		}
+
+		for _, name := range names {
+			nameStr, ok := name.(string)
+			if !ok {
+				return certOpts, bosherr.Error("Expected option 'alternative_names' to contain strings")
+			}
+
+			certOpts.AlternativeNames = append(certOpts.AlternativeNames, nameStr)
+		}
+	}
+
+	if val, found := opts["duration"]; found {
+		var days int64
+
+		switch typedVal := val.(type) {
+		case int:
+			days = int64(typedVal)
+		case int64:
+			days = typedVal
+		default:
+			return certOpts, bosherr.Error("Expected option 'duration' to be a number of days")
+		}
+
+		certOpts.Duration = time.Duration(days) * 24 * time.Hour
+	}
+
+	return certOpts, nil
+}
+
+// parseCA returns CA certificate, its private key and a PEM encoded chain
+// that includes CA certificate followed by its own CAs (for intermediate CAs).
+func (g Generator) parseCA(caValue interface{}) (*x509.Certificate, *rsa.PrivateKey, string, error) {
+	caMap, ok := caValue.(map[string]interface{})
+	if !ok {
+		return nil, nil, "", bosherr.Error("Expected CA to be a certificate")
+	}
+
+	certPEM, _ := caMap["certificate"].(string)
+	keyPEM, _ := caMap["private_key"].(string)
+	chainPEM, _ := caMap["ca"].(string)
+
+	certBlock, _ := pem.Decode([]byte(certPEM))
+	if certBlock == nil {
+		return nil, nil, "", bosherr.Error("Decoding CA certificate")
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, "", bosherr.WrapError(err, "Parsing CA certificate")
+	}
+
+	if !cert.IsCA {
+		return nil, nil, "", bosherr.Error("Expected certificate to be a CA")
+	}
+
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil {
+		return nil, nil, "", bosherr.Error("Decoding CA private key")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, "", bosherr.WrapError(err, "Parsing CA private key")
+	}
+
+	// Root CA's chain only includes itself
+	if chainPEM != certPEM {
+		certPEM += chainPEM
+	}
+
+	return cert, key, certPEM, nil
+}
+
+func (g Generator) generateSSH() (interface{}, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating private key")
+	}
+
+	wireKey := g.sshWireFormat(&key.PublicKey)
+
+	var fingerprint []string
+
+	for _, b := range md5.Sum(wireKey) {
+		fingerprint = append(fingerprint, fmt.Sprintf("%02x", b))
+	}
+
+	return map[string]interface{}{
+		"private_key":            g.encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+		"public_key":             "ssh-rsa " + base64.StdEncoding.EncodeToString(wireKey),
+		"public_key_fingerprint": strings.Join(fingerprint, ":"),
+	}, nil
+}
+
+// sshWireFormat encodes public key as specified in RFC 4253 section 6.6
+func (g Generator) sshWireFormat(key *rsa.PublicKey) []byte {
+	var buf bytes.Buffer
+
+	writeBytes := func(b []byte) {
+		binary.Write(&buf, binary.BigEndian, uint32(len(b)))
+		buf.Write(b)
+	}
+
+	writeMPInt := func(n *big.Int) {
+		b := n.Bytes()
+
+		// Positive numbers with high bit set need a leading zero
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+
+		writeBytes(b)
+	}
+
+	writeBytes([]byte("ssh-rsa"))
+	writeMPInt(big.NewInt(int64(key.E)))
+	writeMPInt(key.N)
+
+	return buf.Bytes()
+}
+
+func (g Generator) generateRSA() (interface{}, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating private key")
+	}
+
+	pubKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling public key")
+	}
+
+	return map[string]interface{}{
+		"private_key": g.encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+		"public_key":  g.encodePEM("PUBLIC KEY", pubKeyDER),
+	}, nil
+}
+
+func (g Generator) encodePEM(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
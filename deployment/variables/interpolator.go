@@ -0,0 +1,306 @@
+package variables
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bpdepman "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+var (
+	placeholderRegexp      = regexp.MustCompile(`\(\(([-\w\p{L}\./!]+)\)\)`)
+	wholePlaceholderRegexp = regexp.MustCompile(`\A\(\(([-\w\p{L}\./!]+)\)\)\z`)
+)
+
+const interpolatorLogTag = "Interpolator"
+
+// Interpolator replaces ((placeholders)) in a manifest with variable values.
+// Values are looked up in static variables, then in credentials store;
+// values for variables defined in the manifest are generated if not found.
+type Interpolator struct {
+	staticVars StaticVariables
+	credsStore CredentialsStore
+	generator  Generator
+	logger     boshlog.Logger
+}
+
+func NewInterpolator(
+	staticVars StaticVariables,
+	credsStore CredentialsStore,
+	generator Generator,
+	logger boshlog.Logger,
+) Interpolator {
+	return Interpolator{
+		staticVars: staticVars,
+		credsStore: credsStore,
+		generator:  generator,
+		logger:     logger,
+	}
+}
+
+type manifestVariables struct {
+	Name      string              `yaml:"name"`
+	Variables []bpdepman.Variable `yaml:"variables"`
+}
+
+// Interpolate returns manifest bytes with all placeholders replaced.
+// Manifest bytes are returned as is if there are no placeholders.
+func (i Interpolator) Interpolate(bytes []byte) ([]byte, error) {
+	if !placeholderRegexp.Match(bytes) {
+		return bytes, nil
+	}
+
+	var manifest manifestVariables
+
+	err := candiedyaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing manifest variables")
+	}
+
+	return i.interpolate(bytes, manifest.Name, manifest.Variables)
+}
+
+// InterpolateWith returns document bytes (e.g. runtime config) with all placeholders
+// replaced using variables of a given deployment so that values are shared
+// with its manifest and variables defined in the manifest can be generated.
+func (i Interpolator) InterpolateWith(bytes []byte, deploymentName string, definitions []bpdepman.Variable) ([]byte, error) {
+	if !placeholderRegexp.Match(bytes) {
+		return bytes, nil
+	}
+
+	return i.interpolate(bytes, deploymentName, definitions)
+}
+
+func (i Interpolator) interpolate(bytes []byte, deploymentName string, definitions []bpdepman.Variable) ([]byte, error) {
+	var tree interface{}
+
+	err := candiedyaml.Unmarshal(bytes, &tree)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing manifest")
+	}
+
+	resolver := &variableResolver{
+		interpolator:   i,
+		deploymentName: deploymentName,
+		definitions:    definitions,
+		values:         map[string]interface{}{},
+		missing:        map[string]struct{}{},
+		generating:     map[string]struct{}{},
+	}
+
+	tree, err = resolver.interpolate(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resolver.missing) > 0 {
+		var names []string
+
+		for name := range resolver.missing {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		return nil, bosherr.Errorf("Expected to find variables: %s", strings.Join(names, ", "))
+	}
+
+	bytes, err = candiedyaml.Marshal(tree)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling interpolated manifest")
+	}
+
+	return bytes, nil
+}
+
+// variableResolver keeps track of variable values used in a single interpolation.
+type variableResolver struct {
+	interpolator   Interpolator
+	deploymentName string
+	definitions    []bpdepman.Variable
+
+	values     map[string]interface{}
+	missing    map[string]struct{}
+	generating map[string]struct{}
+}
+
+func (r *variableResolver) interpolate(node interface{}) (interface{}, error) {
+	switch typedNode := node.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range typedNode {
+			val, err := r.interpolate(v)
+			if err != nil {
+				return nil, err
+			}
+
+			typedNode[k] = val
+		}
+
+		return typedNode, nil
+
+	case []interface{}:
+		for idx, v := range typedNode {
+			val, err := r.interpolate(v)
+			if err != nil {
+				return nil, err
+			}
+
+			typedNode[idx] = val
+		}
+
+		return typedNode, nil
+
+	case string:
+		return r.interpolateString(typedNode)
+
+	default:
+		return node, nil
+	}
+}
+
+func (r *variableResolver) interpolateString(str string) (interface{}, error) {
+	// Whole string placeholders are replaced with values of any type
+	if matches := wholePlaceholderRegexp.FindStringSubmatch(str); matches != nil {
+		val, _, err := r.lookup(matches[1])
+		return val, err
+	}
+
+	var lastErr error
+
+	result := placeholderRegexp.ReplaceAllStringFunc(str, func(placeholder string) string {
+		name := placeholderRegexp.FindStringSubmatch(placeholder)[1]
+
+		val, found, err := r.lookup(name)
+		if err != nil {
+			lastErr = err
+			return placeholder
+		} else if !found {
+			return placeholder
+		}
+
+		switch val.(type) {
+		case string, int, int64, float64, bool:
+			return fmt.Sprintf("%v", val)
+		default:
+			lastErr = bosherr.Errorf("Expected variable %s to be a primitive to be used inside a string", name)
+			return placeholder
+		}
+	})
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return result, nil
+}
+
+// lookup resolves placeholder name, e.g. 'cert.private_key', to a value.
+// Missing variables are recorded and do not result in an error.
+func (r *variableResolver) lookup(placeholderName string) (interface{}, bool, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(placeholderName, "!"), "/")
+
+	pieces := strings.Split(name, ".")
+
+	val, found, err := r.find(pieces[0])
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Finding variable %s", pieces[0])
+	} else if !found {
+		r.missing[pieces[0]] = struct{}{}
+		return nil, false, nil
+	}
+
+	for _, piece := range pieces[1:] {
+		valMap, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, false, bosherr.Errorf("Expected variable %s to be a map", name)
+		}
+
+		val, ok = valMap[piece]
+		if !ok {
+			return nil, false, bosherr.Errorf("Expected variable %s to have key %s", name, piece)
+		}
+	}
+
+	return val, true, nil
+}
+
+func (r *variableResolver) find(name string) (interface{}, bool, error) {
+	if val, found := r.values[name]; found {
+		return val, true, nil
+	}
+
+	if val, found := r.interpolator.staticVars[name]; found {
+		r.values[name] = val
+		return val, true, nil
+	}
+
+	val, found, err := r.interpolator.credsStore.Find(r.deploymentName, name)
+	if err != nil {
+		return nil, false, err
+	} else if found {
+		r.values[name] = val
+		return val, true, nil
+	}
+
+	for _, definition := range r.definitions {
+		if definition.Name == name {
+			val, err := r.generate(definition)
+			if err != nil {
+				return nil, false, err
+			}
+
+			r.values[name] = val
+			return val, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func (r *variableResolver) generate(definition bpdepman.Variable) (interface{}, error) {
+	if _, found := r.generating[definition.Name]; found {
+		return nil, bosherr.Errorf("Detected cycle while generating variable %s", definition.Name)
+	}
+
+	r.generating[definition.Name] = struct{}{}
+	defer delete(r.generating, definition.Name)
+
+	options, err := bputil.NewStringKeyed().ConvertMap(definition.OptionsRaw)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Converting options")
+	}
+
+	definition.Options = options
+
+	r.interpolator.logger.Debug(interpolatorLogTag, "Generating variable %s of type %s", definition.Name, definition.Type)
+
+	findCA := func(caName string) (interface{}, error) {
+		val, found, err := r.find(caName)
+		if err != nil {
+			return nil, err
+		} else if !found {
+			return nil, bosherr.Errorf("Expected to find CA variable %s", caName)
+		}
+
+		return val, nil
+	}
+
+	val, err := r.interpolator.generator.Generate(definition, findCA)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Generating variable %s", definition.Name)
+	}
+
+	err = r.interpolator.credsStore.Save(r.deploymentName, definition.Name, val)
+	if err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
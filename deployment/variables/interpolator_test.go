@@ -0,0 +1,254 @@
+package variables_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdepman "github.com/cppforlife/bosh-provisioner/deployment/manifest"
+	. "github.com/cppforlife/bosh-provisioner/deployment/variables"
+	bpindex "github.com/cppforlife/bosh-provisioner/index"
+)
+
+var _ = Describe("Interpolator", func() {
+	var (
+		fs         *fakesys.FakeFileSystem
+		credsStore ConcreteCredentialsStore
+		staticVars StaticVariables
+		logger     boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		credsStore = NewConcreteCredentialsStore(bpindex.NewFileIndex("/creds.json", fs), logger)
+		staticVars = StaticVariables{}
+	})
+
+	interpolate := func(manifest string) (map[interface{}]interface{}, error) {
+		interpolator := NewInterpolator(staticVars, credsStore, NewGenerator(), logger)
+
+		bytes, err := interpolator.Interpolate([]byte(manifest))
+		if err != nil {
+			return nil, err
+		}
+
+		var result map[interface{}]interface{}
+
+		err = candiedyaml.Unmarshal(bytes, &result)
+		Expect(err).ToNot(HaveOccurred())
+
+		return result, nil
+	}
+
+	Describe("Interpolate", func() {
+		It("returns manifest as is if it does not have placeholders", func() {
+			interpolator := NewInterpolator(staticVars, credsStore, NewGenerator(), logger)
+
+			bytes, err := interpolator.Interpolate([]byte("name: fake-deployment\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(bytes)).To(Equal("name: fake-deployment\n"))
+		})
+
+		It("replaces placeholders with static variables", func() {
+			staticVars = StaticVariables{
+				"fake-var":  map[string]interface{}{"key": "fake-val"},
+				"fake-port": 8080,
+			}
+
+			result, err := interpolate(`
+name: fake-deployment
+properties:
+  whole: ((fake-var))
+  nested: ((fake-var.key))
+  url: http://host:((fake-port))/path
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["properties"]).To(Equal(map[interface{}]interface{}{
+				"whole":  map[interface{}]interface{}{"key": "fake-val"},
+				"nested": "fake-val",
+				"url":    "http://host:8080/path",
+			}))
+		})
+
+		It("generates defined variables and reuses them when interpolating again", func() {
+			manifest := `
+name: fake-deployment
+properties:
+  password: ((fake-password))
+variables:
+- name: fake-password
+  type: password
+`
+
+			result, err := interpolate(manifest)
+			Expect(err).ToNot(HaveOccurred())
+
+			password := result["properties"].(map[interface{}]interface{})["password"]
+			Expect(password).To(MatchRegexp(`\A[a-z0-9]{20}\z`))
+
+			result, err = interpolate(manifest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["properties"].(map[interface{}]interface{})["password"]).To(Equal(password))
+		})
+
+		It("prefers static variables over generated variables", func() {
+			staticVars = StaticVariables{"fake-password": "static-password"}
+
+			result, err := interpolate(`
+name: fake-deployment
+properties:
+  password: ((fake-password))
+variables:
+- name: fake-password
+  type: password
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["properties"]).To(Equal(map[interface{}]interface{}{
+				"password": "static-password",
+			}))
+		})
+
+		It("generates certificates signed by intermediate CA with CA chain", func() {
+			result, err := interpolate(`
+name: fake-deployment
+properties:
+  cert: ((fake-cert))
+  int_ca: ((fake-int-ca.certificate))
+  root_ca: ((fake-root-ca.certificate))
+variables:
+- name: fake-cert
+  type: certificate
+  options:
+    ca: fake-int-ca
+    common_name: fake-cn
+    alternative_names: [10.0.0.2, fake-host]
+- name: fake-int-ca
+  type: certificate
+  options:
+    ca: fake-root-ca
+    is_ca: true
+    common_name: fake-int-ca
+- name: fake-root-ca
+  type: certificate
+  options:
+    is_ca: true
+    common_name: fake-root-ca
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			props := result["properties"].(map[interface{}]interface{})
+			cert := props["cert"].(map[interface{}]interface{})
+
+			Expect(cert["ca"]).To(Equal(props["int_ca"].(string) + props["root_ca"].(string)))
+
+			roots := x509.NewCertPool()
+			roots.AppendCertsFromPEM([]byte(props["root_ca"].(string)))
+
+			intermediates := x509.NewCertPool()
+			intermediates.AppendCertsFromPEM([]byte(props["int_ca"].(string)))
+
+			block, _ := pem.Decode([]byte(cert["certificate"].(string)))
+			parsedCert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(parsedCert.Subject.CommonName).To(Equal("fake-cn"))
+			Expect(parsedCert.DNSNames).To(Equal([]string{"fake-host"}))
+			Expect(parsedCert.IPAddresses[0].String()).To(Equal("10.0.0.2"))
+
+			_, err = parsedCert.Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+				DNSName:       "fake-host",
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("generates ssh and rsa keys", func() {
+			result, err := interpolate(`
+name: fake-deployment
+properties:
+  ssh_key: ((fake-ssh.public_key))
+  ssh_fingerprint: ((fake-ssh.public_key_fingerprint))
+  rsa_key: ((fake-rsa.public_key))
+variables:
+- name: fake-ssh
+  type: ssh
+- name: fake-rsa
+  type: rsa
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			props := result["properties"].(map[interface{}]interface{})
+			Expect(props["ssh_key"]).To(HavePrefix("ssh-rsa "))
+			Expect(props["ssh_fingerprint"]).To(MatchRegexp(`\A([0-9a-f]{2}:){15}[0-9a-f]{2}\z`))
+			Expect(props["rsa_key"]).To(HavePrefix("-----BEGIN PUBLIC KEY-----"))
+		})
+
+		It("returns error listing all missing variables", func() {
+			_, err := interpolate(`
+name: fake-deployment
+properties:
+  a: ((missing-b))
+  b: prefix-((missing-a))
+`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find variables: missing-a, missing-b"))
+		})
+	})
+
+	Describe("InterpolateWith", func() {
+		var (
+			interpolator Interpolator
+			definitions  []bpdepman.Variable
+		)
+
+		BeforeEach(func() {
+			interpolator = NewInterpolator(staticVars, credsStore, NewGenerator(), logger)
+			definitions = []bpdepman.Variable{{Name: "fake-password", Type: "password"}}
+		})
+
+		It("generates variables defined by given definitions", func() {
+			bytes, err := interpolator.InterpolateWith([]byte("password: ((fake-password))\n"), "fake-deployment", definitions)
+			Expect(err).ToNot(HaveOccurred())
+
+			var result map[interface{}]interface{}
+
+			err = candiedyaml.Unmarshal(bytes, &result)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["password"]).To(HaveLen(20))
+		})
+
+		It("uses variable values of given deployment", func() {
+			manifestResult, err := interpolate(`
+name: fake-deployment
+properties:
+  password: ((fake-password))
+variables:
+- name: fake-password
+  type: password
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes, err := interpolator.InterpolateWith([]byte("password: ((fake-password))\n"), "fake-deployment", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			var result map[interface{}]interface{}
+
+			err = candiedyaml.Unmarshal(bytes, &result)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["password"]).To(Equal(manifestResult["properties"].(map[interface{}]interface{})["password"]))
+		})
+
+		It("returns error listing missing variables", func() {
+			_, err := interpolator.InterpolateWith([]byte("password: ((fake-password))\n"), "fake-deployment", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find variables: fake-password"))
+		})
+	})
+})
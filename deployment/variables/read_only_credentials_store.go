@@ -0,0 +1,33 @@
+package variables
+
+// ReadOnlyCredentialsStore keeps generated values in memory
+// so that they are shared within a single run (e.g. by manifest and runtime config)
+// without being recorded in the underlying store.
+type ReadOnlyCredentialsStore struct {
+	store  CredentialsStore
+	values map[string]interface{}
+}
+
+func NewReadOnlyCredentialsStore(store CredentialsStore) ReadOnlyCredentialsStore {
+	return ReadOnlyCredentialsStore{
+		store:  store,
+		values: map[string]interface{}{},
+	}
+}
+
+func (s ReadOnlyCredentialsStore) Find(deploymentName, name string) (interface{}, bool, error) {
+	if val, found := s.values[s.key(deploymentName, name)]; found {
+		return val, true, nil
+	}
+
+	return s.store.Find(deploymentName, name)
+}
+
+func (s ReadOnlyCredentialsStore) Save(deploymentName, name string, value interface{}) error {
+	s.values[s.key(deploymentName, name)] = value
+	return nil
+}
+
+func (s ReadOnlyCredentialsStore) key(deploymentName, name string) string {
+	return deploymentName + "/" + name
+}
@@ -0,0 +1,51 @@
+package variables_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/deployment/variables"
+	bpindex "github.com/cppforlife/bosh-provisioner/index"
+)
+
+var _ = Describe("ReadOnlyCredentialsStore", func() {
+	var (
+		fs         *fakesys.FakeFileSystem
+		credsStore ConcreteCredentialsStore
+		store      ReadOnlyCredentialsStore
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		credsStore = NewConcreteCredentialsStore(bpindex.NewFileIndex("/creds.json", fs), boshlog.NewLogger(boshlog.LevelNone))
+		store = NewReadOnlyCredentialsStore(credsStore)
+	})
+
+	It("finds values recorded in the underlying store", func() {
+		err := credsStore.Save("fake-dep", "fake-password", "fake-val")
+		Expect(err).ToNot(HaveOccurred())
+
+		val, found, err := store.Find("fake-dep", "fake-password")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(val).To(Equal("fake-val"))
+	})
+
+	It("keeps saved values only in memory", func() {
+		err := store.Save("fake-dep", "fake-password", "fake-val")
+		Expect(err).ToNot(HaveOccurred())
+
+		val, found, err := store.Find("fake-dep", "fake-password")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(val).To(Equal("fake-val"))
+
+		_, found, err = store.Find("other-dep", "fake-password")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		Expect(fs.FileExists("/creds.json")).To(BeFalse())
+	})
+})
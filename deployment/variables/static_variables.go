@@ -0,0 +1,63 @@
+package variables
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+// StaticVariables represent variable values provided by the user;
+// they take precedence over stored and generated values.
+type StaticVariables map[string]interface{}
+
+// NewStaticVariablesFromFile returns variables read from a YAML file
+// with variable names as top level keys.
+func NewStaticVariablesFromFile(path string, fs boshsys.FileSystem) (StaticVariables, error) {
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading vars file %s", path)
+	}
+
+	var varsRaw map[interface{}]interface{}
+
+	err = candiedyaml.Unmarshal(bytes, &varsRaw)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing vars file %s", path)
+	}
+
+	vars, err := bputil.NewStringKeyed().ConvertMap(varsRaw)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Converting vars file %s", path)
+	}
+
+	return StaticVariables(vars), nil
+}
+
+// NewStaticVariablesFromKV returns single string variable from 'name=value' pair.
+func NewStaticVariablesFromKV(kv string) (StaticVariables, error) {
+	pieces := strings.SplitN(kv, "=", 2)
+	if len(pieces) != 2 || pieces[0] == "" {
+		return nil, bosherr.Errorf("Expected var '%s' to be in format 'name=value'", kv)
+	}
+
+	return StaticVariables{pieces[0]: pieces[1]}, nil
+}
+
+// Merge returns new variables with values from other taking precedence.
+func (v StaticVariables) Merge(other StaticVariables) StaticVariables {
+	result := StaticVariables{}
+
+	for name, val := range v {
+		result[name] = val
+	}
+
+	for name, val := range other {
+		result[name] = val
+	}
+
+	return result
+}
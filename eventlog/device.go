@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -12,6 +13,7 @@ import (
 type Device interface {
 	WriteLogEntry(LogEntry) error
 	WriteErrorEntry(ErrorEntry) error
+	WriteWarningEntry(WarningEntry) error
 }
 
 // JSONDevice writes events as JSON log entries
@@ -40,6 +42,10 @@ func (d JSONDevice) WriteErrorEntry(entry ErrorEntry) error {
 	return d.writeEntry(entry)
 }
 
+func (d JSONDevice) WriteWarningEntry(entry WarningEntry) error {
+	return d.writeEntry(entry)
+}
+
 func (d JSONDevice) writeEntry(entry interface{}) error {
 	bytes, err := json.Marshal(entry)
 	if err != nil {
@@ -59,10 +65,11 @@ func (d JSONDevice) writeEntry(entry interface{}) error {
 func (d TextDevice) WriteLogEntry(entry LogEntry) error {
 	_, err := fmt.Fprintf(
 		d.writer,
-		"%s %s > %s\n",
-		strings.Title(entry.State),
+		"%s %s > %s%s\n",
+		strings.Title(strings.Replace(entry.State, "_", " ", -1)),
 		strings.ToLower(entry.Stage),
 		entry.Task,
+		d.heartbeatDetails(entry),
 	)
 	if err != nil {
 		return bosherr.WrapError(err, "Writing log entry")
@@ -71,6 +78,30 @@ func (d TextDevice) WriteLogEntry(entry LogEntry) error {
 	return nil
 }
 
+// heartbeatDetails returns data associated with in progress entries
+// formatted as ' (key=val, key2=val2)' with keys sorted.
+func (d TextDevice) heartbeatDetails(entry LogEntry) string {
+	if entry.State != LogEntryStateInProgress || len(entry.Data) == 0 {
+		return ""
+	}
+
+	var keys []string
+
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var pairs []string
+
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, entry.Data[key]))
+	}
+
+	return " (" + strings.Join(pairs, ", ") + ")"
+}
+
 func (d TextDevice) WriteErrorEntry(entry ErrorEntry) error {
 	_, err := fmt.Fprintf(d.writer, "\n-----\nError: %s\n-----\n", entry.Body.Message)
 	if err != nil {
@@ -79,3 +110,12 @@ func (d TextDevice) WriteErrorEntry(entry ErrorEntry) error {
 
 	return nil
 }
+
+func (d TextDevice) WriteWarningEntry(entry WarningEntry) error {
+	_, err := fmt.Fprintf(d.writer, "Warning: %s\n", entry.Body.Message)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing warning entry")
+	}
+
+	return nil
+}
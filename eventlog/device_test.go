@@ -0,0 +1,109 @@
+package eventlog_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/eventlog"
+)
+
+var _ = Describe("JSONDevice", func() {
+	var (
+		buffer *bytes.Buffer
+		device JSONDevice
+	)
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		device = NewJSONDevice(buffer)
+	})
+
+	Describe("WriteLogEntry", func() {
+		It("writes in progress entry with heartbeat data", func() {
+			err := device.WriteLogEntry(LogEntry{
+				Time:  123,
+				Stage: "Compiling packages",
+				Task:  "golang",
+				Total: 2,
+				Index: 1,
+				State: LogEntryStateInProgress,
+				Data:  map[string]interface{}{"elapsed": "10s"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).To(Equal(`{"time":123,"stage":"Compiling packages","task":"golang","tags":null,` +
+				`"total":2,"index":1,"state":"in_progress","progress":0,"data":{"elapsed":"10s"}}` + "\n"))
+		})
+	})
+
+	Describe("WriteWarningEntry", func() {
+		It("writes warning entry as a single JSON line", func() {
+			err := device.WriteWarningEntry(WarningEntry{
+				Time: 123,
+				Body: WarningEntryBody{Message: "fake-warning"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).To(Equal(`{"time":123,"warning":{"message":"fake-warning"}}` + "\n"))
+		})
+	})
+})
+
+var _ = Describe("TextDevice", func() {
+	var (
+		buffer *bytes.Buffer
+		device TextDevice
+	)
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		device = NewTextDevice(buffer)
+	})
+
+	Describe("WriteLogEntry", func() {
+		It("writes started entry", func() {
+			err := device.WriteLogEntry(LogEntry{Stage: "Compiling packages", Task: "golang", State: LogEntryStateStarted})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).To(Equal("Started compiling packages > golang\n"))
+		})
+
+		It("writes finished entry without data", func() {
+			err := device.WriteLogEntry(LogEntry{
+				Stage: "Compiling packages",
+				Task:  "golang",
+				State: LogEntryStateFailed,
+				Data:  map[string]interface{}{"error": "fake-err"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).To(Equal("Failed compiling packages > golang\n"))
+		})
+
+		It("writes in progress entry with heartbeat data sorted by key", func() {
+			err := device.WriteLogEntry(LogEntry{
+				Stage: "Compiling packages",
+				Task:  "golang",
+				State: LogEntryStateInProgress,
+				Data:  map[string]interface{}{"state": "running", "elapsed": "10s"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).To(Equal("In Progress compiling packages > golang (elapsed=10s, state=running)\n"))
+		})
+
+		It("writes in progress entry without heartbeat data", func() {
+			err := device.WriteLogEntry(LogEntry{Stage: "Compiling packages", Task: "golang", State: LogEntryStateInProgress})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).To(Equal("In Progress compiling packages > golang\n"))
+		})
+	})
+
+	Describe("WriteWarningEntry", func() {
+		It("writes warning message", func() {
+			err := device.WriteWarningEntry(WarningEntry{
+				Time: 123,
+				Body: WarningEntryBody{Message: "fake-warning"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).To(Equal("Warning: fake-warning\n"))
+		})
+	})
+})
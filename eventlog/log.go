@@ -8,6 +8,13 @@ import (
 
 const logLogTag = "Log"
 
+const (
+	LogEntryStateStarted    = "started"
+	LogEntryStateInProgress = "in_progress"
+	LogEntryStateFinished   = "finished"
+	LogEntryStateFailed     = "failed"
+)
+
 type Log struct {
 	device Device
 	logger boshlog.Logger
@@ -26,7 +33,7 @@ type LogEntry struct {
 	State    string `json:"state"`
 	Progress int    `json:"progress"`
 
-	// Might contain error key
+	// Might contain error key or heartbeat details
 	Data map[string]interface{} `json:"data,omitempty"`
 }
 
@@ -41,6 +48,17 @@ type ErrorEntryBody struct {
 	Message string `json:"message"`
 }
 
+// WarningEntry describes a problem that does not stop provisioning
+type WarningEntry struct {
+	Time int64 `json:"time"`
+
+	Body WarningEntryBody `json:"warning"`
+}
+
+type WarningEntryBody struct {
+	Message string `json:"message"`
+}
+
 func NewLog(device Device, logger boshlog.Logger) Log {
 	return Log{device: device, logger: logger}
 }
@@ -71,6 +89,21 @@ func (l Log) WriteErr(err error) {
 	}
 }
 
+func (l Log) WriteWarning(message string) {
+	entry := WarningEntry{
+		Time: time.Now().Unix(),
+
+		Body: WarningEntryBody{Message: message},
+	}
+
+	l.logger.Warn(logLogTag, "Warning: %s", message)
+
+	writeErr := l.device.WriteWarningEntry(entry)
+	if writeErr != nil {
+		l.logger.Error(logLogTag, "Failed writing warning entry %s", writeErr)
+	}
+}
+
 func (l Log) WriteLogEntryNoErr(entry LogEntry) {
 	err := l.device.WriteLogEntry(entry)
 	if err != nil {
@@ -24,13 +24,34 @@ func (t Task) Start() {
 		Total: t.total,
 		Index: t.index,
 
-		State:    "started",
+		State:    LogEntryStateStarted,
 		Progress: 0,
 	}
 
 	t.log.WriteLogEntryNoErr(entry)
 }
 
+// Heartbeat indicates that task is still making progress.
+// Data is used to describe what's currently happening (e.g. elapsed time).
+func (t Task) Heartbeat(data map[string]interface{}) {
+	entry := LogEntry{
+		Time: time.Now().Unix(),
+
+		Stage: t.stageName,
+		Task:  t.name,
+
+		Total: t.total,
+		Index: t.index,
+
+		State:    LogEntryStateInProgress,
+		Progress: 0,
+
+		Data: data,
+	}
+
+	t.log.WriteLogEntryNoErr(entry)
+}
+
 func (t Task) End(err error) error {
 	entry := LogEntry{
 		Time: time.Now().Unix(),
@@ -41,12 +62,12 @@ func (t Task) End(err error) error {
 		Total: t.total,
 		Index: t.index,
 
-		State:    "finished",
+		State:    LogEntryStateFinished,
 		Progress: 100,
 	}
 
 	if err != nil {
-		entry.State = "failed"
+		entry.State = LogEntryStateFailed
 		entry.Data = map[string]interface{}{
 			"error": err.Error(),
 		}
@@ -0,0 +1,63 @@
+package eventlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/eventlog"
+)
+
+var _ = Describe("Task", func() {
+	var (
+		buffer *bytes.Buffer
+		log    Log
+	)
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		log = NewLog(NewJSONDevice(buffer), boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	entries := func() []LogEntry {
+		var result []LogEntry
+
+		for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+			var entry LogEntry
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			result = append(result, entry)
+		}
+
+		return result
+	}
+
+	Describe("Heartbeat", func() {
+		It("writes in progress entry with given data between started and finished entries", func() {
+			task := log.BeginStage("Compiling packages", 2).BeginTask("golang")
+
+			task.Heartbeat(map[string]interface{}{"elapsed": "10s"})
+
+			Expect(task.End(nil)).To(Succeed())
+
+			written := entries()
+			Expect(written).To(HaveLen(3))
+
+			Expect(written[0].State).To(Equal(LogEntryStateStarted))
+
+			Expect(written[1].Stage).To(Equal("Compiling packages"))
+			Expect(written[1].Task).To(Equal("golang"))
+			Expect(written[1].Total).To(Equal(2))
+			Expect(written[1].Index).To(Equal(0))
+			Expect(written[1].State).To(Equal(LogEntryStateInProgress))
+			Expect(written[1].Progress).To(Equal(0))
+			Expect(written[1].Data).To(Equal(map[string]interface{}{"elapsed": "10s"}))
+
+			Expect(written[2].State).To(Equal(LogEntryStateFinished))
+			Expect(written[2].Progress).To(Equal(100))
+		})
+	})
+})
@@ -6,11 +6,16 @@ import (
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
 )
 
 type FileIndex struct {
 	path string
 	fs   boshsys.FileSystem
+
+	// Private index file is only accessible by the owner
+	private bool
 }
 
 type fileIndexLockedRecord struct {
@@ -27,6 +32,11 @@ func NewFileIndex(path string, fs boshsys.FileSystem) FileIndex {
 	return FileIndex{path: path, fs: fs}
 }
 
+// NewPrivateFileIndex returns index for sensitive entries (e.g. credentials)
+func NewPrivateFileIndex(path string, fs boshsys.FileSystem) FileIndex {
+	return FileIndex{path: path, fs: fs, private: true}
+}
+
 func (ri FileIndex) List(entries interface{}) error {
 	rawEntries, err := ri.readRawEntries()
 	if err != nil {
@@ -204,7 +214,12 @@ func (ri FileIndex) writeRawEntries(entries []indexEntry) error {
 		return bosherr.WrapError(err, "Marshalling index entries")
 	}
 
-	err = ri.fs.WriteFile(ri.path, bytes)
+	if ri.private {
+		err = bputil.WritePrivateFile(ri.fs, ri.path, bytes)
+	} else {
+		err = ri.fs.WriteFile(ri.path, bytes)
+	}
+
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Writing index file %s", ri.path)
 	}
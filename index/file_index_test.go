@@ -1,6 +1,9 @@
 package index_test
 
 import (
+	"os"
+	"path/filepath"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	. "github.com/onsi/ginkgo"
@@ -29,12 +32,13 @@ type Name struct {
 
 var _ = Describe("FileIndex", func() {
 	var (
+		fs    boshsys.FileSystem
 		index FileIndex
 	)
 
 	BeforeEach(func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		fs := boshsys.NewOsFileSystem(logger)
+		fs = boshsys.NewOsFileSystem(logger)
 
 		file, err := fs.TempFile("file-index")
 		Expect(err).ToNot(HaveOccurred())
@@ -294,5 +298,27 @@ var _ = Describe("FileIndex", func() {
 		})
 	})
 
+	Describe("private index", func() {
+		It("writes index file only accessible by the owner", func() {
+			dirPath, err := fs.TempDir("file-index")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer fs.RemoveAll(dirPath)
+
+			path := filepath.Join(dirPath, "creds", "index.json")
+
+			err = NewPrivateFileIndex(path, fs).Save(Key{Key: "key-1"}, Value{Name: "value-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			info, err := os.Stat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			info, err = os.Stat(filepath.Dir(path))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		})
+	})
+
 	// todo locked methods
 })
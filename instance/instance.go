@@ -33,6 +33,19 @@ func NewInstance(
 	}
 }
 
+// PostDeploy runs post-deploy scripts; it should be called
+// after all instances in the deployment are provisioned.
+func (i Instance) PostDeploy() error {
+	i.logger.Debug(instanceLogTag, "Post-Deploying instance")
+
+	err := i.updater.PostDeploy()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Post-Deploying instance %d", i.depInstance.Index)
+	}
+
+	return nil
+}
+
 func (i Instance) Deprovision() error {
 	i.logger.Debug(instanceLogTag, "Tearing down instance")
 
@@ -8,6 +8,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bperb "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/erbrenderer"
 	bpjobsrepo "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/jobsrepo"
 	bptplsrepo "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/templatesrepo"
 	bprel "github.com/cppforlife/bosh-provisioner/release"
@@ -122,6 +123,64 @@ func (tc ConcreteTemplatesCompiler) Compile(job bpdep.Job, instance bpdep.Instan
 	return nil
 }
 
+// ValidateProperties compares instance properties with property definitions
+// of previously precompiled release jobs used by a deployment job.
+func (tc ConcreteTemplatesCompiler) ValidateProperties(job bpdep.Job, instance bpdep.Instance) (bperb.PropertiesValidationResult, error) {
+	var result bperb.PropertiesValidationResult
+
+	jobReaders, err := tc.buildJobReaders(job)
+	if err != nil {
+		return result, bosherr.WrapError(err, "Building job readers")
+	}
+
+	var relJobs []bpreljob.Job
+
+	for _, jobReader := range jobReaders {
+		relJob, err := jobReader.tarReader.Read()
+		if err != nil {
+			return result, bosherr.WrapError(err, "Reading job")
+		}
+
+		defer jobReader.tarReader.Close()
+
+		relJob.DeploymentJobTemplates = job.Templates
+
+		relJobs = append(relJobs, relJob)
+	}
+
+	return bperb.NewPropertiesValidator().Validate(relJobs, instance), nil
+}
+
+// ResolveLinks populates consumed links of all deployment job templates
+// based on link definitions of previously precompiled release jobs.
+func (tc ConcreteTemplatesCompiler) ResolveLinks(deployment *bpdep.Deployment) error {
+	var relJobs [][]bpreljob.Job
+
+	for _, job := range deployment.Jobs {
+		jobReaders, err := tc.buildJobReaders(job)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Building job readers %s", job.Name)
+		}
+
+		var jobRelJobs []bpreljob.Job
+
+		for _, jobReader := range jobReaders {
+			relJob, err := jobReader.tarReader.Read()
+			if err != nil {
+				return bosherr.WrapError(err, "Reading job")
+			}
+
+			defer jobReader.tarReader.Close()
+
+			jobRelJobs = append(jobRelJobs, relJob)
+		}
+
+		relJobs = append(relJobs, jobRelJobs)
+	}
+
+	return NewLinksResolver().Resolve(deployment, relJobs)
+}
+
 // FindPackages returns list of packages required to run job template.
 // List of packages is usually specified in release job metadata.
 func (tc ConcreteTemplatesCompiler) FindPackages(template bpdep.Template) ([]bprel.Package, error) {
@@ -0,0 +1,125 @@
+package erbrenderer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	. "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/erbrenderer"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+)
+
+var _ = Describe("ERBRenderer", func() {
+	var (
+		dirPath string
+		fs      boshsys.FileSystem
+		runner  boshsys.CmdRunner
+		logger  boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		_, err := exec.LookPath("ruby")
+		if err != nil {
+			Skip("ruby is not available")
+		}
+
+		dirPath, err = ioutil.TempDir("", "erb-renderer")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		runner = boshsys.NewExecCmdRunner(logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dirPath)
+	})
+
+	render := func(template string) (string, error) {
+		relJob := bpreljob.Job{
+			Name: "web",
+			DeploymentJobTemplates: []bpdep.Template{{
+				Name: "web",
+				Links: map[string]bpdep.Link{
+					"database": bpdep.Link{
+						Name: "db",
+						Type: "postgres",
+						Instances: []bpdep.LinkInstance{
+							{Name: "db", Index: 0, ID: "db-0", Address: "10.0.0.5", Bootstrap: true},
+						},
+						Properties: bpdep.Properties{"port": 5432},
+					},
+				},
+			}},
+		}
+
+		instance := bpdep.Instance{Properties: bpdep.Properties{"port": 80}}
+
+		srcPath := filepath.Join(dirPath, "src.erb")
+		dstPath := filepath.Join(dirPath, "dst")
+
+		err := ioutil.WriteFile(srcPath, []byte(template), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		context := NewTemplateEvaluationContext(relJob, instance)
+
+		err = NewERBRenderer(fs, runner, context, logger).Render(srcPath, dstPath)
+		if err != nil {
+			return "", err
+		}
+
+		bytes, err := ioutil.ReadFile(dstPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		return string(bytes), nil
+	}
+
+	It("renders link address and properties", func() {
+		result, err := render(`<%= link("database").address %>:<%= link("database").p("port") %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("10.0.0.5:5432"))
+	})
+
+	It("returns error if link is not consumed", func() {
+		_, err := render(`<%= link("missing").address %>`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Running ruby"))
+	})
+
+	It("renders if_link block if link is consumed", func() {
+		result, err := render(`<% if_link("database") do |db| %><%= db.p("port") %><% end.else do %>none<% end %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("5432"))
+	})
+
+	It("renders else block if link is not consumed", func() {
+		result, err := render(`<% if_link("missing") do |l| %>found<% end.else do %>none<% end %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("none"))
+	})
+
+	It("looks up links in the template from else blocks of link properties", func() {
+		result, err := render(`<% link("database").if_p("missing") do |v| %>found<% end.else_if_link("database") do |db| %><%= db.address %><% end %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("10.0.0.5"))
+	})
+
+	It("looks up link properties from else blocks of link properties", func() {
+		result, err := render(`<% link("database").if_p("missing") do |v| %>found<% end.else_if_p("port") do |port| %><%= port %><% end %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("5432"))
+	})
+
+	It("does not look up nested properties in non-hash values", func() {
+		result, err := render(`<%= p("port.nested", "default") %>,<%= link("database").p("port.nested", "default") %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("default,default"))
+	})
+})
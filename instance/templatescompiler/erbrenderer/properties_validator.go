@@ -0,0 +1,243 @@
+package erbrenderer
+
+import (
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
+)
+
+// PropertiesValidationResult lists problems found with properties
+// given to a deployment job. Unknown properties are not fatal since
+// deployment level properties are shared by all jobs; however,
+// missing properties would fail template rendering.
+type PropertiesValidationResult struct {
+	Unknown bpvalid.Errors
+	Missing bpvalid.Errors
+}
+
+// Err returns nil if there are no missing properties
+func (r PropertiesValidationResult) Err() error {
+	if len(r.Missing) == 0 {
+		return nil
+	}
+
+	return r.Missing
+}
+
+// PropertiesValidator compares properties that will be used to render
+// release jobs with property definitions found in release job specs.
+type PropertiesValidator struct{}
+
+func NewPropertiesValidator() PropertiesValidator {
+	return PropertiesValidator{}
+}
+
+// Validate reports properties not defined by any of the release jobs
+// (with suggestions for likely typos) and properties without defaults
+// that are required by release jobs but are not set.
+func (v PropertiesValidator) Validate(relJobs []bpreljob.Job, instance bpdep.Instance) PropertiesValidationResult {
+	unknownC := bpvalid.NewCollector()
+	missingC := bpvalid.NewCollector()
+
+	var sharedNames []string
+	var sharedLegacy bool
+
+	for _, relJob := range relJobs {
+		props, templateSpecific := jobProperties(relJob, instance)
+
+		missingC := missingC.Key("templates").Key(relJob.Name)
+
+		for _, prop := range relJob.Properties {
+			if prop.Default == nil && !v.hasProperty(props, prop.Name) {
+				missingC.Add(bosherr.Errorf("Missing required property '%s'", prop.Name))
+			}
+		}
+
+		names := v.propertyNames(relJob)
+
+		if templateSpecific {
+			// Jobs that do not define any properties receive all properties
+			if len(names) > 0 {
+				c := unknownC.Key("templates").Key(relJob.Name).Key("properties")
+				v.validateKnown(props, "", names, c)
+			}
+		} else {
+			sharedNames = append(sharedNames, names...)
+			sharedLegacy = sharedLegacy || len(names) == 0
+		}
+	}
+
+	if len(sharedNames) > 0 && !sharedLegacy {
+		v.validateKnown(instance.Properties, "", sharedNames, unknownC.Key("properties"))
+	}
+
+	return PropertiesValidationResult{
+		Unknown: v.collected(unknownC),
+		Missing: v.collected(missingC),
+	}
+}
+
+func (v PropertiesValidator) propertyNames(relJob bpreljob.Job) []string {
+	var names []string
+
+	for _, prop := range relJob.Properties {
+		names = append(names, prop.Name)
+	}
+
+	return names
+}
+
+// validateKnown walks properties and adds an error for the shallowest
+// property path that is neither defined nor leads to a defined property.
+func (v PropertiesValidator) validateKnown(props map[string]interface{}, prefix string, names []string, c bpvalid.Collector) {
+	var keys []string
+
+	for key := range props {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if v.isDefined(path, names) {
+			continue
+		}
+
+		if v.isSection(path, names) {
+			if nested, ok := props[key].(map[string]interface{}); ok {
+				v.validateKnown(nested, path, names, c.Key(key))
+			}
+			continue
+		}
+
+		err := bosherr.Errorf("Unknown property '%s'", path)
+
+		if suggestion := v.suggest(path, names); suggestion != "" {
+			err = bosherr.Errorf("Unknown property '%s' (did you mean '%s'?)", path, suggestion)
+		}
+
+		c.Key(key).Add(err)
+	}
+}
+
+// isDefined returns true if path is a defined property or is nested within one
+func (v PropertiesValidator) isDefined(path string, names []string) bool {
+	for _, name := range names {
+		if path == name || strings.HasPrefix(path, name+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSection returns true if path contains at least one defined property
+func (v PropertiesValidator) isSection(path string, names []string) bool {
+	for _, name := range names {
+		if strings.HasPrefix(name, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// suggest returns the closest defined property (or its section)
+// for a likely misspelled property path; empty if nothing is close enough.
+func (v PropertiesValidator) suggest(path string, names []string) string {
+	var suggestion string
+
+	bestDist := len(path)/3 + 1
+
+	depth := strings.Count(path, ".") + 1
+
+	for _, name := range names {
+		parts := strings.Split(name, ".")
+		if len(parts) > depth {
+			parts = parts[:depth]
+		}
+
+		candidate := strings.Join(parts, ".")
+
+		dist := levenshteinDistance(path, candidate)
+		if dist < bestDist || (dist == bestDist && suggestion != "" && candidate < suggestion) {
+			suggestion = candidate
+			bestDist = dist
+		}
+	}
+
+	return suggestion
+}
+
+// hasProperty returns true if dot separated path is set in properties
+func (v PropertiesValidator) hasProperty(props map[string]interface{}, path string) bool {
+	var current interface{} = props
+
+	for _, part := range strings.Split(path, ".") {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		value, found := currentMap[part]
+		if !found {
+			return false
+		}
+
+		current = value
+	}
+
+	return true
+}
+
+func (v PropertiesValidator) collected(c bpvalid.Collector) bpvalid.Errors {
+	if errs, ok := c.Errors().(bpvalid.Errors); ok {
+		return errs
+	}
+
+	return nil
+}
+
+func levenshteinDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,127 @@
+package erbrenderer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	. "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/erbrenderer"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+)
+
+var _ = Describe("PropertiesValidator", func() {
+	var (
+		relJobs   []bpreljob.Job
+		instance  bpdep.Instance
+		validator PropertiesValidator
+	)
+
+	BeforeEach(func() {
+		validator = NewPropertiesValidator()
+
+		relJobs = []bpreljob.Job{
+			bpreljob.Job{
+				Name: "web",
+				Properties: []bpreljob.Property{
+					bpreljob.Property{Name: "web.port", Default: 8080},
+					bpreljob.Property{Name: "web.password"},
+					bpreljob.Property{Name: "web.tls"},
+				},
+			},
+			bpreljob.Job{
+				Name: "worker",
+				Properties: []bpreljob.Property{
+					bpreljob.Property{Name: "nats.machines"},
+				},
+			},
+		}
+	})
+
+	Describe("Validate", func() {
+		It("returns no problems when all required properties are set", func() {
+			instance.Properties = bpdep.Properties{
+				"web": map[string]interface{}{
+					"password": "secret",
+					"tls":      map[string]interface{}{"cert": "cert", "key": "key"},
+				},
+				"nats": map[string]interface{}{"machines": []interface{}{"10.0.0.1"}},
+			}
+
+			result := validator.Validate(relJobs, instance)
+			Expect(result.Unknown).To(BeEmpty())
+			Expect(result.Missing).To(BeEmpty())
+			Expect(result.Err()).ToNot(HaveOccurred())
+		})
+
+		It("reports missing required properties per job", func() {
+			instance.Properties = bpdep.Properties{
+				"web": map[string]interface{}{"tls": "tls"},
+			}
+
+			result := validator.Validate(relJobs, instance)
+			Expect(result.Err()).To(HaveOccurred())
+			Expect(result.Missing).To(HaveLen(2))
+			Expect(result.Missing[0].Error()).To(Equal(
+				"templates.web: Missing required property 'web.password'"))
+			Expect(result.Missing[1].Error()).To(Equal(
+				"templates.worker: Missing required property 'nats.machines'"))
+		})
+
+		It("reports unknown properties with suggestions", func() {
+			instance.Properties = bpdep.Properties{
+				"web": map[string]interface{}{
+					"password": "secret",
+					"pasword":  "secret",
+					"tls":      "tls",
+				},
+				"nat":       map[string]interface{}{"machines": []interface{}{}},
+				"nats":      map[string]interface{}{"machines": []interface{}{}},
+				"unrelated": "value",
+			}
+
+			result := validator.Validate(relJobs, instance)
+			Expect(result.Err()).ToNot(HaveOccurred())
+			Expect(result.Unknown).To(HaveLen(3))
+			Expect(result.Unknown[0].Error()).To(Equal(
+				"properties.nat: Unknown property 'nat' (did you mean 'nats'?)"))
+			Expect(result.Unknown[1].Error()).To(Equal(
+				"properties.unrelated: Unknown property 'unrelated'"))
+			Expect(result.Unknown[2].Error()).To(Equal(
+				"properties.web.pasword: Unknown property 'web.pasword' (did you mean 'web.password'?)"))
+		})
+
+		It("uses deployment template properties instead of instance properties if specified", func() {
+			relJobs[0].DeploymentJobTemplates = []bpdep.Template{
+				bpdep.Template{
+					Name: "web",
+					Properties: bpdep.Properties{
+						"web": map[string]interface{}{"password": "secret", "tls": "tls", "prot": 80},
+					},
+				},
+			}
+
+			instance.Properties = bpdep.Properties{
+				"nats": map[string]interface{}{"machines": []interface{}{}},
+			}
+
+			result := validator.Validate(relJobs, instance)
+			Expect(result.Missing).To(BeEmpty())
+			Expect(result.Unknown).To(HaveLen(1))
+			Expect(result.Unknown[0].Error()).To(Equal(
+				"templates.web.properties.web.prot: Unknown property 'web.prot' (did you mean 'web.port'?)"))
+		})
+
+		It("does not report unknown properties when a job does not define any properties", func() {
+			relJobs = append(relJobs, bpreljob.Job{Name: "legacy"})
+
+			instance.Properties = bpdep.Properties{
+				"web":       map[string]interface{}{"password": "secret", "tls": "tls"},
+				"nats":      map[string]interface{}{"machines": []interface{}{}},
+				"unrelated": "value",
+			}
+
+			result := validator.Validate(relJobs, instance)
+			Expect(result.Unknown).To(BeEmpty())
+		})
+	})
+})
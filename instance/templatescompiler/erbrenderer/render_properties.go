@@ -20,6 +20,7 @@ func NewRenderProperties(relJob bpreljob.Job, instance bpdep.Instance) RenderPro
 }
 
 // AsMap returns job and instance properties merged together.
+// Deployment template properties take place of instance properties if specified.
 func (p RenderProperties) AsMap() (map[string]interface{}, error) {
 	result, err := p.deepCopyInstanceProperties()
 	if err != nil {
@@ -59,11 +60,13 @@ func (p RenderProperties) copyProperty(path string, value interface{}, dst map[s
 func (p RenderProperties) deepCopyInstanceProperties() (map[string]interface{}, error) {
 	result := map[string]interface{}{}
 
-	if p.instance.Properties == nil {
+	properties, _ := jobProperties(p.relJob, p.instance)
+
+	if properties == nil {
 		return result, nil
 	}
 
-	bytes, err := json.Marshal(p.instance.Properties)
+	bytes, err := json.Marshal(properties)
 	if err != nil {
 		return result, bosherr.WrapError(err, "Marshalling instance properties")
 	}
@@ -75,3 +78,16 @@ func (p RenderProperties) deepCopyInstanceProperties() (map[string]interface{},
 
 	return result, nil
 }
+
+// jobProperties returns deployment template properties for a release job if specified;
+// otherwise instance properties are returned. Second return value indicates
+// whether template properties were selected.
+func jobProperties(relJob bpreljob.Job, instance bpdep.Instance) (bpdep.Properties, bool) {
+	for _, template := range relJob.DeploymentJobTemplates {
+		if template.Name == relJob.Name && template.Properties != nil {
+			return template.Properties, true
+		}
+	}
+
+	return instance.Properties, false
+}
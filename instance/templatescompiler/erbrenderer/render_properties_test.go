@@ -189,5 +189,44 @@ var _ = Describe("RenderProperties", func() {
 				})
 			})
 		}) //~
+
+		Context("when deployment template specifies its own properties", func() {
+			BeforeEach(func() {
+				job = bpreljob.Job{
+					Name: "fake-job",
+					Properties: []bpreljob.Property{
+						bpreljob.Property{
+							Name:    "prop.nest-prop",
+							Default: "job-val",
+						},
+					},
+					DeploymentJobTemplates: []bpdep.Template{
+						bpdep.Template{
+							Name: "fake-job",
+							Properties: map[string]interface{}{
+								"other-prop": "template-val",
+							},
+						},
+					},
+				}
+
+				instance = bpdep.Instance{
+					Properties: map[string]interface{}{
+						"prop": map[string]interface{}{
+							"nest-prop": "instance-val",
+						},
+					},
+				}
+			})
+
+			It("returns map with template properties instead of instance properties", func() {
+				Expect(props.AsMap()).To(Equal(map[string]interface{}{
+					"prop": map[string]interface{}{
+						"nest-prop": "job-val",
+					},
+					"other-prop": "template-val",
+				}))
+			})
+		})
 	})
 })
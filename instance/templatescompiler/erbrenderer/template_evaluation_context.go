@@ -27,6 +27,9 @@ type rootContext struct {
 	NetworkContexts map[string]networkContext `json:"networks"`
 
 	Properties map[string]interface{} `json:"properties"`
+
+	// Links consumed by the rendered job keyed by link name
+	Links map[string]linkContext `json:"links"`
 }
 
 type jobContext struct {
@@ -38,11 +41,27 @@ type templateContext struct {
 	Name string `json:"name"`
 }
 
+type linkContext struct {
+	Address    string                 `json:"address"`
+	Instances  []linkInstanceContext  `json:"instances"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type linkInstanceContext struct {
+	Name      string `json:"name"`
+	Index     int    `json:"index"`
+	ID        string `json:"id"`
+	Address   string `json:"address"`
+	Bootstrap bool   `json:"bootstrap"`
+}
+
 // networkContext is not fully backwards compatible.
 type networkContext struct {
 	IP      string `json:"ip"`
 	Netmask string `json:"netmask"`
 	Gateway string `json:"gateway"`
+
+	DNS []string `json:"dns,omitempty"`
 }
 
 func NewTemplateEvaluationContext(
@@ -68,6 +87,8 @@ func (c TemplateEvaluationContext) MarshalJSON() ([]byte, error) {
 
 		NetworkContexts: c.buildNetworkContexts(),
 		Properties:      properties,
+
+		Links: c.buildLinks(),
 	}
 
 	return json.Marshal(context)
@@ -81,6 +102,37 @@ func (c TemplateEvaluationContext) buildTemplates() []templateContext {
 	return templates
 }
 
+func (c TemplateEvaluationContext) buildLinks() map[string]linkContext {
+	links := map[string]linkContext{}
+
+	for _, template := range c.relJob.DeploymentJobTemplates {
+		if template.Name != c.relJob.Name {
+			continue
+		}
+
+		for name, link := range template.Links {
+			linkCtx := linkContext{
+				Address:    link.Address(),
+				Properties: link.Properties,
+			}
+
+			for _, instance := range link.Instances {
+				linkCtx.Instances = append(linkCtx.Instances, linkInstanceContext{
+					Name:      instance.Name,
+					Index:     instance.Index,
+					ID:        instance.ID,
+					Address:   instance.Address,
+					Bootstrap: instance.Bootstrap,
+				})
+			}
+
+			links[name] = linkCtx
+		}
+	}
+
+	return links
+}
+
 func (c TemplateEvaluationContext) buildNetworkContexts() map[string]networkContext {
 	networkContexts := map[string]networkContext{}
 
@@ -91,6 +143,8 @@ func (c TemplateEvaluationContext) buildNetworkContexts() map[string]networkCont
 			IP:      netConfig.IP,
 			Netmask: netConfig.Netmask,
 			Gateway: netConfig.Gateway,
+
+			DNS: netConfig.DNS,
 		}
 	}
 
@@ -20,11 +20,14 @@ class TemplateEvaluationContext
     @index = spec["index"]
     @properties = openstruct(spec["properties"] || {})
     @raw_properties = spec["properties"] || {}
+    @links = spec["links"] || {}
     @spec = openstruct(spec)
   end
 
   def get_binding
-    binding.taint
+    b = binding
+    b.taint if b.respond_to?(:taint) # removed in ruby 3.2
+    b
   end
 
   def p(*args)
@@ -50,6 +53,20 @@ class TemplateEvaluationContext
     InactiveElseBlock.new
   end
 
+  def link(name)
+    link_spec = @links[name]
+    raise UnknownLink.new(name) if link_spec.nil?
+    EvaluationLink.new(link_spec, self)
+  end
+
+  def if_link(name)
+    link_spec = @links[name]
+    return ActiveElseBlock.new(self) if link_spec.nil?
+
+    yield EvaluationLink.new(link_spec, self)
+    InactiveElseBlock.new
+  end
+
   private
   
   def openstruct(object)
@@ -69,6 +86,7 @@ class TemplateEvaluationContext
     ref = collection
 
     keys.each do |key|
+      return nil unless ref.is_a?(Hash)
       ref = ref[key]
       return nil if ref.nil?
     end
@@ -85,9 +103,74 @@ class TemplateEvaluationContext
     end
   end
 
+  class UnknownLink < StandardError
+    attr_reader :name
+
+    def initialize(name)
+      @name = name
+      super("Can't find link '#{name}'")
+    end
+  end
+
+  class EvaluationLink
+    attr_reader :instances, :properties
+
+    def initialize(link_spec, template)
+      @template = template
+      @address = link_spec["address"]
+      @instances = (link_spec["instances"] || []).map { |i| OpenStruct.new(i) }
+      @properties = link_spec["properties"] || {}
+    end
+
+    def address
+      @address
+    end
+
+    def p(*args)
+      names = Array(args[0])
+
+      names.each do |name|
+        result = lookup_property(@properties, name)
+        return result unless result.nil?
+      end
+
+      return args[1] if args.length == 2
+      raise UnknownProperty.new(names)
+    end
+
+    def if_p(*names)
+      values = names.map do |name|
+        value = lookup_property(@properties, name)
+        return ActiveElseBlock.new(self, @template) if value.nil?
+        value
+      end
+
+      yield *values
+      InactiveElseBlock.new
+    end
+
+    private
+
+    def lookup_property(collection, name)
+      keys = name.split(".")
+      ref = collection
+
+      keys.each do |key|
+        return nil unless ref.is_a?(Hash)
+        ref = ref[key]
+        return nil if ref.nil?
+      end
+
+      ref
+    end
+  end
+
+  # Properties are looked up in the context that created the block
+  # (template or link); links are always looked up in the template
   class ActiveElseBlock
-    def initialize(template)
-      @context = template
+    def initialize(context, template = context)
+      @context = context
+      @template = template
     end
 
     def else
@@ -97,6 +180,10 @@ class TemplateEvaluationContext
     def else_if_p(*names, &block)
       @context.if_p(*names, &block)
     end
+
+    def else_if_link(name, &block)
+      @template.if_link(name, &block)
+    end
   end
 
   class InactiveElseBlock
@@ -105,6 +192,10 @@ class TemplateEvaluationContext
     def else_if_p(*names)
       InactiveElseBlock.new
     end
+
+    def else_if_link(name)
+      InactiveElseBlock.new
+    end
   end
 end
 
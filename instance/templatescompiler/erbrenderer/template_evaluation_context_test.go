@@ -0,0 +1,67 @@
+package erbrenderer_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	. "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/erbrenderer"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+)
+
+var _ = Describe("TemplateEvaluationContext", func() {
+	Describe("MarshalJSON", func() {
+		It("includes links consumed by the rendered job", func() {
+			relJob := bpreljob.Job{
+				Name: "web",
+				DeploymentJobTemplates: []bpdep.Template{
+					{
+						Name: "web",
+						Links: map[string]bpdep.Link{
+							"database": bpdep.Link{
+								Name: "db",
+								Type: "postgres",
+								Instances: []bpdep.LinkInstance{
+									{Name: "db", Index: 0, ID: "db-0", Address: "10.0.0.5", Bootstrap: true},
+								},
+								Properties: bpdep.Properties{"port": 5432},
+							},
+						},
+					},
+					{
+						Name: "other",
+						Links: map[string]bpdep.Link{
+							"other-link": bpdep.Link{Name: "other-link"},
+						},
+					},
+				},
+			}
+
+			bytes, err := json.Marshal(NewTemplateEvaluationContext(relJob, bpdep.Instance{}))
+			Expect(err).ToNot(HaveOccurred())
+
+			var context map[string]interface{}
+
+			err = json.Unmarshal(bytes, &context)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(context["links"]).To(Equal(map[string]interface{}{
+				"database": map[string]interface{}{
+					"address": "10.0.0.5",
+					"instances": []interface{}{
+						map[string]interface{}{
+							"name":      "db",
+							"index":     0.0,
+							"id":        "db-0",
+							"address":   "10.0.0.5",
+							"bootstrap": true,
+						},
+					},
+					"properties": map[string]interface{}{"port": 5432.0},
+				},
+			}))
+		})
+	})
+})
@@ -0,0 +1,248 @@
+package templatescompiler
+
+import (
+	"fmt"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bperb "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/erbrenderer"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
+)
+
+// LinksResolver connects links consumed by job templates
+// with links provided by job templates in the same deployment.
+type LinksResolver struct{}
+
+type linkProvider struct {
+	alias string
+	def   bpreljob.Link
+
+	job    bpdep.Job
+	relJob bpreljob.Job
+}
+
+func NewLinksResolver() LinksResolver {
+	return LinksResolver{}
+}
+
+// Resolve populates Links of each deployment job template.
+// Release jobs are expected to be in the same order as deployment job templates.
+// Consumed link is found by alias if it specifies 'from';
+// otherwise the only provided link of the same type is used.
+func (r LinksResolver) Resolve(deployment *bpdep.Deployment, relJobs [][]bpreljob.Job) error {
+	c := bpvalid.NewCollector()
+
+	providers := r.findProviders(*deployment, relJobs)
+
+	for i := range deployment.Jobs {
+		job := deployment.Jobs[i]
+
+		for j := range job.Templates {
+			template := &deployment.Jobs[i].Templates[j]
+			template.Links = map[string]bpdep.Link{}
+
+			templateC := c.Key("jobs").Index(i).Key("templates").Index(j)
+
+			for _, consumed := range relJobs[i][j].Consumes {
+				consumedC := templateC.Key("consumes").Key(consumed.Name)
+
+				provider, found, err := r.findProvider(consumed, template.ConsumesFrom[consumed.Name], job, providers)
+				if err != nil {
+					consumedC.Add(err)
+					continue
+				}
+
+				if !found {
+					if !consumed.Optional {
+						consumedC.Add(bosherr.Errorf(
+							"Expected to find provider of link '%s' of type '%s' consumed by job '%s'",
+							consumed.Name, consumed.Type, template.Name))
+					}
+					continue
+				}
+
+				link, err := r.buildLink(*deployment, provider)
+				if err != nil {
+					consumedC.Add(err)
+					continue
+				}
+
+				template.Links[consumed.Name] = link
+			}
+		}
+	}
+
+	return c.Errors()
+}
+
+func (r LinksResolver) findProviders(deployment bpdep.Deployment, relJobs [][]bpreljob.Job) []linkProvider {
+	var providers []linkProvider
+
+	for i, job := range deployment.Jobs {
+		for j, template := range job.Templates {
+			relJob := relJobs[i][j]
+
+			for _, provided := range relJob.Provides {
+				alias := template.ProvidesAs[provided.Name]
+				if alias == "" {
+					alias = provided.Name
+				}
+
+				providers = append(providers, linkProvider{
+					alias: alias,
+					def:   provided,
+
+					job:    job,
+					relJob: relJob,
+				})
+			}
+		}
+	}
+
+	return providers
+}
+
+func (r LinksResolver) findProvider(consumed bpreljob.Link, from string, consumer bpdep.Job, providers []linkProvider) (linkProvider, bool, error) {
+	var matched []linkProvider
+
+	for _, provider := range providers {
+		if from != "" && provider.alias != from {
+			continue
+		}
+
+		if provider.def.Type == consumed.Type {
+			matched = append(matched, provider)
+		}
+	}
+
+	// Prefer providers colocated with the consumer if there are multiple
+	if len(matched) > 1 {
+		var colocated []linkProvider
+
+		for _, provider := range matched {
+			if provider.job.Name == consumer.Name {
+				colocated = append(colocated, provider)
+			}
+		}
+
+		if len(colocated) > 0 {
+			matched = colocated
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		if from != "" {
+			return linkProvider{}, false, bosherr.Errorf(
+				"Expected to find link provided as '%s' of type '%s'", from, consumed.Type)
+		}
+
+		return linkProvider{}, false, nil
+
+	case 1:
+		return matched[0], true, nil
+
+	default:
+		var names []string
+
+		for _, provider := range matched {
+			names = append(names, fmt.Sprintf("%s.%s", provider.job.Name, provider.alias))
+		}
+
+		return linkProvider{}, false, bosherr.Errorf(
+			"Expected to find exactly one provider of type '%s' but found: %s (use 'from' to pick one)",
+			consumed.Type, strings.Join(names, ", "))
+	}
+}
+
+func (r LinksResolver) buildLink(deployment bpdep.Deployment, provider linkProvider) (bpdep.Link, error) {
+	link := bpdep.Link{
+		Name:       provider.alias,
+		Type:       provider.def.Type,
+		Deployment: deployment.Name,
+		Properties: bpdep.Properties{},
+	}
+
+	for _, instance := range provider.job.Instances {
+		link.Instances = append(link.Instances, bpdep.LinkInstance{
+			Name:  provider.job.Name,
+			Index: instance.Index,
+			ID:    fmt.Sprintf("%s-%d", provider.job.Name, instance.Index),
+
+			Address: r.instanceAddress(instance),
+
+			Bootstrap: instance.Index == 0,
+		})
+	}
+
+	if len(provider.job.Instances) == 0 {
+		return link, nil
+	}
+
+	// Properties (including job spec defaults) are taken from the first instance
+	relJob := provider.relJob
+	relJob.DeploymentJobTemplates = provider.job.Templates
+
+	props, err := bperb.NewRenderProperties(relJob, provider.job.Instances[0]).AsMap()
+	if err != nil {
+		return link, bosherr.WrapErrorf(err, "Rendering properties of job '%s'", relJob.Name)
+	}
+
+	for _, name := range provider.def.Properties {
+		value, found := r.lookupProperty(props, name)
+		if found {
+			r.setProperty(link.Properties, name, value)
+		}
+	}
+
+	return link, nil
+}
+
+func (r LinksResolver) instanceAddress(instance bpdep.Instance) string {
+	for _, na := range instance.NetworkAssociations {
+		ip := instance.NetworkConfigurationForNetworkAssociation(na).IP
+		if ip != "" {
+			return ip
+		}
+	}
+
+	return ""
+}
+
+func (r LinksResolver) lookupProperty(props map[string]interface{}, name string) (interface{}, bool) {
+	var current interface{} = props
+
+	for _, part := range strings.Split(name, ".") {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = currentMap[part]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// setProperty sets property at dot separated path creating intermediate sections
+func (r LinksResolver) setProperty(props map[string]interface{}, name string, value interface{}) {
+	parts := strings.Split(name, ".")
+
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := props[part].(map[string]interface{})
+		if !ok {
+			nested = map[string]interface{}{}
+			props[part] = nested
+		}
+
+		props = nested
+	}
+
+	props[parts[len(parts)-1]] = value
+}
@@ -0,0 +1,176 @@
+package templatescompiler_test
+
+import (
+	gonet "net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	. "github.com/cppforlife/bosh-provisioner/instance/templatescompiler"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+)
+
+var _ = Describe("LinksResolver", func() {
+	var (
+		network    *bpdep.Network
+		deployment bpdep.Deployment
+		relJobs    [][]bpreljob.Job
+		resolver   LinksResolver
+	)
+
+	buildInstance := func(index int, ip string) bpdep.Instance {
+		return bpdep.Instance{
+			Index: index,
+			NetworkAssociations: []bpdep.NetworkAssociation{
+				{Network: network, StaticIP: gonet.ParseIP(ip), MustHaveStaticIP: true},
+			},
+		}
+	}
+
+	dbRelJob := bpreljob.Job{
+		Name: "postgres",
+		Properties: []bpreljob.Property{
+			{Name: "port", Default: 5432},
+			{Name: "admin.password"},
+		},
+		Provides: []bpreljob.Link{
+			{Name: "db", Type: "postgres", Properties: []string{"port", "missing"}},
+		},
+	}
+
+	webRelJob := bpreljob.Job{
+		Name: "web",
+		Consumes: []bpreljob.Link{
+			{Name: "database", Type: "postgres"},
+		},
+	}
+
+	BeforeEach(func() {
+		network = &bpdep.Network{Name: "default", Type: bpdep.NetworkTypeDynamic}
+
+		deployment = bpdep.Deployment{
+			Name: "dep",
+			Jobs: []bpdep.Job{
+				{
+					Name:      "db",
+					Templates: []bpdep.Template{{Name: "postgres"}},
+					Instances: []bpdep.Instance{
+						buildInstance(0, "10.0.0.5"),
+						buildInstance(1, "10.0.0.6"),
+					},
+				},
+				{
+					Name:      "web",
+					Templates: []bpdep.Template{{Name: "web"}},
+					Instances: []bpdep.Instance{buildInstance(0, "10.0.0.7")},
+				},
+			},
+		}
+
+		relJobs = [][]bpreljob.Job{{dbRelJob}, {webRelJob}}
+
+		resolver = NewLinksResolver()
+	})
+
+	Describe("Resolve", func() {
+		It("populates consumed links with provider instances and shared properties", func() {
+			err := resolver.Resolve(&deployment, relJobs)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.Jobs[0].Templates[0].Links).To(BeEmpty())
+
+			Expect(deployment.Jobs[1].Templates[0].Links).To(Equal(map[string]bpdep.Link{
+				"database": bpdep.Link{
+					Name:       "db",
+					Type:       "postgres",
+					Deployment: "dep",
+					Instances: []bpdep.LinkInstance{
+						{Name: "db", Index: 0, ID: "db-0", Address: "10.0.0.5", Bootstrap: true},
+						{Name: "db", Index: 1, ID: "db-1", Address: "10.0.0.6"},
+					},
+					Properties: bpdep.Properties{"port": 5432},
+				},
+			}))
+
+			Expect(deployment.Jobs[1].Templates[0].Links["database"].Address()).To(Equal("10.0.0.5"))
+		})
+
+		It("uses provider instance properties for shared properties", func() {
+			deployment.Jobs[0].Instances[0].Properties = bpdep.Properties{"port": "6000"}
+
+			err := resolver.Resolve(&deployment, relJobs)
+			Expect(err).ToNot(HaveOccurred())
+
+			link := deployment.Jobs[1].Templates[0].Links["database"]
+			Expect(link.Properties).To(Equal(bpdep.Properties{"port": "6000"}))
+		})
+
+		Context("when there are multiple providers of the same type", func() {
+			BeforeEach(func() {
+				deployment.Jobs = append(deployment.Jobs, bpdep.Job{
+					Name: "other-db",
+					Templates: []bpdep.Template{{
+						Name:       "postgres",
+						ProvidesAs: map[string]string{"db": "other-db"},
+					}},
+					Instances: []bpdep.Instance{buildInstance(0, "10.0.0.8")},
+				})
+
+				relJobs = append(relJobs, []bpreljob.Job{dbRelJob})
+			})
+
+			It("returns error if consumer does not specify provider", func() {
+				err := resolver.Resolve(&deployment, relJobs)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(
+					"jobs[1].templates[0].consumes.database: Expected to find exactly one provider of type 'postgres' but found: db.db, other-db.other-db"))
+			})
+
+			It("uses provider specified by alias", func() {
+				deployment.Jobs[1].Templates[0].ConsumesFrom = map[string]string{"database": "other-db"}
+
+				err := resolver.Resolve(&deployment, relJobs)
+				Expect(err).ToNot(HaveOccurred())
+
+				link := deployment.Jobs[1].Templates[0].Links["database"]
+				Expect(link.Name).To(Equal("other-db"))
+				Expect(link.Address()).To(Equal("10.0.0.8"))
+			})
+		})
+
+		It("returns error if specified provider is not found", func() {
+			deployment.Jobs[1].Templates[0].ConsumesFrom = map[string]string{"database": "unknown"}
+
+			err := resolver.Resolve(&deployment, relJobs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"jobs[1].templates[0].consumes.database: Expected to find link provided as 'unknown' of type 'postgres'"))
+		})
+
+		Context("when there is no provider", func() {
+			BeforeEach(func() {
+				deployment.Jobs = deployment.Jobs[1:]
+				relJobs = relJobs[1:]
+			})
+
+			It("returns error if link is required", func() {
+				err := resolver.Resolve(&deployment, relJobs)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(
+					"jobs[0].templates[0].consumes.database: Expected to find provider of link 'database' of type 'postgres' consumed by job 'web'"))
+			})
+
+			It("skips link if it is optional", func() {
+				relJobs[0][0].Consumes = []bpreljob.Link{
+					{Name: "database", Type: "postgres", Optional: true},
+				}
+
+				err := resolver.Resolve(&deployment, relJobs)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deployment.Jobs[0].Templates[0].Links).To(BeEmpty())
+			})
+		})
+	})
+})
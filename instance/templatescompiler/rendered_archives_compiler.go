@@ -1,7 +1,9 @@
 package templatescompiler
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -50,11 +52,14 @@ func (rac RenderedArchivesCompiler) Compile(relJobs []bpreljob.Job, instance bpd
 
 		renderer := bperb.NewERBRenderer(rac.fs, rac.runner, context, rac.logger)
 
-		dstPath := filepath.Join(path, relJob.Name, "monit")
+		// Jobs without processes (e.g. only running lifecycle scripts) do not have monit file
+		if relJob.MonitTemplate != nil {
+			dstPath := filepath.Join(path, relJob.Name, "monit")
 
-		err := renderer.Render(relJob.MonitTemplate.Path, dstPath)
-		if err != nil {
-			return "", bosherr.WrapError(err, "Rendering monit ERB")
+			err := renderer.Render(relJob.MonitTemplate.Path, dstPath)
+			if err != nil {
+				return "", bosherr.WrapError(err, "Rendering monit ERB")
+			}
 		}
 
 		for _, template := range relJob.Templates {
@@ -64,6 +69,14 @@ func (rac RenderedArchivesCompiler) Compile(relJobs []bpreljob.Job, instance bpd
 			if err != nil {
 				return "", bosherr.WrapErrorf(err, "Rendering %s ERB", template.DstPathEnd)
 			}
+
+			// Lifecycle scripts (e.g. bin/pre-start, bin/drain) must be executable
+			if strings.HasPrefix(template.DstPathEnd, "bin/") {
+				err := rac.fs.Chmod(dstPath, os.FileMode(0755))
+				if err != nil {
+					return "", bosherr.WrapErrorf(err, "Making %s executable", template.DstPathEnd)
+				}
+			}
 		}
 	}
 
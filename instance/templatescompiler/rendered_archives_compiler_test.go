@@ -0,0 +1,80 @@
+package templatescompiler_test
+
+import (
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	. "github.com/cppforlife/bosh-provisioner/instance/templatescompiler"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+)
+
+// fakeCompressor records modes of rendered files since they are removed after compression
+type fakeCompressor struct {
+	fs    *fakesys.FakeFileSystem
+	modes map[string]os.FileMode
+}
+
+func (c *fakeCompressor) Compress(string) (string, error) {
+	for _, path := range []string{"/rendered/fake-job/bin/pre-start", "/rendered/fake-job/config/app.yml"} {
+		c.modes[path] = c.fs.GetFileTestStat(path).FileMode
+	}
+
+	return "/rendered.tgz", nil
+}
+
+func (c *fakeCompressor) CleanUp(string) error { return nil }
+
+var _ = Describe("RenderedArchivesCompiler", func() {
+	var (
+		fs         *fakesys.FakeFileSystem
+		runner     *fakesys.FakeCmdRunner
+		compressor *fakeCompressor
+		compiler   RenderedArchivesCompiler
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fs.TempDirDir = "/rendered"
+
+		runner = fakesys.NewFakeCmdRunner()
+		compressor = &fakeCompressor{fs: fs, modes: map[string]os.FileMode{}}
+
+		compiler = NewRenderedArchivesCompiler(fs, runner, compressor, boshlog.NewLogger(boshlog.LevelNone))
+
+		// Renderer is a fake hence rendered files are created upfront
+		fs.WriteFileString("/rendered/fake-job/bin/pre-start", "")
+		fs.WriteFileString("/rendered/fake-job/config/app.yml", "")
+	})
+
+	Describe("Compile", func() {
+		It("renders templates of jobs without monit and makes lifecycle scripts executable", func() {
+			relJob := bpreljob.Job{
+				Name: "fake-job",
+				Templates: []bpreljob.Template{
+					{SrcPathEnd: "pre-start.erb", DstPathEnd: "bin/pre-start", Path: "/job/templates/pre-start.erb"},
+					{SrcPathEnd: "app.yml.erb", DstPathEnd: "config/app.yml", Path: "/job/templates/app.yml.erb"},
+				},
+			}
+
+			path, err := compiler.Compile([]bpreljob.Job{relJob}, bpdep.Instance{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/rendered.tgz"))
+
+			var renderedSrcPaths []string
+
+			for _, cmd := range runner.RunComplexCommands {
+				renderedSrcPaths = append(renderedSrcPaths, cmd.Args[2])
+			}
+
+			Expect(renderedSrcPaths).To(Equal([]string{"/job/templates/pre-start.erb", "/job/templates/app.yml.erb"}))
+
+			Expect(compressor.modes["/rendered/fake-job/bin/pre-start"]).To(Equal(os.FileMode(0755)))
+			Expect(compressor.modes["/rendered/fake-job/config/app.yml"]).ToNot(Equal(os.FileMode(0755)))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package templatescompiler
 
 import (
 	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bperb "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/erbrenderer"
 	bprel "github.com/cppforlife/bosh-provisioner/release"
 )
 
@@ -13,6 +14,8 @@ type RenderedArchiveRecord struct {
 type TemplatesCompiler interface {
 	Precompile(bprel.Release) error
 	Compile(bpdep.Job, bpdep.Instance) error
+	ValidateProperties(bpdep.Job, bpdep.Instance) (bperb.PropertiesValidationResult, error)
+	ResolveLinks(*bpdep.Deployment) error
 	FindRenderedArchive(bpdep.Job, bpdep.Instance) (RenderedArchiveRecord, error)
 
 	// todo does it belong here?
@@ -6,6 +6,7 @@ import (
 
 	bpagclient "github.com/cppforlife/bosh-provisioner/agent/client"
 	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bpeventlog "github.com/cppforlife/bosh-provisioner/eventlog"
 	bptplcomp "github.com/cppforlife/bosh-provisioner/instance/templatescompiler"
 	bppkgscomp "github.com/cppforlife/bosh-provisioner/packagescompiler"
 )
@@ -43,18 +44,23 @@ func NewApplier(
 	}
 }
 
-func (a Applier) Apply() error {
+// Apply configures instance to run its job templates.
+// Progress of long running agent tasks is reported to a given task.
+func (a Applier) Apply(task bpeventlog.Task) error {
 	a.logger.Debug(applierLogTag, "Applying empty state")
 
+	agentClient := a.agentClient.WithTaskProgressFunc(
+		bpagclient.NewEventLogTaskProgressFunc(task))
+
 	emptyState := NewEmptyState(a.instance)
 
-	_, err := a.agentClient.Apply(emptyState.AsApplySpec())
+	_, err := agentClient.Apply(emptyState.AsApplySpec())
 	if err != nil {
 		return bosherr.WrapError(err, "Applying empty spec")
 	}
 
 	// Changes local copy of an instance
-	a.instance.CurrentState, err = a.agentClient.GetState()
+	a.instance.CurrentState, err = agentClient.GetState()
 	if err != nil {
 		return bosherr.WrapError(err, "Getting state")
 	}
@@ -82,7 +88,7 @@ func (a Applier) Apply() error {
 		return err
 	}
 
-	_, err = a.agentClient.Apply(jobApplySpec)
+	_, err = agentClient.Apply(jobApplySpec)
 	if err != nil {
 		return bosherr.WrapError(err, "Applying job spec")
 	}
@@ -48,14 +48,18 @@ func (s EmptyState) buildNetworkSpecs() map[string]boshas.NetworkSpec {
 	for _, netAssoc := range s.instance.NetworkAssociations {
 		netConfig := s.instance.NetworkConfigurationForNetworkAssociation(netAssoc)
 
-		specs[netAssoc.Network.Name] = boshas.NetworkSpec{
-			Fields: map[string]interface{}{
-				"type":    netAssoc.Network.Type,
-				"ip":      netConfig.IP,
-				"netmask": netConfig.Netmask,
-				"gateway": netConfig.Gateway,
-			},
+		fields := map[string]interface{}{
+			"type":    netAssoc.Network.Type,
+			"ip":      netConfig.IP,
+			"netmask": netConfig.Netmask,
+			"gateway": netConfig.Gateway,
 		}
+
+		if len(netConfig.DNS) > 0 {
+			fields["dns"] = netConfig.DNS
+		}
+
+		specs[netAssoc.Network.Name] = boshas.NetworkSpec{Fields: fields}
 	}
 
 	return specs
@@ -0,0 +1,39 @@
+package updater
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bpagclient "github.com/cppforlife/bosh-provisioner/agent/client"
+)
+
+const postDeployerLogTag = "PostDeployer"
+
+type PostDeployer struct {
+	agentClient bpagclient.Client
+	logger      boshlog.Logger
+}
+
+func NewPostDeployer(
+	agentClient bpagclient.Client,
+	logger boshlog.Logger,
+) PostDeployer {
+	return PostDeployer{
+		agentClient: agentClient,
+		logger:      logger,
+	}
+}
+
+// PostDeploy runs after all instances in the deployment are set up.
+func (d PostDeployer) PostDeploy() error {
+	d.logger.Debug(postDeployerLogTag, "Running post-deploy")
+
+	err := d.agentClient.PostDeploy()
+	if bpagclient.IsUnsupportedActionError(err) {
+		d.logger.Info(postDeployerLogTag, "Skipping post-deploy since agent does not support it")
+	} else if err != nil {
+		return bosherr.WrapError(err, "Post-Deploying")
+	}
+
+	return nil
+}
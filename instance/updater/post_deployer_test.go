@@ -0,0 +1,58 @@
+package updater_test
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpagclient "github.com/cppforlife/bosh-provisioner/agent/client"
+	fakebpagclient "github.com/cppforlife/bosh-provisioner/agent/client/fakes"
+	. "github.com/cppforlife/bosh-provisioner/instance/updater"
+)
+
+var _ = Describe("PostDeployer", func() {
+	var (
+		agentClient  *fakebpagclient.FakeClient
+		logger       boshlog.Logger
+		postDeployer PostDeployer
+	)
+
+	BeforeEach(func() {
+		agentClient = &fakebpagclient.FakeClient{}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		postDeployer = NewPostDeployer(agentClient, logger)
+	})
+
+	Describe("PostDeploy", func() {
+		Context("when the script exits with a 0 exit status", func() {
+			It("does not return an error", func() {
+				err := postDeployer.PostDeploy()
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when the script exits with a non 0 exit status", func() {
+			BeforeEach(func() {
+				agentClient.PostDeployErr = bosherr.Error("some-error")
+			})
+
+			It("return an error", func() {
+				err := postDeployer.PostDeploy()
+				Expect(err).To(MatchError("Post-Deploying: some-error"))
+			})
+		})
+
+		Context("when agent does not support running scripts", func() {
+			BeforeEach(func() {
+				agentClient.PostDeployErr = bosherr.WrapError(
+					bpagclient.UnsupportedActionError{Method: "run_script"}, "Running script post-deploy")
+			})
+
+			It("skips post-deploy without returning an error", func() {
+				err := postDeployer.PostDeploy()
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+})
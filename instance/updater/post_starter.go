@@ -28,7 +28,10 @@ func NewPostStarter(
 func (w PostStarter) PostStart() error {
 	w.logger.Debug(postStarterLogTag, "Running post-start")
 
-	if err := w.agentClient.PostStart(); err != nil {
+	err := w.agentClient.PostStart()
+	if bpagclient.IsUnsupportedActionError(err) {
+		w.logger.Info(postStarterLogTag, "Skipping post-start since agent does not support it")
+	} else if err != nil {
 		return bosherr.WrapError(err, "Post-Starting")
 	}
 
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	bpagclient "github.com/cppforlife/bosh-provisioner/agent/client"
 	fakebpagclient "github.com/cppforlife/bosh-provisioner/agent/client/fakes"
 	. "github.com/cppforlife/bosh-provisioner/instance/updater"
 )
@@ -41,5 +42,17 @@ var _ = Describe("PostStarter", func() {
 				Expect(err).To(MatchError("Post-Starting: some-error"))
 			})
 		})
+
+		Context("when agent does not support running scripts", func() {
+			BeforeEach(func() {
+				agentClient.PostStartErr = bosherr.WrapError(
+					bpagclient.UnsupportedActionError{Method: "run_script"}, "Running script post-start")
+			})
+
+			It("skips post-start without returning an error", func() {
+				err := postStarter.PostStart()
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
 	})
 })
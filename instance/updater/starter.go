@@ -27,13 +27,16 @@ func NewStarter(
 func (s Starter) Start() error {
 	s.logger.Debug(starterLogTag, "Running pre-start")
 
-	if err := s.agentClient.PreStart(); err != nil {
+	err := s.agentClient.PreStart()
+	if bpagclient.IsUnsupportedActionError(err) {
+		s.logger.Info(starterLogTag, "Skipping pre-start since agent does not support it")
+	} else if err != nil {
 		return bosherr.WrapError(err, "Pre-Starting")
 	}
 
 	s.logger.Debug(starterLogTag, "Starting instance")
 
-	_, err := s.agentClient.Start()
+	_, err = s.agentClient.Start()
 	if err != nil {
 		return bosherr.WrapError(err, "Starting")
 	}
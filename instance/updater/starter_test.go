@@ -0,0 +1,51 @@
+package updater_test
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpagclient "github.com/cppforlife/bosh-provisioner/agent/client"
+	fakebpagclient "github.com/cppforlife/bosh-provisioner/agent/client/fakes"
+	. "github.com/cppforlife/bosh-provisioner/instance/updater"
+)
+
+var _ = Describe("Starter", func() {
+	var (
+		agentClient *fakebpagclient.FakeClient
+		logger      boshlog.Logger
+		starter     Starter
+	)
+
+	BeforeEach(func() {
+		agentClient = &fakebpagclient.FakeClient{}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		starter = NewStarter(agentClient, logger)
+	})
+
+	Describe("Start", func() {
+		Context("when pre-start fails", func() {
+			BeforeEach(func() {
+				agentClient.PreStartErr = bosherr.Error("some-error")
+			})
+
+			It("returns an error without starting instance", func() {
+				err := starter.Start()
+				Expect(err).To(MatchError("Pre-Starting: some-error"))
+			})
+		})
+
+		Context("when agent does not support running scripts", func() {
+			BeforeEach(func() {
+				agentClient.PreStartErr = bosherr.WrapError(
+					bpagclient.UnsupportedActionError{Method: "run_script"}, "Running script pre-start")
+			})
+
+			It("skips pre-start and starts instance", func() {
+				err := starter.Start()
+				Expect(err).To(MatchError("Starting: fake-start-err"))
+			})
+		})
+	})
+})
@@ -15,12 +15,13 @@ const updaterLogTag = "Updater"
 type Updater struct {
 	instanceDesc string
 
-	drainer     Drainer
-	stopper     Stopper
-	applier     bpapplier.Applier
-	starter     Starter
-	waiter      Waiter
-	postStarter PostStarter
+	drainer      Drainer
+	stopper      Stopper
+	applier      bpapplier.Applier
+	starter      Starter
+	waiter       Waiter
+	postStarter  PostStarter
+	postDeployer PostDeployer
 
 	eventLog bpeventlog.Log
 	logger   boshlog.Logger
@@ -34,18 +35,20 @@ func NewUpdater(
 	starter Starter,
 	waiter Waiter,
 	postStarter PostStarter,
+	postDeployer PostDeployer,
 	eventLog bpeventlog.Log,
 	logger boshlog.Logger,
 ) Updater {
 	return Updater{
 		instanceDesc: instanceDesc,
 
-		drainer:     drainer,
-		stopper:     stopper,
-		applier:     applier,
-		starter:     starter,
-		waiter:      waiter,
-		postStarter: postStarter,
+		drainer:      drainer,
+		stopper:      stopper,
+		applier:      applier,
+		starter:      starter,
+		waiter:       waiter,
+		postStarter:  postStarter,
+		postDeployer: postDeployer,
 
 		eventLog: eventLog,
 		logger:   logger,
@@ -57,7 +60,7 @@ func (u Updater) SetUp() error {
 
 	task := stage.BeginTask("Applying")
 
-	err := task.End(u.applier.Apply())
+	err := task.End(u.applier.Apply(task))
 	if err != nil {
 		return bosherr.WrapError(err, "Applying")
 	}
@@ -86,6 +89,20 @@ func (u Updater) SetUp() error {
 	return nil
 }
 
+// PostDeploy runs once all instances are set up.
+func (u Updater) PostDeploy() error {
+	stage := u.eventLog.BeginStage(fmt.Sprintf("Post-Deploying instance %s", u.instanceDesc), 1)
+
+	task := stage.BeginTask("Post-Deploy")
+
+	err := task.End(u.postDeployer.PostDeploy())
+	if err != nil {
+		return bosherr.WrapError(err, "Post-Deploying")
+	}
+
+	return nil
+}
+
 func (u Updater) TearDown() error {
 	stage := u.eventLog.BeginStage(fmt.Sprintf("Tearing down instance %s", u.instanceDesc), 2)
 
@@ -70,6 +70,11 @@ func (f Factory) NewUpdater(
 		f.logger,
 	)
 
+	postDeployer := NewPostDeployer(
+		agentClient,
+		f.logger,
+	)
+
 	updater := NewUpdater(
 		fmt.Sprintf("%s/%d", instance.JobName, instance.Index),
 		drainer,
@@ -78,6 +83,7 @@ func (f Factory) NewUpdater(
 		starter,
 		waiter,
 		postStarter,
+		postDeployer,
 		f.eventLog,
 		f.logger,
 	)
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	bprelcache "github.com/cppforlife/bosh-provisioner/release/cache"
+)
+
+// runClearCache removes all cached releases. Returns process exit status.
+func runClearCache(cache bprelcache.Cache) int {
+	err := cache.Clear()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to clear cache:\n%s\n", err)
+		return 1
+	}
+
+	fmt.Fprintln(os.Stdout, "Cleared release cache")
+
+	return 0
+}
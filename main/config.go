@@ -8,6 +8,7 @@ import (
 
 	bpeventlog "github.com/cppforlife/bosh-provisioner/eventlog"
 	bpprov "github.com/cppforlife/bosh-provisioner/provisioner"
+	bprel "github.com/cppforlife/bosh-provisioner/release"
 	bpvm "github.com/cppforlife/bosh-provisioner/vm"
 )
 
@@ -17,6 +18,10 @@ var (
 			DeviceType: bpeventlog.ConfigDeviceTypeJSON,
 		},
 
+		ReleaseReader: bprel.ReaderConfig{
+			CacheMaxSizeMB: 4096,
+		},
+
 		VMProvisioner: bpvm.ProvisionerConfig{
 			AgentProvisioner: bpvm.AgentProvisionerConfig{
 				Platform: "ubuntu",
@@ -63,6 +68,8 @@ type Config struct {
 
 	Blobstore bpprov.BlobstoreConfig `json:"blobstore"`
 
+	ReleaseReader bprel.ReaderConfig `json:"release_reader"`
+
 	VMProvisioner bpvm.ProvisionerConfig `json:"vm_provisioner"`
 
 	DeploymentProvisioner bpprov.DeploymentProvisionerConfig `json:"deployment_provisioner"`
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	bprel "github.com/cppforlife/bosh-provisioner/release"
+	bptar "github.com/cppforlife/bosh-provisioner/tar"
+)
+
+// runCreateRelease creates next dev release tarball from a release
+// source directory (e.g. 'create-release ~/workspace/room101-release [room101]').
+// Returns process exit status.
+func runCreateRelease(
+	args []string,
+	compressor bptar.Compressor,
+	uuidGen boshuuid.Generator,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) int {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, "Must provide release directory and optionally release name")
+		return 1
+	}
+
+	var name string
+
+	if len(args) == 2 {
+		name = args[1]
+	}
+
+	creator := bprel.NewDevReleaseCreator(args[0], compressor, uuidGen, fs, logger)
+
+	created, err := creator.Create(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create release from %s:\n%s\n", args[0], err)
+		return 1
+	}
+
+	rel := created.Manifest.Release
+
+	fmt.Fprintf(os.Stdout, "Created release %s/%s\n", rel.Name, rel.Version)
+	fmt.Fprintf(os.Stdout, "Manifest: %s\nTarball: %s\n", created.ManifestPath, created.TarballPath)
+
+	return 0
+}
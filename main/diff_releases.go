@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bpprov "github.com/cppforlife/bosh-provisioner/provisioner"
+	bprel "github.com/cppforlife/bosh-provisioner/release"
+	bprelinsp "github.com/cppforlife/bosh-provisioner/release/inspector"
+)
+
+// runDiffReleases prints added, removed and changed jobs and packages between
+// two releases, e.g. 'diff-releases https://example.com/r-1.tgz https://example.com/r-2.tgz',
+// and deployment jobs in the current manifest that use changed release jobs.
+// Returns process exit status.
+func runDiffReleases(
+	args []string,
+	inspector bprelinsp.Inspector,
+	deploymentReaderFactory bpdep.ReaderFactory,
+	config bpprov.DeploymentProvisionerConfig,
+) int {
+	flags := flag.NewFlagSet("diff-releases", flag.ContinueOnError)
+
+	jsonOpt := flags.Bool("json", false, "Print differences as JSON")
+	nameOpt := flags.String("name", "", "Release name (required for dir:// and dir+git:// releases)")
+	fromVersionOpt := flags.String("from-version", bprel.LatestVersion, "First release version (for dir:// releases)")
+	toVersionOpt := flags.String("to-version", bprel.LatestVersion, "Second release version (for dir:// releases)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 1
+	}
+
+	if flags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Must provide two release URLs to compare")
+		return 1
+	}
+
+	fromURL, toURL := flags.Arg(0), flags.Arg(1)
+
+	fromRelease, err := inspector.Inspect(*nameOpt, *fromVersionOpt, fromURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to inspect release %s:\n%s\n", fromURL, err)
+		return 1
+	}
+
+	toRelease, err := inspector.Inspect(*nameOpt, *toVersionOpt, toURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to inspect release %s:\n%s\n", toURL, err)
+		return 1
+	}
+
+	diff := bprelinsp.NewReleaseDiff(fromRelease, toRelease)
+
+	var affectedJobs []bprelinsp.AffectedJob
+
+	// Affected jobs are only shown when deployment manifest is configured
+	if config.ManifestPath != "" {
+		reader := deploymentReaderFactory.NewManifestReader(config.ManifestPath, config.OpsFiles, config.RuntimeConfigPath)
+
+		deployment, err := reader.Read()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Manifest %s is invalid:\n%s\n", config.ManifestPath, err)
+			return 1
+		}
+
+		affectedJobs = diff.AffectedJobs(deployment.Jobs)
+	}
+
+	if *jsonOpt {
+		result := struct {
+			bprelinsp.ReleaseDiff
+			AffectedJobs []bprelinsp.AffectedJob `json:"affected_jobs"`
+		}{diff, affectedJobs}
+
+		bytes, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to marshal release differences:\n%s\n", err)
+			return 1
+		}
+
+		fmt.Fprintln(os.Stdout, string(bytes))
+
+		return 0
+	}
+
+	fmt.Fprintln(os.Stdout, diff)
+
+	if config.ManifestPath != "" {
+		fmt.Fprintln(os.Stdout, "")
+		fmt.Fprintln(os.Stdout, "Affected deployment jobs (re-rendered and restarted):")
+
+		if len(affectedJobs) == 0 {
+			fmt.Fprintln(os.Stdout, "  none")
+		}
+
+		for _, job := range affectedJobs {
+			fmt.Fprintf(os.Stdout, "  %s (%s)\n", job.Name, strings.Join(job.Templates, ", "))
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"strings"
+)
+
+// stringsFlag collects values of a flag that can be specified multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
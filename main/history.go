@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bpdepdiff "github.com/cppforlife/bosh-provisioner/deployment/diff"
+	bpprov "github.com/cppforlife/bosh-provisioner/provisioner"
+	bphistrepo "github.com/cppforlife/bosh-provisioner/provisioner/historyrepo"
+	bprel "github.com/cppforlife/bosh-provisioner/release"
+)
+
+// runHistory prints all successful provisions. Returns process exit status.
+func runHistory(historyRepo bphistrepo.HistoryRepository) int {
+	entries, err := historyRepo.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list history:\n%s\n", err)
+		return 1
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stdout, "No history entries")
+		return 0
+	}
+
+	for _, entry := range entries {
+		var releases []string
+
+		for _, release := range entry.Releases {
+			releases = append(releases, release.Name+"/"+release.Version)
+		}
+
+		fmt.Fprintf(os.Stdout, "%d\t%s\t%s/%s\t%s\n",
+			entry.ID, entry.FinishedAt.Format(time.RFC3339),
+			entry.DeploymentName, entry.JobName, strings.Join(releases, ", "))
+	}
+
+	return 0
+}
+
+// runDiff prints changes between two history entries
+// (e.g. 'diff 3 5') or between the last history entry and
+// the current deployment manifest if entries are not specified.
+// Returns process exit status.
+func runDiff(
+	args []string,
+	deploymentReaderFactory bpdep.ReaderFactory,
+	config bpprov.DeploymentProvisionerConfig,
+	historyRepo bphistrepo.HistoryRepository,
+) int {
+	switch len(args) {
+	case 0:
+		return runDiffWithCurrent(deploymentReaderFactory, config, historyRepo)
+	case 2:
+		return runDiffEntries(args[0], args[1], historyRepo)
+	default:
+		fmt.Fprintln(os.Stderr, "Must provide either two history entry IDs or none to compare with the current manifest")
+		return 1
+	}
+}
+
+func runDiffWithCurrent(
+	deploymentReaderFactory bpdep.ReaderFactory,
+	config bpprov.DeploymentProvisionerConfig,
+	historyRepo bphistrepo.HistoryRepository,
+) int {
+	if config.ManifestPath == "" {
+		fmt.Fprintln(os.Stderr, "Must provide deployment_provisioner.manifest_path to compare with")
+		return 1
+	}
+
+	entries, err := historyRepo.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list history:\n%s\n", err)
+		return 1
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No history entries to compare with")
+		return 1
+	}
+
+	reader := deploymentReaderFactory.NewManifestReader(config.ManifestPath, config.OpsFiles, config.RuntimeConfigPath)
+
+	deployment, err := reader.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Manifest %s is invalid:\n%s\n", config.ManifestPath, err)
+		return 1
+	}
+
+	lastEntry := entries[len(entries)-1]
+
+	resolveLatestVersions(&deployment, lastEntry)
+
+	// History entries record source manifests with resolved release versions
+	manifestBytes, err := deployment.ResolvedSourceManifest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build manifest %s:\n%s\n", config.ManifestPath, err)
+		return 1
+	}
+
+	return printManifestChanges(lastEntry, bphistrepo.Entry{
+		Manifest:      manifestBytes,
+		RuntimeConfig: deployment.SourceRuntimeConfig,
+	})
+}
+
+// resolveLatestVersions uses versions recorded in the history entry for 'latest'
+// releases since actual versions are only known once releases are read.
+func resolveLatestVersions(deployment *bpdep.Deployment, entry bphistrepo.Entry) {
+	for i, release := range deployment.Releases {
+		if release.Version != bprel.LatestVersion {
+			continue
+		}
+
+		for _, recordedRelease := range entry.Releases {
+			if recordedRelease.Name == release.Name {
+				deployment.Releases[i].Version = recordedRelease.Version
+			}
+		}
+	}
+}
+
+func runDiffEntries(fromID, toID string, historyRepo bphistrepo.HistoryRepository) int {
+	fromEntry, err := findHistoryEntry(fromID, historyRepo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	toEntry, err := findHistoryEntry(toID, historyRepo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	status := printManifestChanges(fromEntry, toEntry)
+	if status != 0 {
+		return status
+	}
+
+	printChanges("Releases and rendered templates", bpdepdiff.Diff(historyEntryState(fromEntry), historyEntryState(toEntry)))
+
+	return 0
+}
+
+// printManifestChanges prints changes between manifests and runtime configs
+// of two entries. Returns process exit status.
+func printManifestChanges(fromEntry, toEntry bphistrepo.Entry) int {
+	changes, err := bpdepdiff.DiffYAML(fromEntry.Manifest, toEntry.Manifest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to compare manifests:\n%s\n", err)
+		return 1
+	}
+
+	printChanges("Manifest", changes)
+
+	if len(fromEntry.RuntimeConfig) == 0 && len(toEntry.RuntimeConfig) == 0 {
+		return 0
+	}
+
+	changes, err = bpdepdiff.DiffYAML(fromEntry.RuntimeConfig, toEntry.RuntimeConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to compare runtime configs:\n%s\n", err)
+		return 1
+	}
+
+	printChanges("Runtime config", changes)
+
+	return 0
+}
+
+func findHistoryEntry(idStr string, historyRepo bphistrepo.HistoryRepository) (bphistrepo.Entry, error) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return bphistrepo.Entry{}, bosherr.Errorf("Expected history entry ID '%s' to be a number", idStr)
+	}
+
+	entry, found, err := historyRepo.Find(id)
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Finding history entry %d", id)
+	} else if !found {
+		return entry, bosherr.Errorf("History entry %d does not exist", id)
+	}
+
+	return entry, nil
+}
+
+// historyEntryState returns resolved release information in a form
+// that can be compared item by item (list items are matched by name)
+func historyEntryState(entry bphistrepo.Entry) map[string]interface{} {
+	fingerprints := func(records []bphistrepo.FingerprintRecord) []interface{} {
+		result := []interface{}{}
+
+		for _, record := range records {
+			result = append(result, map[string]interface{}{
+				"name":        record.Name,
+				"version":     record.Version,
+				"fingerprint": record.Fingerprint,
+				"sha1":        record.SHA1,
+			})
+		}
+
+		return result
+	}
+
+	releases := []interface{}{}
+
+	for _, release := range entry.Releases {
+		releases = append(releases, map[string]interface{}{
+			"name":        release.Name,
+			"version":     release.Version,
+			"url":         release.URL,
+			"commit_hash": release.CommitHash,
+			"jobs":        fingerprints(release.Jobs),
+			"packages":    fingerprints(release.Packages),
+		})
+	}
+
+	archives := []interface{}{}
+
+	for _, archive := range entry.RenderedArchives {
+		archives = append(archives, map[string]interface{}{
+			"name": archive.JobName,
+			"sha1": archive.SHA1,
+		})
+	}
+
+	return map[string]interface{}{
+		"releases":          releases,
+		"rendered_archives": archives,
+	}
+}
+
+func printChanges(title string, changes bpdepdiff.Changes) {
+	if len(changes) == 0 {
+		fmt.Fprintf(os.Stdout, "%s: no changes\n", title)
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "%s:\n%s\n", title, changes)
+}
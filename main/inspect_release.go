@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	bprel "github.com/cppforlife/bosh-provisioner/release"
+	bprelinsp "github.com/cppforlife/bosh-provisioner/release/inspector"
+)
+
+// runInspectRelease prints jobs (templates, packages and properties) and packages
+// (dependencies, fingerprints and SHA1s) of a release located at URL,
+// e.g. 'inspect-release -json https://example.com/release.tgz'.
+// Returns process exit status.
+func runInspectRelease(args []string, inspector bprelinsp.Inspector) int {
+	flags := flag.NewFlagSet("inspect-release", flag.ContinueOnError)
+
+	jsonOpt := flags.Bool("json", false, "Print release as JSON")
+	nameOpt := flags.String("name", "", "Release name (required for dir:// and dir+git:// releases)")
+	versionOpt := flags.String("version", bprel.LatestVersion, "Release version (for dir:// and dir+git:// releases)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 1
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Must provide release URL to inspect")
+		return 1
+	}
+
+	url := flags.Arg(0)
+
+	release, err := inspector.Inspect(*nameOpt, *versionOpt, url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to inspect release %s:\n%s\n", url, err)
+		return 1
+	}
+
+	if !*jsonOpt {
+		fmt.Fprintln(os.Stdout, release)
+		return 0
+	}
+
+	bytes, err := json.MarshalIndent(release, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to marshal release %s:\n%s\n", url, err)
+		return 1
+	}
+
+	fmt.Fprintln(os.Stdout, string(bytes))
+
+	return 0
+}
@@ -9,8 +9,10 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
 
 	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bpdepvars "github.com/cppforlife/bosh-provisioner/deployment/variables"
 	bpdload "github.com/cppforlife/bosh-provisioner/downloader"
 	bpeventlog "github.com/cppforlife/bosh-provisioner/eventlog"
 	bpinstance "github.com/cppforlife/bosh-provisioner/instance"
@@ -19,8 +21,10 @@ import (
 	bppkgscomp "github.com/cppforlife/bosh-provisioner/packagescompiler"
 	bpprov "github.com/cppforlife/bosh-provisioner/provisioner"
 	bprel "github.com/cppforlife/bosh-provisioner/release"
+	bprelinsp "github.com/cppforlife/bosh-provisioner/release/inspector"
 	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
 	bptar "github.com/cppforlife/bosh-provisioner/tar"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
 	bpvagrantvm "github.com/cppforlife/bosh-provisioner/vm/vagrant"
 )
 
@@ -28,8 +32,16 @@ const mainLogTag = "main"
 
 var (
 	configPathOpt = flag.String("configPath", "", "Path to configuration file")
+
+	varsFilesOpt stringsFlag
+	varsOpt      stringsFlag
 )
 
+func init() {
+	flag.Var(&varsFilesOpt, "vars-file", "Path to YAML file with variable values (can be repeated)")
+	flag.Var(&varsOpt, "var", "Variable value in 'name=value' format (can be repeated)")
+}
+
 func main() {
 	logger, fs, runner, uuidGen := basicDeps()
 
@@ -55,9 +67,10 @@ func main() {
 
 	downloader := bpdload.NewDefaultMuxDownloader(fs, runner, blobstore, logger)
 
-	extractor := bptar.NewCmdExtractor(runner, fs, logger)
+	extractor := bptar.NewArchiveExtractor(fs, logger)
 
-	compressor := bptar.NewCmdCompressor(runner, fs, logger)
+	// Deterministic archives keep rendered templates archive SHA1s stable across runs
+	compressor := bptar.NewArchiveCompressor(true, fs, logger)
 
 	renderedArchivesCompiler := bptplcomp.NewRenderedArchivesCompiler(
 		fs,
@@ -75,6 +88,63 @@ func main() {
 
 	reposFactory := NewReposFactory(config.ReposDir, fs, downloader, blobstore, logger)
 
+	releaseCache := reposFactory.NewReleaseCache(config.ReleaseReader.CacheMaxSizeMB * 1024 * 1024)
+
+	releaseReaderFactory := bprel.NewReaderFactory(
+		config.ReleaseReader,
+		releaseCache,
+		downloader,
+		extractor,
+		compressor,
+		fs,
+		logger,
+	)
+
+	command := flag.Arg(0)
+
+	credsStore := reposFactory.NewCredentialsStore()
+
+	// Only provisioning records generated credentials;
+	// other commands (e.g. validate) must not change provisioner state
+	if command != "" && command != "provision" {
+		credsStore = bpdepvars.NewReadOnlyCredentialsStore(credsStore)
+	}
+
+	interpolator := bpdepvars.NewInterpolator(
+		mustLoadStaticVariables(fs, eventLog),
+		credsStore,
+		bpdepvars.NewGenerator(),
+		logger,
+	)
+
+	deploymentReaderFactory := bpdep.NewReaderFactory(interpolator, fs, logger)
+
+	switch command {
+	case "", "provision":
+		// Continue provisioning below
+	case "validate":
+		os.Exit(runValidate(deploymentReaderFactory, config.DeploymentProvisioner))
+	case "show-manifest", "interpolate":
+		os.Exit(runShowManifest(deploymentReaderFactory, config.DeploymentProvisioner))
+	case "history":
+		os.Exit(runHistory(reposFactory.NewHistoryRepo()))
+	case "diff":
+		os.Exit(runDiff(flag.Args()[1:], deploymentReaderFactory, config.DeploymentProvisioner, reposFactory.NewHistoryRepo()))
+	case "inspect-release":
+		inspector := bprelinsp.NewInspector(releaseReaderFactory, jobReaderFactory, logger)
+		os.Exit(runInspectRelease(flag.Args()[1:], inspector))
+	case "diff-releases":
+		inspector := bprelinsp.NewInspector(releaseReaderFactory, jobReaderFactory, logger)
+		os.Exit(runDiffReleases(flag.Args()[1:], inspector, deploymentReaderFactory, config.DeploymentProvisioner))
+	case "clear-cache":
+		os.Exit(runClearCache(releaseCache))
+	case "create-release":
+		os.Exit(runCreateRelease(flag.Args()[1:], compressor, uuidGen, fs, logger))
+	default:
+		eventLog.WriteErr(bosherr.Errorf("Unknown command '%s'", command))
+		os.Exit(1)
+	}
+
 	blobstoreProvisioner := bpprov.NewBlobstoreProvisioner(
 		fs,
 		config.Blobstore,
@@ -113,15 +183,6 @@ func main() {
 		logger,
 	)
 
-	releaseReaderFactory := bprel.NewReaderFactory(
-		downloader,
-		extractor,
-		fs,
-		logger,
-	)
-
-	deploymentReaderFactory := bpdep.NewReaderFactory(fs, logger)
-
 	vagrantVMProvisionerFactory := bpvagrantvm.NewVMProvisionerFactory(
 		fs,
 		runner,
@@ -139,6 +200,7 @@ func main() {
 		packagesCompilerFactory,
 		templatesCompiler,
 		vagrantVMProvisioner,
+		config.DeploymentProvisioner,
 		eventLog,
 		logger,
 	)
@@ -154,6 +216,9 @@ func main() {
 		vagrantVMProvisioner,
 		releaseCompiler,
 		instanceProvisioner,
+		reposFactory.NewAppliedStateRepo(),
+		reposFactory.NewHistoryRepo(),
+		clock.NewClock(),
 		eventLog,
 		logger,
 	)
@@ -191,6 +256,34 @@ func mustLoadConfig(fs boshsys.FileSystem, logger boshlog.Logger) Config {
 	return config
 }
 
+// mustLoadStaticVariables merges variables from vars files and individual vars;
+// later values take precedence over earlier ones.
+func mustLoadStaticVariables(fs boshsys.FileSystem, eventLog bpeventlog.Log) bpdepvars.StaticVariables {
+	vars := bpdepvars.StaticVariables{}
+
+	for _, path := range varsFilesOpt {
+		fileVars, err := bpdepvars.NewStaticVariablesFromFile(path, fs)
+		if err != nil {
+			eventLog.WriteErr(bosherr.WrapError(err, "Loading vars file"))
+			os.Exit(1)
+		}
+
+		vars = vars.Merge(fileVars)
+	}
+
+	for _, kv := range varsOpt {
+		kvVars, err := bpdepvars.NewStaticVariablesFromKV(kv)
+		if err != nil {
+			eventLog.WriteErr(bosherr.WrapError(err, "Loading var"))
+			os.Exit(1)
+		}
+
+		vars = vars.Merge(kvVars)
+	}
+
+	return vars
+}
+
 func mustSetTmpDir(config Config, fs boshsys.FileSystem, eventLog bpeventlog.Log) {
 	// todo leaky abstraction?
 	if len(config.TmpDir) == 0 {
@@ -210,10 +303,18 @@ func mustSetTmpDir(config Config, fs boshsys.FileSystem, eventLog bpeventlog.Log
 	}
 }
 
+// mustCreateReposDir makes repos dir only accessible by the owner
+// since it keeps credentials and provision history
 func mustCreateReposDir(config Config, fs boshsys.FileSystem, eventLog bpeventlog.Log) {
-	err := fs.MkdirAll(config.ReposDir, os.ModeDir)
+	err := fs.MkdirAll(config.ReposDir, bputil.PrivateDirMode)
 	if err != nil {
 		eventLog.WriteErr(bosherr.WrapError(err, "Creating repos dir"))
 		os.Exit(1)
 	}
+
+	err = fs.Chmod(config.ReposDir, bputil.PrivateDirMode)
+	if err != nil {
+		eventLog.WriteErr(bosherr.WrapError(err, "Changing repos dir mode"))
+		os.Exit(1)
+	}
 }
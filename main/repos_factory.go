@@ -6,13 +6,18 @@ import (
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
 
+	bpdepvars "github.com/cppforlife/bosh-provisioner/deployment/variables"
 	bpdload "github.com/cppforlife/bosh-provisioner/downloader"
 	bpindex "github.com/cppforlife/bosh-provisioner/index"
 	bpjobsrepo "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/jobsrepo"
 	bptplsrepo "github.com/cppforlife/bosh-provisioner/instance/templatescompiler/templatesrepo"
 	bpcpkgsrepo "github.com/cppforlife/bosh-provisioner/packagescompiler/compiledpackagesrepo"
 	bppkgsrepo "github.com/cppforlife/bosh-provisioner/packagescompiler/packagesrepo"
+	bpappstaterepo "github.com/cppforlife/bosh-provisioner/provisioner/appliedstaterepo"
+	bphistrepo "github.com/cppforlife/bosh-provisioner/provisioner/historyrepo"
+	bprelcache "github.com/cppforlife/bosh-provisioner/release/cache"
 )
 
 type ReposFactory struct {
@@ -39,6 +44,17 @@ func NewReposFactory(
 	}
 }
 
+// NewReleaseCache returns cache of extracted releases limited to max size in bytes
+func (f ReposFactory) NewReleaseCache(maxSize int64) bprelcache.Cache {
+	return bprelcache.NewDirCache(
+		filepath.Join(f.dirPath, "cache"),
+		maxSize,
+		f.fs,
+		clock.NewClock(),
+		f.logger,
+	)
+}
+
 func (f ReposFactory) NewJobsRepo() bpjobsrepo.JobsRepository {
 	return bpjobsrepo.NewConcreteJobsRepository(
 		f.newIndex("jobs"),
@@ -81,6 +97,28 @@ func (f ReposFactory) NewCompiledPackagesRepo() bpcpkgsrepo.CompiledPackagesRepo
 	)
 }
 
+func (f ReposFactory) NewCredentialsStore() bpdepvars.CredentialsStore {
+	return bpdepvars.NewConcreteCredentialsStore(
+		bpindex.NewPrivateFileIndex(filepath.Join(f.dirPath, "credentials.json"), f.fs),
+		f.logger,
+	)
+}
+
+func (f ReposFactory) NewAppliedStateRepo() bpappstaterepo.AppliedStateRepository {
+	return bpappstaterepo.NewConcreteAppliedStateRepository(
+		f.newIndex("applied_state"),
+		f.logger,
+	)
+}
+
+func (f ReposFactory) NewHistoryRepo() bphistrepo.HistoryRepository {
+	return bphistrepo.NewFileHistoryRepository(
+		filepath.Join(f.dirPath, "history"),
+		f.fs,
+		f.logger,
+	)
+}
+
 func (f ReposFactory) newIndex(name string) bpindex.Index {
 	return bpindex.NewFileIndex(filepath.Join(f.dirPath, name+".json"), f.fs)
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bpprov "github.com/cppforlife/bosh-provisioner/provisioner"
+)
+
+// runShowManifest reads deployment manifest the same way provisioning does
+// and prints it with ops files, variables and runtime config applied.
+// Returns process exit status.
+func runShowManifest(
+	deploymentReaderFactory bpdep.ReaderFactory,
+	config bpprov.DeploymentProvisionerConfig,
+) int {
+	if config.ManifestPath == "" {
+		fmt.Fprintln(os.Stderr, "Must provide deployment_provisioner.manifest_path to show manifest")
+		return 1
+	}
+
+	reader := deploymentReaderFactory.NewManifestReader(config.ManifestPath, config.OpsFiles, config.RuntimeConfigPath)
+
+	deployment, err := reader.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Manifest %s is invalid:\n%s\n", config.ManifestPath, err)
+		return 1
+	}
+
+	bytes, err := deployment.Manifest.Bytes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to show manifest %s:\n%s\n", config.ManifestPath, err)
+		return 1
+	}
+
+	fmt.Fprint(os.Stdout, string(bytes))
+
+	return 0
+}
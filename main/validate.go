@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	bpprov "github.com/cppforlife/bosh-provisioner/provisioner"
+)
+
+// runValidate reads deployment manifest the same way provisioning does
+// (ops files are applied and variables are interpolated) and
+// prints all found problems. Returns process exit status.
+func runValidate(
+	deploymentReaderFactory bpdep.ReaderFactory,
+	config bpprov.DeploymentProvisionerConfig,
+) int {
+	if config.ManifestPath == "" {
+		fmt.Fprintln(os.Stderr, "Must provide deployment_provisioner.manifest_path to validate")
+		return 1
+	}
+
+	reader := deploymentReaderFactory.NewManifestReader(config.ManifestPath, config.OpsFiles, config.RuntimeConfigPath)
+
+	_, err := reader.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "Manifest %s is invalid:\n%s\n", config.ManifestPath, err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "Manifest %s is valid\n", config.ManifestPath)
+
+	return 0
+}
@@ -50,7 +50,10 @@ func NewConcretePackagesCompiler(
 // All packages are compiled regardless if they will be later used or not.
 // Currently Compile does not account for stemcell differences.
 func (pc ConcretePackagesCompiler) Compile(release bprel.Release) error {
-	packages := release.ResolvedPackageDependencies()
+	packages, err := release.ResolvedPackageDependencies()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resolving release %s/%s packages", release.Name, release.Version)
+	}
 
 	releaseDesc := fmt.Sprintf("Compiling release %s/%s", release.Name, release.Version)
 
@@ -69,7 +72,7 @@ func (pc ConcretePackagesCompiler) Compile(release bprel.Release) error {
 			continue
 		}
 
-		err = task.End(pc.compilePkg(*pkg))
+		err = task.End(pc.compilePkg(*pkg, task))
 		if err != nil {
 			return err
 		}
@@ -99,7 +102,8 @@ func (pc ConcretePackagesCompiler) FindCompiledPackage(pkg bprel.Package) (Compi
 // compilePackage populates blobstore with a compiled package for a
 // given package. Assumes that dependencies of given package have
 // already been compiled and are in the blobstore.
-func (pc ConcretePackagesCompiler) compilePkg(pkg bprel.Package) error {
+// Compilation progress is reported to a given task.
+func (pc ConcretePackagesCompiler) compilePkg(pkg bprel.Package, task bpeventlog.Task) error {
 	pc.logger.Debug(concretePackagesCompilerLogTag,
 		"Preparing to compile package %v", pkg)
 
@@ -130,7 +134,10 @@ func (pc ConcretePackagesCompiler) compilePkg(pkg bprel.Package) error {
 		return err
 	}
 
-	compiledPkgRes, err := pc.agentClient.CompilePackage(
+	agentClient := pc.agentClient.WithTaskProgressFunc(
+		bpagentclient.NewEventLogTaskProgressFunc(task))
+
+	compiledPkgRes, err := agentClient.CompilePackage(
 		pkgRec.BlobID, // source tar
 		pkgRec.SHA1,   // source tar
 		pkg.Name,
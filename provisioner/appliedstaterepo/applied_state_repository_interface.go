@@ -0,0 +1,22 @@
+package appliedstaterepo
+
+type AppliedStateRecord struct {
+	DeploymentName string
+	JobName        string
+
+	// Release versions are recorded after being resolved (e.g. latest -> 0+dev.3)
+	Releases []AppliedReleaseRecord
+}
+
+type AppliedReleaseRecord struct {
+	Name    string
+	Version string
+	URL     string
+}
+
+// AppliedStateRepository keeps information about the last successfully
+// provisioned deployment since there is only one VM.
+type AppliedStateRepository interface {
+	Find() (AppliedStateRecord, bool, error)
+	Save(AppliedStateRecord) error
+}
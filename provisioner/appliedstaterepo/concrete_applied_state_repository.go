@@ -0,0 +1,47 @@
+package appliedstaterepo
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bpindex "github.com/cppforlife/bosh-provisioner/index"
+)
+
+type CASRepository struct {
+	index  bpindex.Index
+	logger boshlog.Logger
+}
+
+// Only a single record is kept
+type lastAppliedKey struct{}
+
+func NewConcreteAppliedStateRepository(
+	index bpindex.Index,
+	logger boshlog.Logger,
+) CASRepository {
+	return CASRepository{index: index, logger: logger}
+}
+
+func (r CASRepository) Find() (AppliedStateRecord, bool, error) {
+	var record AppliedStateRecord
+
+	err := r.index.Find(lastAppliedKey{}, &record)
+	if err != nil {
+		if err == bpindex.ErrNotFound {
+			return record, false, nil
+		}
+
+		return record, false, bosherr.WrapError(err, "Finding applied state")
+	}
+
+	return record, true, nil
+}
+
+func (r CASRepository) Save(record AppliedStateRecord) error {
+	err := r.index.Save(lastAppliedKey{}, record)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving applied state")
+	}
+
+	return nil
+}
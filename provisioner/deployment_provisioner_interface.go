@@ -7,4 +7,14 @@ type DeploymentProvisioner interface {
 type DeploymentProvisionerConfig struct {
 	// If manifest path is empty, release compilation and job provisioning will be skipped
 	ManifestPath string `json:"manifest_path"`
+
+	// Ops files are applied to the manifest in order before it's interpreted
+	OpsFiles []string `json:"ops_files"`
+
+	// Runtime config addons are colocated with deployment jobs (optional)
+	RuntimeConfigPath string `json:"runtime_config_path"`
+
+	// Properties without defaults that are not set fail provisioning
+	// instead of being reported as warnings (templates may check them with if_p)
+	StrictProperties bool `json:"strict_properties"`
 }
@@ -0,0 +1,176 @@
+package historyrepo
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+const fileHistoryRepositoryLogTag = "FileHistoryRepository"
+
+// FileHistoryRepository keeps each entry in a separate directory
+// (<dir>/<id>/entry.json, <dir>/<id>/manifest.yml and optionally <dir>/<id>/runtime-config.yml)
+// so that saved manifests can be easily inspected. Entries include applied
+// properties hence they are only accessible by the owner.
+type FileHistoryRepository struct {
+	dirPath string
+
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+func NewFileHistoryRepository(
+	dirPath string,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) FileHistoryRepository {
+	return FileHistoryRepository{
+		dirPath: dirPath,
+
+		fs:     fs,
+		logger: logger,
+	}
+}
+
+func (r FileHistoryRepository) Save(entry Entry) (Entry, error) {
+	entries, err := r.List()
+	if err != nil {
+		return entry, err
+	}
+
+	entry.ID = 1
+
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+
+	entryDir := r.entryDir(entry.ID)
+
+	r.logger.Debug(fileHistoryRepositoryLogTag, "Saving history entry %s", entryDir)
+
+	err = r.fs.MkdirAll(entryDir, bputil.PrivateDirMode)
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Creating history entry dir %s", entryDir)
+	}
+
+	// Directory might have been created by previous versions with default mode
+	err = r.fs.Chmod(r.dirPath, bputil.PrivateDirMode)
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Changing mode of history dir %s", r.dirPath)
+	}
+
+	bytes, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return entry, bosherr.WrapError(err, "Marshalling history entry")
+	}
+
+	err = bputil.WritePrivateFile(r.fs, filepath.Join(entryDir, "manifest.yml"), entry.Manifest)
+	if err != nil {
+		return entry, bosherr.WrapError(err, "Writing history entry manifest")
+	}
+
+	if len(entry.RuntimeConfig) > 0 {
+		err = bputil.WritePrivateFile(r.fs, filepath.Join(entryDir, "runtime-config.yml"), entry.RuntimeConfig)
+		if err != nil {
+			return entry, bosherr.WrapError(err, "Writing history entry runtime config")
+		}
+	}
+
+	// Entry is written last since its presence indicates a complete entry
+	err = bputil.WritePrivateFile(r.fs, filepath.Join(entryDir, "entry.json"), bytes)
+	if err != nil {
+		return entry, bosherr.WrapError(err, "Writing history entry")
+	}
+
+	return entry, nil
+}
+
+func (r FileHistoryRepository) List() ([]Entry, error) {
+	var entries []Entry
+
+	paths, err := r.fs.Glob(filepath.Join(r.dirPath, "*", "entry.json"))
+	if err != nil {
+		return entries, bosherr.WrapError(err, "Listing history entries")
+	}
+
+	for _, path := range paths {
+		id, err := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+		if err != nil {
+			r.logger.Debug(fileHistoryRepositoryLogTag, "Skipping unknown history entry %s", path)
+			continue
+		}
+
+		entry, err := r.read(id)
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Sort(entriesByID(entries))
+
+	return entries, nil
+}
+
+func (r FileHistoryRepository) Find(id int) (Entry, bool, error) {
+	if !r.fs.FileExists(filepath.Join(r.entryDir(id), "entry.json")) {
+		return Entry{}, false, nil
+	}
+
+	entry, err := r.read(id)
+	if err != nil {
+		return entry, false, err
+	}
+
+	return entry, true, nil
+}
+
+func (r FileHistoryRepository) read(id int) (Entry, error) {
+	var entry Entry
+
+	entryDir := r.entryDir(id)
+
+	bytes, err := r.fs.ReadFile(filepath.Join(entryDir, "entry.json"))
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Reading history entry %d", id)
+	}
+
+	err = json.Unmarshal(bytes, &entry)
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Unmarshalling history entry %d", id)
+	}
+
+	entry.Manifest, err = r.fs.ReadFile(filepath.Join(entryDir, "manifest.yml"))
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Reading history entry %d manifest", id)
+	}
+
+	runtimeConfigPath := filepath.Join(entryDir, "runtime-config.yml")
+
+	if r.fs.FileExists(runtimeConfigPath) {
+		entry.RuntimeConfig, err = r.fs.ReadFile(runtimeConfigPath)
+		if err != nil {
+			return entry, bosherr.WrapErrorf(err, "Reading history entry %d runtime config", id)
+		}
+	}
+
+	return entry, nil
+}
+
+func (r FileHistoryRepository) entryDir(id int) string {
+	return filepath.Join(r.dirPath, strconv.Itoa(id))
+}
+
+type entriesByID []Entry
+
+func (s entriesByID) Len() int           { return len(s) }
+func (s entriesByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s entriesByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
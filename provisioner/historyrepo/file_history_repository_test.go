@@ -0,0 +1,147 @@
+package historyrepo_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/provisioner/historyrepo"
+)
+
+var _ = Describe("FileHistoryRepository", func() {
+	var (
+		dirPath string
+		repo    FileHistoryRepository
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		dirPath, err = ioutil.TempDir("", "history-repo")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+
+		repo = NewFileHistoryRepository(filepath.Join(dirPath, "history"), fs, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dirPath)
+	})
+
+	newEntry := func(manifest string) Entry {
+		return Entry{
+			DeploymentName: "fake-dep",
+			JobName:        "fake-job",
+
+			StartedAt:  time.Date(2015, time.March, 2, 10, 20, 30, 0, time.UTC),
+			FinishedAt: time.Date(2015, time.March, 2, 10, 25, 30, 0, time.UTC),
+
+			Releases: []ReleaseRecord{{
+				Name:     "fake-release",
+				Version:  "0+dev.1",
+				Jobs:     []FingerprintRecord{{Name: "fake-job", Fingerprint: "job-fp", SHA1: "job-sha1"}},
+				Packages: []FingerprintRecord{{Name: "fake-pkg", Fingerprint: "pkg-fp", SHA1: "pkg-sha1"}},
+			}},
+
+			RenderedArchives: []RenderedArchiveRecord{{JobName: "fake-job", SHA1: "archive-sha1"}},
+
+			Manifest: []byte(manifest),
+		}
+	}
+
+	Describe("Save", func() {
+		It("assigns sequential IDs and saves manifest next to the entry", func() {
+			entry, err := repo.Save(newEntry("name: first\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry.ID).To(Equal(1))
+
+			entry, err = repo.Save(newEntry("name: second\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry.ID).To(Equal(2))
+
+			bytes, err := ioutil.ReadFile(filepath.Join(dirPath, "history", "2", "manifest.yml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(bytes)).To(Equal("name: second\n"))
+		})
+
+		It("makes history dir and entry files only accessible by the owner", func() {
+			_, err := repo.Save(newEntry("name: dep\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, name := range []string{"history", "history/1"} {
+				info, err := os.Stat(filepath.Join(dirPath, name))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+			}
+
+			for _, name := range []string{"entry.json", "manifest.yml"} {
+				info, err := os.Stat(filepath.Join(dirPath, "history", "1", name))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			}
+		})
+	})
+
+	Describe("List", func() {
+		It("returns no entries if nothing was saved", func() {
+			entries, err := repo.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("returns entries ordered by ID", func() {
+			for i := 0; i < 11; i++ {
+				_, err := repo.Save(newEntry("name: dep\n"))
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			entries, err := repo.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(11))
+
+			for i, entry := range entries {
+				Expect(entry.ID).To(Equal(i + 1))
+			}
+		})
+	})
+
+	Describe("Find", func() {
+		It("returns previously saved entry", func() {
+			savedEntry, err := repo.Save(newEntry("name: dep\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			entry, found, err := repo.Find(savedEntry.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(entry).To(Equal(savedEntry))
+		})
+
+		It("returns previously saved entry with runtime config", func() {
+			entry := newEntry("name: dep\n")
+			entry.RuntimeConfig = []byte("addons: []\n")
+
+			savedEntry, err := repo.Save(entry)
+			Expect(err).ToNot(HaveOccurred())
+
+			entry, found, err := repo.Find(savedEntry.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(entry.RuntimeConfig).To(Equal([]byte("addons: []\n")))
+		})
+
+		It("returns not found if entry does not exist", func() {
+			_, found, err := repo.Find(5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+})
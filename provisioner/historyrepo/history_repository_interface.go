@@ -0,0 +1,70 @@
+package historyrepo
+
+import (
+	"time"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+)
+
+// Entry describes a single successful provision
+type Entry struct {
+	ID int
+
+	DeploymentName string
+	JobName        string
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// Release versions are recorded after being resolved (e.g. latest -> 0+dev.3)
+	Releases []ReleaseRecord
+
+	RenderedArchives []RenderedArchiveRecord
+
+	// Spec applied to the instance as reported by the agent
+	ApplySpec boshas.V1ApplySpec
+
+	// Manifest used for provisioning with ops applied and release versions resolved;
+	// variables are kept as placeholders so that their values are not recorded.
+	// Saved next to the entry.
+	Manifest []byte `json:"-"`
+
+	// Runtime config used for provisioning (if any) before variables were interpolated
+	RuntimeConfig []byte `json:"-"`
+}
+
+type ReleaseRecord struct {
+	Name    string
+	Version string
+	URL     string
+
+	CommitHash string
+
+	Jobs     []FingerprintRecord
+	Packages []FingerprintRecord
+}
+
+type FingerprintRecord struct {
+	Name        string
+	Version     string
+	Fingerprint string
+	SHA1        string
+}
+
+type RenderedArchiveRecord struct {
+	JobName string
+	SHA1    string
+	BlobID  string
+}
+
+// HistoryRepository keeps entries for every successful provision
+// so that deployments can be compared between runs.
+type HistoryRepository interface {
+	// Save assigns next available ID to the entry
+	Save(Entry) (Entry, error)
+
+	// List returns entries ordered by ID
+	List() ([]Entry, error)
+
+	Find(id int) (Entry, bool, error)
+}
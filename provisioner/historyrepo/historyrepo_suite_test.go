@@ -0,0 +1,13 @@
+package historyrepo_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHistoryrepo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Historyrepo Suite")
+}
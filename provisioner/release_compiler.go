@@ -1,6 +1,8 @@
 package provisioner
 
 import (
+	"fmt"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
@@ -22,6 +24,8 @@ type ReleaseCompiler struct {
 
 	vmProvisioner bpvm.Provisioner
 
+	config DeploymentProvisionerConfig
+
 	eventLog bpeventlog.Log
 	logger   boshlog.Logger
 }
@@ -31,6 +35,7 @@ func NewReleaseCompiler(
 	packagesCompilerFactory bppkgscomp.ConcretePackagesCompilerFactory,
 	templatesCompiler bptplcomp.TemplatesCompiler,
 	vmProvisioner bpvm.Provisioner,
+	config DeploymentProvisionerConfig,
 	eventLog bpeventlog.Log,
 	logger boshlog.Logger,
 ) ReleaseCompiler {
@@ -42,53 +47,149 @@ func NewReleaseCompiler(
 
 		vmProvisioner: vmProvisioner,
 
+		config: config,
+
 		eventLog: eventLog,
 		logger:   logger,
 	}
 }
 
-func (p ReleaseCompiler) Compile(instance bpdep.Instance, depReleases []bpdep.Release) error {
+// Compile compiles packages and precompiles job templates of all deployment releases.
+// Returns read releases so that their job and package fingerprints can be recorded.
+func (p ReleaseCompiler) Compile(instance bpdep.Instance, depReleases []bpdep.Release) ([]bprel.Release, error) {
+	var relReleases []bprel.Release
+
 	vm, err := p.vmProvisioner.Provision(instance)
 	if err != nil {
-		return bosherr.WrapError(err, "Provisioning VM")
+		return relReleases, bosherr.WrapError(err, "Provisioning VM")
 	}
 
 	defer vm.Deprovision()
 
 	pkgsCompiler := p.packagesCompilerFactory.NewCompiler(vm.AgentClient())
 
+	var latestVersionsCount int
+
 	for _, depRelease := range depReleases {
-		err := p.compileRelease(pkgsCompiler, depRelease)
+		if depRelease.Version == bprel.LatestVersion {
+			latestVersionsCount++
+		}
+	}
+
+	// Single stage includes all 'latest' versions; each is resolved once its release is read
+	var resolveStage *bpeventlog.Stage
+
+	if latestVersionsCount > 0 {
+		resolveStage = p.eventLog.BeginStage("Resolving release versions", latestVersionsCount)
+	}
+
+	// Index is used so that resolved versions are visible
+	// to deployment job templates that point to releases
+	for i := range depReleases {
+		relRelease, err := p.compileRelease(pkgsCompiler, &depReleases[i], resolveStage)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Release %s", depRelease.Name)
+			return relReleases, bosherr.WrapErrorf(err, "Release %s", depReleases[i].Name)
 		}
+
+		relReleases = append(relReleases, relRelease)
 	}
 
-	return nil
+	return relReleases, nil
 }
 
-func (p ReleaseCompiler) compileRelease(pkgsCompiler bppkgscomp.PackagesCompiler, depRelease bpdep.Release) error {
+func (p ReleaseCompiler) compileRelease(
+	pkgsCompiler bppkgscomp.PackagesCompiler,
+	depRelease *bpdep.Release,
+	resolveStage *bpeventlog.Stage,
+) (bprel.Release, error) {
 	relReader := p.releaseReaderFactory.NewReader(
 		depRelease.Name,
 		depRelease.Version,
 		depRelease.URL,
+		depRelease.SHA1,
 	)
 
 	relRelease, err := relReader.Read()
 	if err != nil {
-		return bosherr.WrapError(err, "Reading release")
+		return relRelease, bosherr.WrapError(err, "Reading release")
 	}
 
 	defer relReader.Close()
 
+	if depRelease.Version == bprel.LatestVersion {
+		p.resolveLatestVersion(resolveStage, depRelease, relRelease.Version)
+	}
+
 	err = pkgsCompiler.Compile(relRelease)
 	if err != nil {
-		return bosherr.WrapError(err, "Compiling release packages")
+		return relRelease, bosherr.WrapError(err, "Compiling release packages")
 	}
 
 	err = p.templatesCompiler.Precompile(relRelease)
 	if err != nil {
-		return bosherr.WrapError(err, "Precompiling release job templates")
+		return relRelease, bosherr.WrapError(err, "Precompiling release job templates")
+	}
+
+	return relRelease, nil
+}
+
+// resolveLatestVersion replaces 'latest' with the version of the read release
+func (p ReleaseCompiler) resolveLatestVersion(stage *bpeventlog.Stage, depRelease *bpdep.Release, version string) {
+	task := stage.BeginTask(fmt.Sprintf("%s/%s -> %s", depRelease.Name, bprel.LatestVersion, version))
+
+	p.logger.Info(releaseCompilerLogTag,
+		"Resolved release %s version latest to %s", depRelease.Name, version)
+
+	depRelease.Version = version
+
+	task.End(nil)
+}
+
+// ResolveLinks connects links consumed by deployment job templates
+// with links provided by other job templates in the deployment.
+// Resolved links are saved onto deployment job templates.
+func (p ReleaseCompiler) ResolveLinks(deployment *bpdep.Deployment) error {
+	stage := p.eventLog.BeginStage("Resolving links", 1)
+
+	task := stage.BeginTask(deployment.Name)
+
+	err := task.End(p.templatesCompiler.ResolveLinks(deployment))
+	if err != nil {
+		return bosherr.WrapError(err, "Resolving deployment links")
+	}
+
+	return nil
+}
+
+// ValidateProperties checks job instance properties against property definitions
+// of compiled release jobs. Unknown properties are only reported as warnings
+// since they could be meant for other jobs; missing properties are also reported
+// as warnings since templates may check them with if_p, unless strict properties
+// are configured in which case they result in an error.
+func (p ReleaseCompiler) ValidateProperties(job bpdep.Job, instance bpdep.Instance) error {
+	stage := p.eventLog.BeginStage("Validating properties", 1)
+
+	task := stage.BeginTask(job.Name)
+
+	result, err := p.templatesCompiler.ValidateProperties(job, instance)
+	if err != nil {
+		return task.End(bosherr.WrapError(err, "Validating job properties"))
+	}
+
+	warnings := result.Unknown
+
+	if !p.config.StrictProperties {
+		warnings = append(warnings, result.Missing...)
+		result.Missing = nil
+	}
+
+	for _, warning := range warnings {
+		p.eventLog.WriteWarning(fmt.Sprintf("Job %s: %s", job.Name, warning))
+	}
+
+	err = task.End(result.Err())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Job %s", job.Name)
 	}
 
 	return nil
@@ -1,55 +1,83 @@
 package provisioner
 
 import (
+	"time"
+
+	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
 
 	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
 	bpeventlog "github.com/cppforlife/bosh-provisioner/eventlog"
 	bpinstance "github.com/cppforlife/bosh-provisioner/instance"
+	bpappstaterepo "github.com/cppforlife/bosh-provisioner/provisioner/appliedstaterepo"
+	bphistrepo "github.com/cppforlife/bosh-provisioner/provisioner/historyrepo"
+	bprel "github.com/cppforlife/bosh-provisioner/release"
 	bpvm "github.com/cppforlife/bosh-provisioner/vm"
 )
 
+const singleConfiguredVMProvisionerLogTag = "SingleConfiguredVMProvisioner"
+
 // SingleConfiguredVMProvisioner interprets deployment manifest and
 // configures 1 VM just like regular BOSH VM.
 type SingleConfiguredVMProvisioner struct {
 	manifestPath            string
+	opsPaths                []string
+	runtimeConfigPath       string
 	deploymentReaderFactory bpdep.ReaderFactory
 
 	vmProvisioner       bpvm.Provisioner
 	releaseCompiler     ReleaseCompiler
 	instanceProvisioner bpinstance.Provisioner
 
+	appliedStateRepo bpappstaterepo.AppliedStateRepository
+	historyRepo      bphistrepo.HistoryRepository
+
+	clock    clock.Clock
 	eventLog bpeventlog.Log
 	logger   boshlog.Logger
 }
 
 func NewSingleConfiguredVMProvisioner(
 	manifestPath string,
+	opsPaths []string,
+	runtimeConfigPath string,
 	deploymentReaderFactory bpdep.ReaderFactory,
 	vmProvisioner bpvm.Provisioner,
 	releaseCompiler ReleaseCompiler,
 	instanceProvisioner bpinstance.Provisioner,
+	appliedStateRepo bpappstaterepo.AppliedStateRepository,
+	historyRepo bphistrepo.HistoryRepository,
+	clock clock.Clock,
 	eventLog bpeventlog.Log,
 	logger boshlog.Logger,
 ) SingleConfiguredVMProvisioner {
 	return SingleConfiguredVMProvisioner{
 		manifestPath:            manifestPath,
+		opsPaths:                opsPaths,
+		runtimeConfigPath:       runtimeConfigPath,
 		deploymentReaderFactory: deploymentReaderFactory,
 
 		vmProvisioner:       vmProvisioner,
 		releaseCompiler:     releaseCompiler,
 		instanceProvisioner: instanceProvisioner,
 
+		appliedStateRepo: appliedStateRepo,
+		historyRepo:      historyRepo,
+
+		clock:    clock,
 		eventLog: eventLog,
 		logger:   logger,
 	}
 }
 
 func (p SingleConfiguredVMProvisioner) Provision() error {
+	startedAt := p.clock.Now().UTC()
+
 	stage := p.eventLog.BeginStage("Setting up instance", 2)
 
-	reader := p.deploymentReaderFactory.NewManifestReader(p.manifestPath)
+	reader := p.deploymentReaderFactory.NewManifestReader(p.manifestPath, p.opsPaths, p.runtimeConfigPath)
 
 	task := stage.BeginTask("Reading deployment manifest")
 
@@ -84,26 +112,155 @@ func (p SingleConfiguredVMProvisioner) Provision() error {
 		return bosherr.WrapError(err, "Deprovisioning VM")
 	}
 
-	err = p.releaseCompiler.Compile(deployment.CompilationInstance, deployment.Releases)
+	relReleases, err := p.releaseCompiler.Compile(deployment.CompilationInstance, deployment.Releases)
 	if err != nil {
 		return bosherr.WrapError(err, "Compiling releases")
 	}
 
+	err = p.releaseCompiler.ResolveLinks(&deployment)
+	if err != nil {
+		return bosherr.WrapError(err, "Resolving links")
+	}
+
+	// Job is picked again so that its templates include resolved links
+	job = deployment.Jobs[0]
+
+	err = p.releaseCompiler.ValidateProperties(job, depInstance)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating properties")
+	}
+
 	vm, err = p.vmProvisioner.Provision(depInstance)
 	if err != nil {
 		return bosherr.WrapError(err, "Provisioning VM")
 	}
 
-	_, err = p.instanceProvisioner.Provision(vm.AgentClient(), job, depInstance)
+	instance, err = p.instanceProvisioner.Provision(vm.AgentClient(), job, depInstance)
 	if err != nil {
 		return bosherr.WrapError(err, "Starting instance")
 	}
 
+	// Single instance is the whole deployment
+	err = instance.PostDeploy()
+	if err != nil {
+		return bosherr.WrapError(err, "Post-Deploying instance")
+	}
+
+	err = p.saveAppliedState(deployment, job)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving applied state")
+	}
+
+	state, err := vm.AgentClient().GetState()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting instance state")
+	}
+
+	err = p.saveHistoryEntry(startedAt, deployment, job, relReleases, state)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving history entry")
+	}
+
 	// Do not Deprovision() VM to keep instance running
 
 	return nil
 }
 
+// saveAppliedState records release versions resolved during compilation
+func (p SingleConfiguredVMProvisioner) saveAppliedState(deployment bpdep.Deployment, job bpdep.Job) error {
+	record := bpappstaterepo.AppliedStateRecord{
+		DeploymentName: deployment.Name,
+		JobName:        job.Name,
+	}
+
+	for _, release := range deployment.Releases {
+		record.Releases = append(record.Releases, bpappstaterepo.AppliedReleaseRecord{
+			Name:    release.Name,
+			Version: release.Version,
+			URL:     release.URL,
+		})
+	}
+
+	return p.appliedStateRepo.Save(record)
+}
+
+// saveHistoryEntry records what exactly was deployed so that
+// it could be compared with later provisions
+func (p SingleConfiguredVMProvisioner) saveHistoryEntry(
+	startedAt time.Time,
+	deployment bpdep.Deployment,
+	job bpdep.Job,
+	relReleases []bprel.Release,
+	state boshaction.GetStateV1ApplySpec,
+) error {
+	// Interpolated manifest is not recorded since it includes variable values
+	manifestBytes, err := deployment.ResolvedSourceManifest()
+	if err != nil {
+		return err
+	}
+
+	entry := bphistrepo.Entry{
+		DeploymentName: deployment.Name,
+		JobName:        job.Name,
+
+		StartedAt:  startedAt,
+		FinishedAt: p.clock.Now().UTC(),
+
+		RenderedArchives: []bphistrepo.RenderedArchiveRecord{{
+			JobName: job.Name,
+			SHA1:    state.RenderedTemplatesArchiveSpec.Sha1,
+			BlobID:  state.RenderedTemplatesArchiveSpec.BlobstoreID,
+		}},
+
+		ApplySpec:     state.V1ApplySpec,
+		Manifest:      manifestBytes,
+		RuntimeConfig: deployment.SourceRuntimeConfig,
+	}
+
+	for i, release := range deployment.Releases {
+		entry.Releases = append(entry.Releases, p.buildReleaseRecord(release, relReleases[i]))
+	}
+
+	entry, err = p.historyRepo.Save(entry)
+	if err != nil {
+		return err
+	}
+
+	p.logger.Info(singleConfiguredVMProvisionerLogTag, "Saved history entry %d", entry.ID)
+
+	return nil
+}
+
+func (p SingleConfiguredVMProvisioner) buildReleaseRecord(release bpdep.Release, relRelease bprel.Release) bphistrepo.ReleaseRecord {
+	record := bphistrepo.ReleaseRecord{
+		Name:    release.Name,
+		Version: release.Version,
+		URL:     release.URL,
+
+		CommitHash: relRelease.CommitHash,
+	}
+
+	for _, job := range relRelease.Jobs {
+		record.Jobs = append(record.Jobs, bphistrepo.FingerprintRecord{
+			Name:        job.Name,
+			Version:     job.Version,
+			Fingerprint: job.Fingerprint,
+			SHA1:        job.SHA1,
+		})
+	}
+
+	for _, pkg := range relRelease.Packages {
+		record.Packages = append(record.Packages, bphistrepo.FingerprintRecord{
+			Name:        pkg.Name,
+			Version:     pkg.Version,
+			Fingerprint: pkg.Fingerprint,
+			SHA1:        pkg.SHA1,
+		})
+	}
+
+	return record
+}
+
 func (p SingleConfiguredVMProvisioner) validateInstance(deployment bpdep.Deployment) (bpdep.Job, bpdep.Instance, error) {
 	var job bpdep.Job
 	var instance bpdep.Instance
@@ -2,10 +2,13 @@ package provisioner
 
 import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
 
 	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
 	bpeventlog "github.com/cppforlife/bosh-provisioner/eventlog"
 	bpinstance "github.com/cppforlife/bosh-provisioner/instance"
+	bpappstaterepo "github.com/cppforlife/bosh-provisioner/provisioner/appliedstaterepo"
+	bphistrepo "github.com/cppforlife/bosh-provisioner/provisioner/historyrepo"
 	bpvm "github.com/cppforlife/bosh-provisioner/vm"
 )
 
@@ -17,6 +20,10 @@ type SingleVMProvisionerFactory struct {
 	releaseCompiler     ReleaseCompiler
 	instanceProvisioner bpinstance.Provisioner
 
+	appliedStateRepo bpappstaterepo.AppliedStateRepository
+	historyRepo      bphistrepo.HistoryRepository
+
+	clock    clock.Clock
 	eventLog bpeventlog.Log
 	logger   boshlog.Logger
 }
@@ -27,6 +34,9 @@ func NewSingleVMProvisionerFactory(
 	vmProvisioner bpvm.Provisioner,
 	releaseCompiler ReleaseCompiler,
 	instanceProvisioner bpinstance.Provisioner,
+	appliedStateRepo bpappstaterepo.AppliedStateRepository,
+	historyRepo bphistrepo.HistoryRepository,
+	clock clock.Clock,
 	eventLog bpeventlog.Log,
 	logger boshlog.Logger,
 ) SingleVMProvisionerFactory {
@@ -38,6 +48,10 @@ func NewSingleVMProvisionerFactory(
 		releaseCompiler:     releaseCompiler,
 		instanceProvisioner: instanceProvisioner,
 
+		appliedStateRepo: appliedStateRepo,
+		historyRepo:      historyRepo,
+
+		clock:    clock,
 		eventLog: eventLog,
 		logger:   logger,
 	}
@@ -49,10 +63,15 @@ func (f SingleVMProvisionerFactory) NewSingleVMProvisioner() DeploymentProvision
 	if len(f.deploymentProvisionerConfig.ManifestPath) > 0 {
 		prov = NewSingleConfiguredVMProvisioner(
 			f.deploymentProvisionerConfig.ManifestPath,
+			f.deploymentProvisionerConfig.OpsFiles,
+			f.deploymentProvisionerConfig.RuntimeConfigPath,
 			f.deploymentReaderFactory,
 			f.vmProvisioner,
 			f.releaseCompiler,
 			f.instanceProvisioner,
+			f.appliedStateRepo,
+			f.historyRepo,
+			f.clock,
 			f.eventLog,
 			f.logger,
 		)
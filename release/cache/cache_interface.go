@@ -0,0 +1,16 @@
+// Package cache keeps directories (e.g. extracted releases) between provisions.
+package cache
+
+type Cache interface {
+	// Find returns path to a cached directory for a given key.
+	Find(key string) (string, bool, error)
+
+	// Save moves directory into the cache and returns its new path.
+	Save(key, srcPath string) (string, error)
+
+	// Remove removes cached directory for a given key if it exists.
+	Remove(key string) error
+
+	// Clear removes all cached directories.
+	Clear() error
+}
@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
+)
+
+const dirCacheLogTag = "DirCache"
+
+// DirCache keeps each cached directory in <dir>/<sha1 of key>/ and
+// records sizes and last use times in <dir>/index.json. Least recently
+// used directories are removed when total size goes over max size.
+type DirCache struct {
+	dirPath string
+
+	// Zero means no limit
+	maxSize int64
+
+	fs     boshsys.FileSystem
+	clock  clock.Clock
+	logger boshlog.Logger
+}
+
+type dirCacheEntry struct {
+	Key      string    `json:"key"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Entries are keyed by directory names
+type dirCacheIndex map[string]dirCacheEntry
+
+type dirCacheEntriesByLastUsed []dirCacheEntry
+
+func (s dirCacheEntriesByLastUsed) Len() int           { return len(s) }
+func (s dirCacheEntriesByLastUsed) Less(i, j int) bool { return s[i].LastUsed.Before(s[j].LastUsed) }
+func (s dirCacheEntriesByLastUsed) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func NewDirCache(
+	dirPath string,
+	maxSize int64,
+	fs boshsys.FileSystem,
+	clock clock.Clock,
+	logger boshlog.Logger,
+) DirCache {
+	return DirCache{
+		dirPath: dirPath,
+		maxSize: maxSize,
+
+		fs:     fs,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+func (c DirCache) Find(key string) (string, bool, error) {
+	index, err := c.readIndex()
+	if err != nil {
+		return "", false, err
+	}
+
+	name := c.entryName(key)
+	path := filepath.Join(c.dirPath, name)
+
+	entry, found := index[name]
+	if !found || !c.fs.FileExists(path) {
+		return "", false, nil
+	}
+
+	entry.LastUsed = c.clock.Now()
+	index[name] = entry
+
+	err = c.writeIndex(index)
+	if err != nil {
+		return "", false, err
+	}
+
+	c.logger.Debug(dirCacheLogTag, "Found cached %s at %s", key, path)
+
+	return path, true, nil
+}
+
+func (c DirCache) Save(key, srcPath string) (string, error) {
+	index, err := c.readIndex()
+	if err != nil {
+		return "", err
+	}
+
+	name := c.entryName(key)
+	path := filepath.Join(c.dirPath, name)
+
+	err = c.fs.MkdirAll(c.dirPath, os.ModePerm)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating cache dir")
+	}
+
+	err = c.moveDir(srcPath, path)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Moving %s into cache", key)
+	}
+
+	size, err := c.dirSize(path)
+	if err != nil {
+		return "", err
+	}
+
+	index[name] = dirCacheEntry{Key: key, Size: size, LastUsed: c.clock.Now()}
+
+	err = c.evict(index, key)
+	if err != nil {
+		return "", err
+	}
+
+	err = c.writeIndex(index)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (c DirCache) Remove(key string) error {
+	index, err := c.readIndex()
+	if err != nil {
+		return err
+	}
+
+	name := c.entryName(key)
+
+	c.logger.Debug(dirCacheLogTag, "Removing %s", key)
+
+	err = c.fs.RemoveAll(filepath.Join(c.dirPath, name))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing %s", key)
+	}
+
+	if _, found := index[name]; !found {
+		return nil
+	}
+
+	delete(index, name)
+
+	return c.writeIndex(index)
+}
+
+func (c DirCache) Clear() error {
+	err := c.fs.RemoveAll(c.dirPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing cache dir")
+	}
+
+	return nil
+}
+
+// evict removes least recently used entries (except for kept one)
+// until total size is within max size.
+func (c DirCache) evict(index dirCacheIndex, keptKey string) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+
+	var totalSize int64
+
+	var entries []dirCacheEntry
+
+	for _, entry := range index {
+		totalSize += entry.Size
+		entries = append(entries, entry)
+	}
+
+	sort.Sort(dirCacheEntriesByLastUsed(entries))
+
+	keptName := c.entryName(keptKey)
+
+	for _, entry := range entries {
+		if totalSize <= c.maxSize {
+			break
+		}
+
+		name := c.entryName(entry.Key)
+
+		if name == keptName {
+			continue
+		}
+
+		c.logger.Debug(dirCacheLogTag, "Evicting %s", entry.Key)
+
+		err := c.fs.RemoveAll(filepath.Join(c.dirPath, name))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Evicting %s", entry.Key)
+		}
+
+		totalSize -= entry.Size
+
+		delete(index, name)
+	}
+
+	return nil
+}
+
+// moveDir renames directory if possible since cache dir
+// might be on a different device than source directory.
+func (c DirCache) moveDir(srcPath, dstPath string) error {
+	err := c.fs.Rename(srcPath, dstPath)
+	if err == nil {
+		return nil
+	}
+
+	c.logger.Debug(dirCacheLogTag, "Failed to rename %s, copying instead: %s", srcPath, err)
+
+	err = c.fs.CopyDir(srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+
+	return c.fs.RemoveAll(srcPath)
+}
+
+func (c DirCache) dirSize(path string) (int64, error) {
+	var size int64
+
+	err := c.fs.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Calculating size of %s", path)
+	}
+
+	return size, nil
+}
+
+func (c DirCache) entryName(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c DirCache) indexPath() string {
+	return filepath.Join(c.dirPath, "index.json")
+}
+
+func (c DirCache) readIndex() (dirCacheIndex, error) {
+	index := dirCacheIndex{}
+
+	if !c.fs.FileExists(c.indexPath()) {
+		return index, nil
+	}
+
+	bytes, err := c.fs.ReadFile(c.indexPath())
+	if err != nil {
+		return index, bosherr.WrapError(err, "Reading cache index")
+	}
+
+	err = json.Unmarshal(bytes, &index)
+	if err != nil {
+		return index, bosherr.WrapError(err, "Unmarshalling cache index")
+	}
+
+	return index, nil
+}
+
+func (c DirCache) writeIndex(index dirCacheIndex) error {
+	bytes, err := json.Marshal(index)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling cache index")
+	}
+
+	err = c.fs.WriteFile(c.indexPath(), bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing cache index")
+	}
+
+	return nil
+}
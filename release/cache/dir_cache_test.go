@@ -0,0 +1,144 @@
+package cache_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release/cache"
+)
+
+var _ = Describe("DirCache", func() {
+	var (
+		dir   string
+		fs    boshsys.FileSystem
+		clock *fakeclock.FakeClock
+		cache DirCache
+	)
+
+	// newSrcDir returns directory with a single file of a given size
+	newSrcDir := func(size int) string {
+		srcDir, err := ioutil.TempDir("", "dir-cache-src")
+		Expect(err).ToNot(HaveOccurred())
+
+		content := strings.Repeat("x", size)
+		Expect(ioutil.WriteFile(filepath.Join(srcDir, "release.MF"), []byte(content), 0644)).To(Succeed())
+
+		return srcDir
+	}
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		clock = fakeclock.NewFakeClock(time.Now())
+
+		var err error
+
+		dir, err = ioutil.TempDir("", "dir-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		cache = NewDirCache(filepath.Join(dir, "cache"), 25, fs, clock, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("finds saved directories", func() {
+		srcDir := newSrcDir(10)
+
+		path, err := cache.Save("key-1", srcDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists(srcDir)).To(BeFalse())
+		Expect(fs.FileExists(filepath.Join(path, "release.MF"))).To(BeTrue())
+
+		foundPath, found, err := cache.Find("key-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(foundPath).To(Equal(path))
+
+		_, found, err = cache.Find("key-2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("removes least recently used directories when over max size", func() {
+		_, err := cache.Save("key-1", newSrcDir(10))
+		Expect(err).ToNot(HaveOccurred())
+
+		clock.Increment(time.Minute)
+
+		_, err = cache.Save("key-2", newSrcDir(10))
+		Expect(err).ToNot(HaveOccurred())
+
+		clock.Increment(time.Minute)
+
+		// Makes key-2 least recently used
+		_, _, err = cache.Find("key-1")
+		Expect(err).ToNot(HaveOccurred())
+
+		clock.Increment(time.Minute)
+
+		_, err = cache.Save("key-3", newSrcDir(10))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found, err := cache.Find("key-2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		_, found, err = cache.Find("key-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		_, found, err = cache.Find("key-3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+	})
+
+	It("keeps just saved directory even if it is over max size", func() {
+		path, err := cache.Save("key-1", newSrcDir(30))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists(path)).To(BeTrue())
+	})
+
+	It("removes directory for a given key", func() {
+		path, err := cache.Save("key-1", newSrcDir(10))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = cache.Save("key-2", newSrcDir(10))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cache.Remove("key-1")).To(Succeed())
+		Expect(fs.FileExists(path)).To(BeFalse())
+
+		_, found, err := cache.Find("key-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		_, found, err = cache.Find("key-2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		Expect(cache.Remove("key-3")).To(Succeed())
+	})
+
+	It("removes all directories when cleared", func() {
+		_, err := cache.Save("key-1", newSrcDir(10))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cache.Clear()).To(Succeed())
+
+		_, found, err := cache.Find("key-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+})
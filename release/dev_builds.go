@@ -0,0 +1,136 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+// devBuilds keeps job and package tarballs created for dev releases
+// (e.g. .dev_builds/packages/golang/<fingerprint>.tgz) so that
+// unchanged jobs and packages keep their SHA1s across dev releases.
+type devBuilds struct {
+	dir string // e.g. .dev_builds
+	fs  boshsys.FileSystem
+}
+
+type devBuildsIndexFile struct {
+	Builds        map[string]devBuildsIndexBuild `yaml:"builds"`
+	FormatVersion string                         `yaml:"format-version"`
+}
+
+type devBuildsIndexBuild struct {
+	Version string `yaml:"version"`
+	SHA1    string `yaml:"sha1"`
+}
+
+func newDevBuilds(dir string, fs boshsys.FileSystem) devBuilds {
+	return devBuilds{dir: dir, fs: fs}
+}
+
+// Find returns path and SHA1 of previously built tarball with a given fingerprint.
+// Tarballs that do not match recorded SHA1 are ignored.
+func (b devBuilds) Find(kind, name, fingerprint string) (string, string, bool, error) {
+	file, err := b.readIndex(kind, name)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	build, found := file.Builds[fingerprint]
+	if !found {
+		return "", "", false, nil
+	}
+
+	path := b.tarballPath(kind, name, fingerprint)
+
+	if !b.fs.FileExists(path) {
+		return "", "", false, nil
+	}
+
+	digest, err := bputil.NewDigestFromFile(bputil.DigestAlgorithmSHA1, path, b.fs)
+	if err != nil {
+		return "", "", false, bosherr.WrapErrorf(err, "Calculating SHA1 of %s", path)
+	}
+
+	if digest.Value != build.SHA1 {
+		return "", "", false, nil
+	}
+
+	return path, build.SHA1, true, nil
+}
+
+// Add copies built tarball into dev builds and records it in the index.
+func (b devBuilds) Add(kind, name, fingerprint, srcPath, sha1 string) (string, error) {
+	path := b.tarballPath(kind, name, fingerprint)
+
+	err := b.fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating dev builds directory for %s", name)
+	}
+
+	err = b.fs.CopyFile(srcPath, path)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Copying %s tarball", name)
+	}
+
+	file, err := b.readIndex(kind, name)
+	if err != nil {
+		return "", err
+	}
+
+	if file.Builds == nil {
+		file.Builds = map[string]devBuildsIndexBuild{}
+	}
+
+	file.Builds[fingerprint] = devBuildsIndexBuild{Version: fingerprint, SHA1: sha1}
+	file.FormatVersion = releaseIndexFormatVersion
+
+	bytes, err := candiedyaml.Marshal(file)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Marshalling dev builds index")
+	}
+
+	indexPath := b.indexPath(kind, name)
+
+	err = b.fs.WriteFile(indexPath, bytes)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Writing dev builds index %s", indexPath)
+	}
+
+	return path, nil
+}
+
+func (b devBuilds) readIndex(kind, name string) (devBuildsIndexFile, error) {
+	var file devBuildsIndexFile
+
+	path := b.indexPath(kind, name)
+
+	if !b.fs.FileExists(path) {
+		return file, nil
+	}
+
+	bytes, err := b.fs.ReadFile(path)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Reading dev builds index %s", path)
+	}
+
+	err = candiedyaml.Unmarshal(bytes, &file)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Parsing dev builds index %s", path)
+	}
+
+	return file, nil
+}
+
+func (b devBuilds) indexPath(kind, name string) string {
+	return filepath.Join(b.dir, kind, name, "index.yml")
+}
+
+func (b devBuilds) tarballPath(kind, name, fingerprint string) string {
+	return filepath.Join(b.dir, kind, name, fingerprint+".tgz")
+}
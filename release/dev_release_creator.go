@@ -0,0 +1,300 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	bprelman "github.com/cppforlife/bosh-provisioner/release/manifest"
+	bptar "github.com/cppforlife/bosh-provisioner/tar"
+)
+
+const devReleaseCreatorLogTag = "DevReleaseCreator"
+
+// DevReleaseCreator creates dev release tarballs from a release source directory
+// similarly to 'bosh create release --with-tarball'. Created releases are recorded
+// in dev_releases/<name>/index.yml and built jobs and packages are kept in .dev_builds/.
+type DevReleaseCreator struct {
+	dir string
+
+	builder    DirBuilder
+	devBuilds  devBuilds
+	compressor bptar.Compressor
+	uuidGen    boshuuid.Generator
+	fs         boshsys.FileSystem
+	logger     boshlog.Logger
+}
+
+// CreatedRelease describes dev release written into release directory.
+type CreatedRelease struct {
+	Manifest bprelman.Manifest
+
+	ManifestPath string // e.g. dev_releases/room101/room101-0+dev.3.yml
+	TarballPath  string // e.g. dev_releases/room101/room101-0+dev.3.tgz
+}
+
+func NewDevReleaseCreator(
+	dir string,
+	compressor bptar.Compressor,
+	uuidGen boshuuid.Generator,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) DevReleaseCreator {
+	return DevReleaseCreator{
+		dir: dir,
+
+		builder:    NewDirBuilder(dir, compressor, fs, logger),
+		devBuilds:  newDevBuilds(filepath.Join(dir, ".dev_builds"), fs),
+		compressor: compressor,
+		uuidGen:    uuidGen,
+		fs:         fs,
+		logger:     logger,
+	}
+}
+
+// Create builds next dev version of the release and writes its manifest and tarball.
+// If name is empty, it is taken from config/final.yml or release directory name.
+func (c DevReleaseCreator) Create(name string) (CreatedRelease, error) {
+	var created CreatedRelease
+
+	if name == "" {
+		defaultName, err := c.defaultName()
+		if err != nil {
+			return created, err
+		}
+
+		name = defaultName
+	}
+
+	version, err := nextDevVersion(c.dir, name, c.fs)
+	if err != nil {
+		return created, bosherr.WrapError(err, "Computing dev version")
+	}
+
+	built, err := c.builder.Build(name, version)
+	if err != nil {
+		return created, bosherr.WrapError(err, "Building release")
+	}
+
+	defer func() {
+		err := c.builder.CleanUp(built)
+		if err != nil {
+			c.logger.Debug(devReleaseCreatorLogTag, "Failed to clean up built release %v", err)
+		}
+	}()
+
+	manifest := built.Manifest
+
+	jobTarPaths, err := c.reuseJobDevBuilds(manifest.Release.Jobs, built.JobTarPaths)
+	if err != nil {
+		return created, err
+	}
+
+	pkgTarPaths, err := c.reusePackageDevBuilds(manifest.Release.Packages, built.PackageTarPaths)
+	if err != nil {
+		return created, err
+	}
+
+	manifestBytes, err := manifest.Bytes()
+	if err != nil {
+		return created, err
+	}
+
+	devReleasesDir := filepath.Join(c.dir, "dev_releases", name)
+	fileName := name + "-" + version
+
+	created = CreatedRelease{
+		Manifest: manifest,
+
+		ManifestPath: filepath.Join(devReleasesDir, fileName+".yml"),
+		TarballPath:  filepath.Join(devReleasesDir, fileName+".tgz"),
+	}
+
+	err = c.writeTarball(manifestBytes, jobTarPaths, pkgTarPaths, created.TarballPath)
+	if err != nil {
+		return created, err
+	}
+
+	err = c.fs.WriteFile(created.ManifestPath, manifestBytes)
+	if err != nil {
+		return created, bosherr.WrapError(err, "Writing release manifest")
+	}
+
+	buildID, err := c.uuidGen.Generate()
+	if err != nil {
+		return created, bosherr.WrapError(err, "Generating build ID")
+	}
+
+	err = newReleaseIndex(filepath.Join(c.dir, "dev_releases"), name, c.fs).AddVersion(buildID, version)
+	if err != nil {
+		return created, bosherr.WrapError(err, "Updating dev releases index")
+	}
+
+	return created, nil
+}
+
+// reuseJobDevBuilds replaces newly built job tarballs with previously built ones
+// that have same fingerprints; new tarballs are saved for future dev releases.
+func (c DevReleaseCreator) reuseJobDevBuilds(jobs []bprelman.Job, builtPaths map[string]string) (map[string]string, error) {
+	tarPaths := map[string]string{}
+
+	for i, job := range jobs {
+		path, sha1, err := c.reuseDevBuild("jobs", job.Name, job.Fingerprint, builtPaths[job.Name], job.SHA1)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reusing job %s", job.Name)
+		}
+
+		jobs[i].SHA1 = sha1
+		tarPaths[job.Name] = path
+	}
+
+	return tarPaths, nil
+}
+
+func (c DevReleaseCreator) reusePackageDevBuilds(pkgs []bprelman.Package, builtPaths map[string]string) (map[string]string, error) {
+	tarPaths := map[string]string{}
+
+	for i, pkg := range pkgs {
+		path, sha1, err := c.reuseDevBuild("packages", pkg.Name, pkg.Fingerprint, builtPaths[pkg.Name], pkg.SHA1)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reusing package %s", pkg.Name)
+		}
+
+		pkgs[i].SHA1 = sha1
+		tarPaths[pkg.Name] = path
+	}
+
+	return tarPaths, nil
+}
+
+func (c DevReleaseCreator) reuseDevBuild(kind, name, fingerprint, builtPath, builtSHA1 string) (string, string, error) {
+	path, sha1, found, err := c.devBuilds.Find(kind, name, fingerprint)
+	if err != nil {
+		return "", "", err
+	}
+
+	if found {
+		c.logger.Debug(devReleaseCreatorLogTag, "Reusing %s/%s with fingerprint %s", kind, name, fingerprint)
+		return path, sha1, nil
+	}
+
+	path, err = c.devBuilds.Add(kind, name, fingerprint, builtPath, builtSHA1)
+	if err != nil {
+		return "", "", err
+	}
+
+	return path, builtSHA1, nil
+}
+
+// defaultName returns release name configured in config/final.yml
+// (name or older final_name key) or release directory name.
+func (c DevReleaseCreator) defaultName() (string, error) {
+	path := filepath.Join(c.dir, "config", "final.yml")
+
+	if c.fs.FileExists(path) {
+		bytes, err := c.fs.ReadFile(path)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Reading config/final.yml")
+		}
+
+		var config struct {
+			Name      string `yaml:"name"`
+			FinalName string `yaml:"final_name"`
+		}
+
+		err = candiedyaml.Unmarshal(bytes, &config)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Parsing config/final.yml")
+		}
+
+		if config.Name != "" {
+			return config.Name, nil
+		}
+
+		if config.FinalName != "" {
+			return config.FinalName, nil
+		}
+	}
+
+	absDir, err := filepath.Abs(c.dir)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Expanding release directory")
+	}
+
+	return filepath.Base(absDir), nil
+}
+
+// writeTarball creates release tarball with release.MF, jobs/ and packages/
+func (c DevReleaseCreator) writeTarball(
+	manifestBytes []byte,
+	jobTarPaths, pkgTarPaths map[string]string,
+	dstPath string,
+) error {
+	stagePath, err := c.fs.TempDir("release-DevReleaseCreator")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating staging directory")
+	}
+
+	defer c.fs.RemoveAll(stagePath)
+
+	err = c.fs.WriteFile(filepath.Join(stagePath, "release.MF"), manifestBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing release.MF")
+	}
+
+	stageTarballs := func(kind string, tarPaths map[string]string) error {
+		err := c.fs.MkdirAll(filepath.Join(stagePath, kind), os.ModePerm)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating %s directory", kind)
+		}
+
+		for name, tarPath := range tarPaths {
+			err := c.fs.CopyFile(tarPath, filepath.Join(stagePath, kind, name+".tgz"))
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Copying %s/%s", kind, name)
+			}
+		}
+
+		return nil
+	}
+
+	err = stageTarballs("jobs", jobTarPaths)
+	if err != nil {
+		return err
+	}
+
+	err = stageTarballs("packages", pkgTarPaths)
+	if err != nil {
+		return err
+	}
+
+	tarPath, err := c.compressor.Compress(stagePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Compressing release")
+	}
+
+	err = c.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating dev releases directory")
+	}
+
+	defer func() {
+		err := c.compressor.CleanUp(tarPath)
+		if err != nil {
+			c.logger.Debug(devReleaseCreatorLogTag, "Failed to clean up compressed release %v", err)
+		}
+	}()
+
+	// Release directory might be on a different device than temporary directory
+	err = c.fs.CopyFile(tarPath, dstPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Copying release tarball")
+	}
+
+	return nil
+}
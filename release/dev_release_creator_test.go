@@ -0,0 +1,128 @@
+package release_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release"
+	bptar "github.com/cppforlife/bosh-provisioner/tar"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+var _ = Describe("DevReleaseCreator", func() {
+	var (
+		dir     string
+		fs      boshsys.FileSystem
+		logger  boshlog.Logger
+		runner  boshsys.CmdRunner
+		creator DevReleaseCreator
+	)
+
+	writeFile := func(path, content string) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		runner = boshsys.NewExecCmdRunner(logger)
+
+		var err error
+
+		dir, err = ioutil.TempDir("", "dev-release-creator-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		compressor := bptar.NewCmdCompressor(runner, fs, logger)
+		creator = NewDevReleaseCreator(dir, compressor, boshuuid.NewGenerator(), fs, logger)
+
+		writeFile("config/final.yml", "final_name: room101\n")
+
+		writeFile("packages/warden/spec", "name: warden\nfiles: [warden/*.go]\n")
+		writeFile("packages/warden/packaging", "go build")
+		writeFile("src/warden/main.go", "package main")
+
+		writeFile("jobs/warden/spec", "name: warden\ntemplates: {ctl.erb: bin/ctl}\npackages: [warden]\n")
+		writeFile("jobs/warden/templates/ctl.erb", "#!/bin/bash")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Create", func() {
+		It("creates dev release tarball that can be read by tar reader", func() {
+			created, err := creator.Create("")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(created.Manifest.Release.Name).To(Equal("room101"))
+			Expect(created.Manifest.Release.Version).To(Equal("0+dev.1"))
+			Expect(created.Manifest.Release.CommitHash).To(Equal("non-git"))
+			Expect(created.TarballPath).To(Equal(filepath.Join(dir, "dev_releases/room101/room101-0+dev.1.tgz")))
+			Expect(fs.FileExists(created.ManifestPath)).To(BeTrue())
+
+			extractor := bptar.NewCmdExtractor(runner, fs, logger)
+			downloader := &fakeDownloader{path: created.TarballPath}
+
+			reader := NewTarReader(created.TarballPath, bputil.Digest{}, downloader, extractor, fs, logger)
+
+			release, err := reader.Read()
+			Expect(err).ToNot(HaveOccurred())
+
+			defer reader.Close()
+
+			Expect(release.Name).To(Equal("room101"))
+			Expect(release.Version).To(Equal("0+dev.1"))
+			Expect(release.Jobs).To(HaveLen(1))
+			Expect(release.Packages).To(HaveLen(1))
+
+			pkg := release.Packages[0]
+			Expect(pkg.Fingerprint).To(Equal(created.Manifest.Release.Packages[0].Fingerprint))
+
+			digest, err := bputil.NewDigestFromFile(bputil.DigestAlgorithmSHA1, pkg.TarPath, fs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digest.Value).To(Equal(pkg.SHA1))
+		})
+
+		It("increments dev version and reuses dev builds with same fingerprints", func() {
+			first, err := creator.Create("")
+			Expect(err).ToNot(HaveOccurred())
+
+			writeFile("jobs/warden/templates/ctl.erb", "#!/bin/bash\nexit 0")
+
+			second, err := creator.Create("")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(second.Manifest.Release.Version).To(Equal("0+dev.2"))
+
+			firstRel := first.Manifest.Release
+			secondRel := second.Manifest.Release
+
+			Expect(secondRel.Packages[0].SHA1).To(Equal(firstRel.Packages[0].SHA1))
+			Expect(secondRel.Jobs[0].Fingerprint).ToNot(Equal(firstRel.Jobs[0].Fingerprint))
+
+			Expect(fs.FileExists(filepath.Join(dir, ".dev_builds/packages/warden/index.yml"))).To(BeTrue())
+			Expect(fs.FileExists(filepath.Join(dir, ".dev_builds/jobs/warden", secondRel.Jobs[0].Fingerprint+".tgz"))).To(BeTrue())
+
+			index, err := fs.ReadFileString(filepath.Join(dir, "dev_releases/room101/index.yml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(index).To(ContainSubstring("0+dev.1"))
+			Expect(index).To(ContainSubstring("0+dev.2"))
+		})
+
+		It("uses given release name", func() {
+			created, err := creator.Create("other")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.Manifest.Release.Name).To(Equal("other"))
+		})
+	})
+})
@@ -0,0 +1,657 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bpreljobman "github.com/cppforlife/bosh-provisioner/release/job/manifest"
+	bprelman "github.com/cppforlife/bosh-provisioner/release/manifest"
+	bptar "github.com/cppforlife/bosh-provisioner/tar"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+const (
+	dirBuilderLogTag           = "DirBuilder"
+	dirBuilderNonGitCommitHash = "non-git"
+)
+
+// DirBuilder creates job and package tarballs from a release source directory
+// (jobs/, packages/, src/, blobs/ and config/blobs.yml) similarly to 'bosh create release'.
+// See release source directory layout at the end of the file.
+type DirBuilder struct {
+	dir string
+
+	compressor bptar.Compressor
+	fs         boshsys.FileSystem
+	logger     boshlog.Logger
+}
+
+// BuiltRelease describes jobs and packages built from a release source directory.
+type BuiltRelease struct {
+	Manifest bprelman.Manifest
+
+	// Keyed by job/package names
+	JobTarPaths     map[string]string
+	PackageTarPaths map[string]string
+
+	// Directory that includes all tarballs
+	Path string
+}
+
+type packageSpec struct {
+	Name          string   `yaml:"name"`
+	Dependencies  []string `yaml:"dependencies"`
+	Files         []string `yaml:"files"`
+	ExcludedFiles []string `yaml:"excluded_files"`
+}
+
+// blobRecord is an entry in config/blobs.yml keyed by blob path
+type blobRecord struct {
+	Size     int64  `yaml:"size"`
+	ObjectID string `yaml:"object_id"`
+	SHA      string `yaml:"sha"`
+}
+
+func NewDirBuilder(
+	dir string,
+	compressor bptar.Compressor,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) DirBuilder {
+	return DirBuilder{
+		dir: dir,
+
+		compressor: compressor,
+		fs:         fs,
+		logger:     logger,
+	}
+}
+
+// Build creates tarballs for all jobs and packages in a temporary directory.
+// Job and package versions are their fingerprints.
+func (b DirBuilder) Build(name, version string) (BuiltRelease, error) {
+	built := BuiltRelease{
+		JobTarPaths:     map[string]string{},
+		PackageTarPaths: map[string]string{},
+	}
+
+	path, err := b.fs.TempDir("release-DirBuilder")
+	if err != nil {
+		return built, bosherr.WrapError(err, "Creating build directory")
+	}
+
+	built.Path = path
+
+	err = b.build(name, version, &built)
+	if err != nil {
+		cleanUpErr := b.CleanUp(built)
+		if cleanUpErr != nil {
+			b.logger.Debug(dirBuilderLogTag, "Failed to clean up build directory %v", cleanUpErr)
+		}
+
+		return built, err
+	}
+
+	return built, nil
+}
+
+func (b DirBuilder) CleanUp(built BuiltRelease) error {
+	return b.fs.RemoveAll(built.Path)
+}
+
+func (b DirBuilder) build(name, version string, built *BuiltRelease) error {
+	manRelease := &built.Manifest.Release
+
+	manRelease.Name = name
+	manRelease.Version = version
+	manRelease.CommitHash = b.commitHash()
+
+	blobs, err := b.readBlobs()
+	if err != nil {
+		return err
+	}
+
+	srcFiles, err := b.listFiles(filepath.Join(b.dir, "src"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing src/ directory")
+	}
+
+	blobFiles, err := b.listFiles(filepath.Join(b.dir, "blobs"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing blobs/ directory")
+	}
+
+	pkgNames, err := b.listNames("packages")
+	if err != nil {
+		return err
+	}
+
+	for _, pkgName := range pkgNames {
+		manPkg, tarPath, err := b.buildPackage(pkgName, srcFiles, blobFiles, blobs, built.Path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Building package %s", pkgName)
+		}
+
+		manRelease.Packages = append(manRelease.Packages, manPkg)
+		built.PackageTarPaths[pkgName] = tarPath
+	}
+
+	// Catch missing and circular dependencies before building jobs
+	var release Release
+
+	err = release.populateFromManifest(built.Manifest)
+	if err != nil {
+		return err
+	}
+
+	jobNames, err := b.listNames("jobs")
+	if err != nil {
+		return err
+	}
+
+	for _, jobName := range jobNames {
+		manJob, tarPath, err := b.buildJob(jobName, built.PackageTarPaths, built.Path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Building job %s", jobName)
+		}
+
+		manRelease.Jobs = append(manRelease.Jobs, manJob)
+		built.JobTarPaths[jobName] = tarPath
+	}
+
+	return nil
+}
+
+func (b DirBuilder) buildPackage(
+	name string,
+	srcFiles, blobFiles map[string]archiveFile,
+	blobs map[string]blobRecord,
+	buildPath string,
+) (bprelman.Package, string, error) {
+	var manPkg bprelman.Package
+
+	pkgDir := filepath.Join(b.dir, "packages", name)
+
+	specBytes, err := b.fs.ReadFile(filepath.Join(pkgDir, "spec"))
+	if err != nil {
+		return manPkg, "", bosherr.WrapError(err, "Reading spec")
+	}
+
+	var spec packageSpec
+
+	err = candiedyaml.Unmarshal(specBytes, &spec)
+	if err != nil {
+		return manPkg, "", bosherr.WrapError(err, "Parsing spec")
+	}
+
+	if spec.Name != name {
+		return manPkg, "", bosherr.Errorf(
+			"Expected spec name '%s' to match package directory name", spec.Name)
+	}
+
+	packagingFile, err := b.newArchiveFile(filepath.Join(pkgDir, "packaging"), "packaging")
+	if err != nil {
+		return manPkg, "", bosherr.WrapError(err, "Finding packaging script")
+	}
+
+	files, err := b.matchPackageFiles(spec, srcFiles, blobFiles, blobs)
+	if err != nil {
+		return manPkg, "", err
+	}
+
+	files = append(files, packagingFile)
+
+	fingerprint, err := fingerprintFiles(files, spec.Dependencies, b.fs)
+	if err != nil {
+		return manPkg, "", bosherr.WrapError(err, "Fingerprinting")
+	}
+
+	tarPath := filepath.Join(buildPath, "packages", name+".tgz")
+
+	sha1, err := b.compressFiles(files, tarPath)
+	if err != nil {
+		return manPkg, "", err
+	}
+
+	manPkg = bprelman.Package{
+		Name: name,
+
+		VersionRaw:     fingerprint,
+		Version:        fingerprint,
+		FingerprintRaw: fingerprint,
+		Fingerprint:    fingerprint,
+		SHA1Raw:        sha1,
+		SHA1:           sha1,
+	}
+
+	for _, depName := range spec.Dependencies {
+		manPkg.DependencyNames = append(manPkg.DependencyNames, bprelman.DependencyName(depName))
+	}
+
+	return manPkg, tarPath, nil
+}
+
+// matchPackageFiles finds files specified by package spec globs.
+// Files from src/ take precedence over blobs with the same path.
+// Blobs listed in config/blobs.yml must be present in blobs/.
+func (b DirBuilder) matchPackageFiles(
+	spec packageSpec,
+	srcFiles, blobFiles map[string]archiveFile,
+	blobs map[string]blobRecord,
+) ([]archiveFile, error) {
+	var files []archiveFile
+
+	matchedPaths := map[string]struct{}{}
+
+	excluded, err := b.globsMatcher(spec.ExcludedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, glob := range spec.Files {
+		globRegexp, err := newGlobRegexp(glob)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+
+		// Blobs that were not downloaded yet are only known from config/blobs.yml
+		for _, path := range b.sortedPaths(srcFiles, blobFiles, blobs) {
+			if !globRegexp.MatchString(path) || excluded(path) {
+				continue
+			}
+
+			found = true
+
+			if _, ok := matchedPaths[path]; ok {
+				continue
+			}
+
+			matchedPaths[path] = struct{}{}
+
+			if file, ok := srcFiles[path]; ok {
+				files = append(files, file)
+				continue
+			}
+
+			file, ok := blobFiles[path]
+			if !ok {
+				return nil, bosherr.Errorf(
+					"Expected blob '%s' to be downloaded into blobs/ directory", path)
+			}
+
+			if blob, ok := blobs[path]; ok && blob.SHA != "" {
+				digest, err := bputil.NewDigestFromString(blob.SHA)
+				if err != nil {
+					return nil, bosherr.WrapErrorf(err, "Parsing blob '%s' digest", path)
+				}
+
+				err = digest.Verify(file.SrcPath, b.fs)
+				if err != nil {
+					return nil, bosherr.WrapErrorf(err, "Verifying blob '%s'", path)
+				}
+			}
+
+			files = append(files, file)
+		}
+
+		if !found {
+			return nil, bosherr.Errorf("Expected to find files matching '%s' in src/ or blobs/", glob)
+		}
+	}
+
+	return files, nil
+}
+
+func (b DirBuilder) buildJob(name string, pkgTarPaths map[string]string, buildPath string) (bprelman.Job, string, error) {
+	var manJob bprelman.Job
+
+	jobDir := filepath.Join(b.dir, "jobs", name)
+	specPath := filepath.Join(jobDir, "spec")
+
+	specBytes, err := b.fs.ReadFile(specPath)
+	if err != nil {
+		return manJob, "", bosherr.WrapError(err, "Reading spec")
+	}
+
+	spec, err := bpreljobman.NewManifestFromBytes(specBytes)
+	if err != nil {
+		return manJob, "", bosherr.WrapError(err, "Parsing spec")
+	}
+
+	if spec.Job.Name != name {
+		return manJob, "", bosherr.Errorf(
+			"Expected spec name '%s' to match job directory name", spec.Job.Name)
+	}
+
+	for _, pkgName := range spec.Job.PackageNames {
+		if _, found := pkgTarPaths[pkgName]; !found {
+			return manJob, "", bosherr.Errorf("Expected to find package '%s'", pkgName)
+		}
+	}
+
+	// Spec is fingerprinted as 'spec' but included into the tarball as 'job.MF'
+	specFile, err := b.newArchiveFile(specPath, "spec")
+	if err != nil {
+		return manJob, "", err
+	}
+
+	files := []archiveFile{specFile}
+
+	monitPath := filepath.Join(jobDir, "monit")
+
+	if b.fs.FileExists(monitPath) {
+		monitFile, err := b.newArchiveFile(monitPath, "monit")
+		if err != nil {
+			return manJob, "", err
+		}
+
+		files = append(files, monitFile)
+	}
+
+	for srcName := range spec.Job.TemplateNames {
+		templatePath := filepath.Join("templates", srcName)
+
+		templateFile, err := b.newArchiveFile(filepath.Join(jobDir, templatePath), templatePath)
+		if err != nil {
+			return manJob, "", bosherr.WrapErrorf(err, "Finding template %s", srcName)
+		}
+
+		files = append(files, templateFile)
+	}
+
+	fingerprint, err := fingerprintFiles(files, nil, b.fs)
+	if err != nil {
+		return manJob, "", bosherr.WrapError(err, "Fingerprinting")
+	}
+
+	tarPath := filepath.Join(buildPath, "jobs", name+".tgz")
+
+	tarFiles := append([]archiveFile{}, files...)
+	tarFiles[0].Path = "job.MF"
+
+	sha1, err := b.compressFiles(tarFiles, tarPath)
+	if err != nil {
+		return manJob, "", err
+	}
+
+	manJob = bprelman.Job{
+		Name: name,
+
+		VersionRaw:     fingerprint,
+		Version:        fingerprint,
+		FingerprintRaw: fingerprint,
+		Fingerprint:    fingerprint,
+		SHA1Raw:        sha1,
+		SHA1:           sha1,
+	}
+
+	return manJob, tarPath, nil
+}
+
+// compressFiles creates tarball with given files and returns its SHA1
+func (b DirBuilder) compressFiles(files []archiveFile, dstPath string) (string, error) {
+	stagePath, err := b.fs.TempDir("release-DirBuilder-stage")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating staging directory")
+	}
+
+	defer b.fs.RemoveAll(stagePath)
+
+	for _, file := range files {
+		path := filepath.Join(stagePath, file.Path)
+
+		err := b.fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Creating directory for %s", file.Path)
+		}
+
+		err = b.fs.CopyFile(file.SrcPath, path)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Copying %s", file.Path)
+		}
+
+		err = b.fs.Chmod(path, file.Mode.Perm())
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Changing permissions of %s", file.Path)
+		}
+	}
+
+	tarPath, err := b.compressor.Compress(stagePath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Compressing")
+	}
+
+	err = b.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating tarball directory")
+	}
+
+	err = b.fs.Rename(tarPath, dstPath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Moving tarball")
+	}
+
+	digest, err := bputil.NewDigestFromFile(bputil.DigestAlgorithmSHA1, dstPath, b.fs)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Calculating tarball SHA1")
+	}
+
+	return digest.Value, nil
+}
+
+func (b DirBuilder) newArchiveFile(srcPath, path string) (archiveFile, error) {
+	file, err := b.fs.OpenFile(srcPath, os.O_RDONLY, 0)
+	if err != nil {
+		return archiveFile{}, bosherr.WrapErrorf(err, "Opening %s", srcPath)
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return archiveFile{}, bosherr.WrapErrorf(err, "Checking %s", srcPath)
+	}
+
+	return archiveFile{SrcPath: srcPath, Path: path, Mode: info.Mode()}, nil
+}
+
+// listFiles returns all regular files in a directory keyed by their relative paths
+func (b DirBuilder) listFiles(dir string) (map[string]archiveFile, error) {
+	files := map[string]archiveFile{}
+
+	if !b.fs.FileExists(dir) {
+		return files, nil
+	}
+
+	err := b.fs.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		relPath = filepath.ToSlash(relPath)
+
+		files[relPath] = archiveFile{SrcPath: path, Path: relPath, Mode: info.Mode()}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// listNames returns names of jobs or packages that include spec files
+func (b DirBuilder) listNames(kind string) ([]string, error) {
+	var names []string
+
+	specPaths, err := b.fs.Glob(filepath.Join(b.dir, kind, "*", "spec"))
+	if err != nil {
+		return names, bosherr.WrapErrorf(err, "Globbing %s/ directory", kind)
+	}
+
+	for _, specPath := range specPaths {
+		names = append(names, filepath.Base(filepath.Dir(specPath)))
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (b DirBuilder) readBlobs() (map[string]blobRecord, error) {
+	blobs := map[string]blobRecord{}
+
+	path := filepath.Join(b.dir, "config", "blobs.yml")
+
+	if !b.fs.FileExists(path) {
+		return blobs, nil
+	}
+
+	bytes, err := b.fs.ReadFile(path)
+	if err != nil {
+		return blobs, bosherr.WrapError(err, "Reading config/blobs.yml")
+	}
+
+	err = candiedyaml.Unmarshal(bytes, &blobs)
+	if err != nil {
+		return blobs, bosherr.WrapError(err, "Parsing config/blobs.yml")
+	}
+
+	return blobs, nil
+}
+
+func (b DirBuilder) globsMatcher(globs []string) (func(string) bool, error) {
+	var matchers []func(string) bool
+
+	for _, glob := range globs {
+		globRegexp, err := newGlobRegexp(glob)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, globRegexp.MatchString)
+	}
+
+	return func(path string) bool {
+		for _, matcher := range matchers {
+			if matcher(path) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func (b DirBuilder) sortedPaths(srcFiles, blobFiles map[string]archiveFile, blobs map[string]blobRecord) []string {
+	uniquePaths := map[string]struct{}{}
+
+	for path := range srcFiles {
+		uniquePaths[path] = struct{}{}
+	}
+
+	for path := range blobFiles {
+		uniquePaths[path] = struct{}{}
+	}
+
+	for path := range blobs {
+		uniquePaths[path] = struct{}{}
+	}
+
+	var paths []string
+
+	for path := range uniquePaths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// commitHash returns abbreviated commit hash of checked out git revision;
+// 'non-git' if release directory is not a git repository (same as bosh_cli).
+func (b DirBuilder) commitHash() string {
+	head, err := b.fs.ReadFileString(filepath.Join(b.dir, ".git", "HEAD"))
+	if err != nil {
+		return dirBuilderNonGitCommitHash
+	}
+
+	hash := strings.TrimSpace(head)
+
+	if strings.HasPrefix(hash, "ref: ") {
+		hash = b.resolveGitRef(strings.TrimPrefix(hash, "ref: "))
+	}
+
+	if len(hash) > 8 {
+		hash = hash[:8]
+	}
+
+	return hash
+}
+
+func (b DirBuilder) resolveGitRef(ref string) string {
+	hash, err := b.fs.ReadFileString(filepath.Join(b.dir, ".git", ref))
+	if err == nil {
+		return strings.TrimSpace(hash)
+	}
+
+	// Refs might have been packed by git gc
+	packedRefs, err := b.fs.ReadFileString(filepath.Join(b.dir, ".git", "packed-refs"))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(packedRefs, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) == 2 && parts[1] == ref {
+			return parts[0]
+		}
+	}
+
+	return ""
+}
+
+/*
+Example of BOSH release source directory:
+
+$ tree ~/workspace/room101-release
+~/workspace/room101-release
+├── config
+│   ├── blobs.yml
+│   └── final.yml
+├── blobs
+│   └── golang
+│       └── go1.2.linux-amd64.tar.gz
+├── jobs
+│   └── warden
+│       ├── monit
+│       ├── spec
+│       └── templates
+│           └── warden_ctl.erb
+├── packages
+│   ├── golang_1.2
+│   │   ├── packaging
+│   │   └── spec
+│   └── warden-linux
+│       ├── packaging
+│       └── spec
+└── src
+    └── warden-linux
+        └── main.go
+*/
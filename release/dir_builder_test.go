@@ -0,0 +1,209 @@
+package release_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release"
+	bptar "github.com/cppforlife/bosh-provisioner/tar"
+)
+
+var _ = Describe("DirBuilder", func() {
+	var (
+		dir     string
+		fs      boshsys.FileSystem
+		builder DirBuilder
+	)
+
+	writeFile := func(path, content string, mode os.FileMode) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), mode)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+
+		dir, err = ioutil.TempDir("", "dir-builder-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		runner := boshsys.NewExecCmdRunner(logger)
+		builder = NewDirBuilder(dir, bptar.NewCmdCompressor(runner, fs, logger), fs, logger)
+
+		writeFile("packages/golang/spec", "name: golang\nfiles: [golang/*.tar.gz]\n", 0644)
+		writeFile("packages/golang/packaging", "tar xzf golang/*", 0644)
+		writeFile("blobs/golang/go.tar.gz", "go-blob", 0644)
+
+		writeFile("packages/warden/spec", `
+name: warden
+dependencies: [golang]
+files:
+- warden/**/*
+excluded_files:
+- warden/**/*_test.go
+`, 0644)
+		writeFile("packages/warden/packaging", "go build", 0755)
+		writeFile("src/warden/main.go", "package main", 0644)
+		writeFile("src/warden/lib/lib.go", "package lib", 0644)
+		writeFile("src/warden/lib/lib_test.go", "package lib", 0644)
+
+		writeFile("jobs/warden/spec", `
+name: warden
+templates: {warden_ctl.erb: bin/warden_ctl}
+packages: [warden]
+`, 0644)
+		writeFile("jobs/warden/monit", "check process warden", 0644)
+		writeFile("jobs/warden/templates/warden_ctl.erb", "#!/bin/bash", 0644)
+
+		writeFile(".git/HEAD", "ref: refs/heads/master\n", 0644)
+		writeFile(".git/packed-refs", "# pack-refs\n0123456789abcdef refs/heads/master\n", 0644)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Build", func() {
+		var built BuiltRelease
+
+		BeforeEach(func() {
+			built = BuiltRelease{}
+		})
+
+		AfterEach(func() {
+			if built.Path != "" {
+				Expect(builder.CleanUp(built)).To(Succeed())
+			}
+		})
+
+		It("builds job and package tarballs versioned by their fingerprints", func() {
+			var err error
+
+			built, err = builder.Build("room101", "0+dev.1")
+			Expect(err).ToNot(HaveOccurred())
+
+			rel := built.Manifest.Release
+			Expect(rel.Name).To(Equal("room101"))
+			Expect(rel.Version).To(Equal("0+dev.1"))
+			Expect(rel.CommitHash).To(Equal("01234567"))
+
+			Expect(rel.Packages).To(HaveLen(2))
+			Expect(rel.Packages[0].Name).To(Equal("golang"))
+			Expect(rel.Packages[1].Name).To(Equal("warden"))
+			Expect(rel.Packages[1].DependencyNames).To(HaveLen(1))
+			Expect(string(rel.Packages[1].DependencyNames[0])).To(Equal("golang"))
+
+			Expect(rel.Jobs).To(HaveLen(1))
+			Expect(rel.Jobs[0].Name).To(Equal("warden"))
+
+			for _, pkg := range rel.Packages {
+				Expect(pkg.Fingerprint).To(HaveLen(40))
+				Expect(pkg.Version).To(Equal(pkg.Fingerprint))
+				Expect(pkg.SHA1).To(HaveLen(40))
+				Expect(fs.FileExists(built.PackageTarPaths[pkg.Name])).To(BeTrue())
+			}
+
+			Expect(rel.Jobs[0].Version).To(Equal(rel.Jobs[0].Fingerprint))
+			Expect(fs.FileExists(built.JobTarPaths["warden"])).To(BeTrue())
+		})
+
+		It("produces same fingerprints for unchanged directory", func() {
+			first, err := builder.Build("room101", "0+dev.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.CleanUp(first)).To(Succeed())
+
+			built, err = builder.Build("room101", "0+dev.2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(built.Manifest.Release.Packages[1].Fingerprint).To(
+				Equal(first.Manifest.Release.Packages[1].Fingerprint))
+		})
+
+		It("changes package fingerprint when its file mode changes", func() {
+			first, err := builder.Build("room101", "0+dev.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.CleanUp(first)).To(Succeed())
+
+			Expect(os.Chmod(filepath.Join(dir, "src/warden/main.go"), 0755)).To(Succeed())
+
+			built, err = builder.Build("room101", "0+dev.2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(built.Manifest.Release.Packages[1].Fingerprint).ToNot(
+				Equal(first.Manifest.Release.Packages[1].Fingerprint))
+		})
+
+		It("ignores excluded files", func() {
+			first, err := builder.Build("room101", "0+dev.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.CleanUp(first)).To(Succeed())
+
+			writeFile("src/warden/lib/other_test.go", "package lib", 0644)
+
+			built, err = builder.Build("room101", "0+dev.2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(built.Manifest.Release.Packages[1].Fingerprint).To(
+				Equal(first.Manifest.Release.Packages[1].Fingerprint))
+		})
+
+		It("verifies blobs against config/blobs.yml", func() {
+			writeFile("config/blobs.yml", "golang/go.tar.gz: {size: 7, object_id: abc, sha: 0000000000000000000000000000000000000000}\n", 0644)
+
+			_, err := builder.Build("room101", "0+dev.1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying blob 'golang/go.tar.gz'"))
+		})
+
+		It("returns error if blob listed in config/blobs.yml was not downloaded", func() {
+			writeFile("config/blobs.yml", "golang/go1.2.tar.gz: {size: 7, object_id: abc, sha: abc}\n", 0644)
+
+			_, err := builder.Build("room101", "0+dev.1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected blob 'golang/go1.2.tar.gz' to be downloaded into blobs/ directory"))
+		})
+
+		It("returns error if package files cannot be found", func() {
+			writeFile("packages/golang/spec", "name: golang\nfiles: [golang/*.zip]\n", 0644)
+
+			_, err := builder.Build("room101", "0+dev.1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find files matching 'golang/*.zip'"))
+		})
+
+		It("returns error if package depends on unknown package", func() {
+			writeFile("packages/golang/spec", "name: golang\ndependencies: [gcc]\nfiles: [golang/*.tar.gz]\n", 0644)
+
+			_, err := builder.Build("room101", "0+dev.1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected package 'golang' dependency 'gcc' to be present in the release"))
+		})
+
+		It("returns error if package dependencies are circular", func() {
+			writeFile("packages/golang/spec", "name: golang\ndependencies: [warden]\nfiles: [golang/*.tar.gz]\n", 0644)
+
+			_, err := builder.Build("room101", "0+dev.1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("golang -> warden -> golang"))
+		})
+
+		It("returns error if job template is missing", func() {
+			Expect(os.Remove(filepath.Join(dir, "jobs/warden/templates/warden_ctl.erb"))).To(Succeed())
+
+			_, err := builder.Build("room101", "0+dev.1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Finding template warden_ctl.erb"))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package release
 
 import (
 	"path/filepath"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -41,19 +42,29 @@ func NewDirReader(
 func (r DirReader) Read() (Release, error) {
 	var release Release
 
-	// e.g. room101-0+dev.16.yml
-	manifestFileName := r.releaseName + "-" + r.releaseVersion + ".yml"
+	devIndex := newReleaseIndex(filepath.Join(r.dir, "dev_releases"), r.releaseName, r.fs)
+	finalIndex := newReleaseIndex(filepath.Join(r.dir, "releases"), r.releaseName, r.fs)
 
-	// bosh_cli now places release manifests into sub-directories named after a release
-	oldManifestPath := filepath.Join(r.dir, "dev_releases", manifestFileName)
-	migratedManifestPath := filepath.Join(r.dir, "dev_releases", r.releaseName, manifestFileName)
+	version := r.releaseVersion
 
-	manifestPath := r.pathThatExistsOrEmpty(oldManifestPath, migratedManifestPath)
+	if version == LatestVersion {
+		latestVersion, err := r.latestVersion(devIndex, finalIndex)
+		if err != nil {
+			return release, bosherr.WrapError(err, "Resolving latest version")
+		}
+
+		r.logger.Debug(dirReaderLogTag, "Resolved latest version to %s", latestVersion)
+
+		version = latestVersion
+	}
+
+	manifestPaths := append(devIndex.ManifestPaths(version), finalIndex.ManifestPaths(version)...)
+
+	manifestPath := r.pathThatExistsOrEmpty(manifestPaths...)
 	if len(manifestPath) == 0 {
 		return release, bosherr.Errorf(
-			"Manifest not found at '%s' or '%s'",
-			oldManifestPath,
-			migratedManifestPath,
+			"Manifest not found at '%s'",
+			strings.Join(manifestPaths, "' or '"),
 		)
 	}
 
@@ -69,52 +80,99 @@ func (r DirReader) Read() (Release, error) {
 
 	r.logger.Debug(dirReaderLogTag, "Done building manifest %#v", manifest)
 
-	release.populateFromManifest(manifest)
+	err = release.populateFromManifest(manifest)
+	if err != nil {
+		closeErr := r.Close()
+		if closeErr != nil {
+			r.logger.Debug(dirReaderLogTag, "Failed to close release %v", closeErr)
+		}
+
+		return release, bosherr.WrapError(err, "Building release")
+	}
 
-	r.populateReleaseTarPaths(&release)
+	err = r.populateReleaseTarPaths(&release)
+	if err != nil {
+		closeErr := r.Close()
+		if closeErr != nil {
+			r.logger.Debug(dirReaderLogTag, "Failed to close release %v", closeErr)
+		}
+
+		return release, bosherr.WrapError(err, "Fetching final builds")
+	}
 
 	return release, nil
 }
 
+// latestVersion returns the greatest dev or final release version
+func (r DirReader) latestVersion(devIndex, finalIndex releaseIndex) (string, error) {
+	devVersions, err := devIndex.Versions()
+	if err != nil {
+		return "", err
+	}
+
+	finalVersions, err := finalIndex.Versions()
+	if err != nil {
+		return "", err
+	}
+
+	version := MaxVersion(append(devVersions, finalVersions...))
+	if version == "" {
+		return "", bosherr.Errorf("Expected to find at least one dev or final release '%s'", r.releaseName)
+	}
+
+	return version, nil
+}
+
 func (r DirReader) Close() error {
 	// Caller owns release directory; hence, nothing to clean up
 	return nil
 }
 
 // populateReleaseTarPaths sets TarPath for each job/package in the release.
-func (r DirReader) populateReleaseTarPaths(release *Release) {
-	devBuilds := filepath.Join(r.dir, ".dev_builds")
-	finalBuilds := filepath.Join(r.dir, ".final_builds")
+// Final builds missing locally are downloaded from the final blobstore.
+func (r DirReader) populateReleaseTarPaths(release *Release) error {
+	finalBuilds := newFinalBuilds(r.dir, r.fs, r.logger)
 
-	for i, job := range release.Jobs {
-		fileName := job.Fingerprint + ".tgz"
+	tarPath := func(kind, name, fingerprint string) (string, error) {
+		devPath := filepath.Join(r.dir, ".dev_builds", kind, name, fingerprint+".tgz")
 
-		devPath := filepath.Join(devBuilds, "jobs", job.Name, fileName)
-		finalPath := filepath.Join(finalBuilds, "jobs", job.Name, fileName)
+		if r.fs.FileExists(devPath) {
+			return devPath, nil
+		}
 
-		release.Jobs[i].TarPath = r.pathThatExistsOrEmpty(devPath, finalPath)
+		return finalBuilds.Fetch(kind, name, fingerprint)
 	}
 
-	for _, pkg := range release.Packages {
-		fileName := pkg.Fingerprint + ".tgz"
+	for i, job := range release.Jobs {
+		path, err := tarPath("jobs", job.Name, job.Fingerprint)
+		if err != nil {
+			return err
+		}
 
-		devPath := filepath.Join(devBuilds, "packages", pkg.Name, fileName)
-		finalPath := filepath.Join(finalBuilds, "packages", pkg.Name, fileName)
+		release.Jobs[i].TarPath = path
+	}
+
+	for _, pkg := range release.Packages {
+		path, err := tarPath("packages", pkg.Name, pkg.Fingerprint)
+		if err != nil {
+			return err
+		}
 
-		pkg.TarPath = r.pathThatExistsOrEmpty(devPath, finalPath)
+		pkg.TarPath = path
 	}
+
+	return nil
 }
 
-// pathThatExists returns first path that exists on the file system.
-func (r DirReader) pathThatExistsOrEmpty(firstPath, secondPath string) string {
-	switch {
-	case r.fs.FileExists(firstPath):
-		return firstPath
-	case r.fs.FileExists(secondPath):
-		return secondPath
-	default:
-		return ""
+// pathThatExistsOrEmpty returns first path that exists on the file system.
+func (r DirReader) pathThatExistsOrEmpty(paths ...string) string {
+	for _, path := range paths {
+		if r.fs.FileExists(path) {
+			return path
+		}
 	}
+
+	return ""
 }
 
 /*
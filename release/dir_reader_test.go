@@ -0,0 +1,289 @@
+package release_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release"
+)
+
+var _ = Describe("DirReader", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		logger boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	writeManifest := func(path, version string) {
+		fs.WriteFileString(path, "name: room101\nversion: "+version+"\ncommit_hash: abc123\n")
+	}
+
+	Describe("Read", func() {
+		It("reads release with exact version", func() {
+			writeManifest("/rel/dev_releases/room101/room101-0+dev.1.yml", "0+dev.1")
+
+			release, err := NewDirReader("room101", "0+dev.1", "/rel", fs, logger).Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Version).To(Equal("0+dev.1"))
+		})
+
+		It("resolves latest version from dev releases index", func() {
+			fs.WriteFileString("/rel/dev_releases/room101/index.yml", `
+builds:
+  uuid-1: {version: 0+dev.9}
+  uuid-2: {version: 0+dev.10}
+format-version: "2"
+`)
+			writeManifest("/rel/dev_releases/room101/room101-0+dev.10.yml", "0+dev.10")
+
+			release, err := NewDirReader("room101", "latest", "/rel", fs, logger).Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Version).To(Equal("0+dev.10"))
+		})
+
+		It("resolves latest version from final releases index if it is greater", func() {
+			fs.WriteFileString("/rel/dev_releases/index.yml", "builds:\n  uuid-1: {version: 2+dev.1}\n")
+			fs.WriteFileString("/rel/releases/room101/index.yml", "builds:\n  uuid-2: {version: 3}\n")
+			writeManifest("/rel/releases/room101/room101-3.yml", "3")
+
+			release, err := NewDirReader("room101", "latest", "/rel", fs, logger).Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Version).To(Equal("3"))
+		})
+
+		It("returns error if package depends on package missing from the release", func() {
+			fs.WriteFileString("/rel/dev_releases/room101/room101-0+dev.1.yml", `
+name: room101
+version: 0+dev.1
+commit_hash: abc123
+packages:
+- name: warden
+  version: fake-version
+  fingerprint: fake-fingerprint
+  sha1: fake-sha1
+  dependencies: [golang]
+`)
+
+			_, err := NewDirReader("room101", "0+dev.1", "/rel", fs, logger).Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected package 'warden' dependency 'golang' to be present in the release"))
+		})
+
+		It("returns error if there are no releases to resolve latest version", func() {
+			_, err := NewDirReader("room101", "latest", "/rel", fs, logger).Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find at least one dev or final release 'room101'"))
+		})
+	})
+
+	Describe("Read with final builds", func() {
+		var (
+			dir   string
+			osFS  boshsys.FileSystem
+			jobFP = "fake-job-fingerprint"
+		)
+
+		writeFile := func(path, content string) {
+			path = filepath.Join(dir, path)
+			Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			osFS = boshsys.NewOsFileSystem(logger)
+
+			var err error
+
+			dir, err = ioutil.TempDir("", "dir-reader-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			writeFile("releases/room101/room101-3.yml", `
+name: room101
+version: 3
+commit_hash: abc123
+jobs:
+- name: warden
+  version: fake-job-fingerprint
+  fingerprint: fake-job-fingerprint
+  sha1: `+sha1Of("job-tarball")+`
+`)
+
+			writeFile(".final_builds/jobs/warden/index.yml", `
+builds:
+  fake-job-fingerprint:
+    version: fake-job-fingerprint
+    blobstore_id: fake-blob-id
+    sha1: `+sha1Of("job-tarball")+`
+format-version: "2"
+`)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		read := func() (Release, error) {
+			return NewDirReader("room101", "3", dir, osFS, logger).Read()
+		}
+
+		finalPath := func() string {
+			return filepath.Join(dir, ".final_builds/jobs/warden", jobFP+".tgz")
+		}
+
+		It("uses final build if it is present locally", func() {
+			writeFile(".final_builds/jobs/warden/"+jobFP+".tgz", "job-tarball")
+			writeFile("config/final.yml", "blobstore: {provider: unknown}\n")
+
+			release, err := read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Jobs[0].TarPath).To(Equal(finalPath()))
+		})
+
+		It("downloads missing final build from local final blobstore", func() {
+			writeFile("config/final.yml", "blobstore: {provider: local, options: {blobstore_path: blobs-dir}}\n")
+			writeFile("blobs-dir/fake-blob-id", "job-tarball")
+
+			release, err := read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Jobs[0].TarPath).To(Equal(finalPath()))
+
+			content, err := osFS.ReadFileString(finalPath())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("job-tarball"))
+		})
+
+		It("downloads missing final build from dav final blobstore with credentials from private.yml", func() {
+			sum := sha1.Sum([]byte("fake-blob-id"))
+			blobPath := "/blobs/" + hex.EncodeToString(sum[:])[0:2] + "/fake-blob-id"
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				user, password, _ := req.BasicAuth()
+				if req.URL.Path != blobPath || user != "fake-user" || password != "fake-password" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.Write([]byte("job-tarball"))
+			}))
+
+			defer server.Close()
+
+			writeFile("config/final.yml", "blobstore: {provider: dav, options: {endpoint: '"+server.URL+"/blobs'}}\n")
+			writeFile("config/private.yml", "blobstore: {options: {user: fake-user, password: fake-password}}\n")
+
+			release, err := read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Jobs[0].TarPath).To(Equal(finalPath()))
+		})
+
+		It("downloads missing final build from s3 final blobstore", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/fake-bucket/fake-blob-id" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.Write([]byte("job-tarball"))
+			}))
+
+			defer server.Close()
+
+			writeFile("config/final.yml", `
+blobstore:
+  provider: s3
+  options:
+    bucket_name: fake-bucket
+    host: `+server.Listener.Addr().String()+`
+    use_ssl: false
+`)
+
+			release, err := read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Jobs[0].TarPath).To(Equal(finalPath()))
+		})
+
+		It("downloads missing final build from s3 final blobstore signing requests with credentials from private.yml", func() {
+			authRegexp := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=fake-key-id/\d{8}/fake-region/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/fake-bucket/fake-blob-id" || !authRegexp.MatchString(req.Header.Get("Authorization")) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				w.Write([]byte("job-tarball"))
+			}))
+
+			defer server.Close()
+
+			writeFile("config/final.yml", `
+blobstore:
+  provider: s3
+  options:
+    bucket_name: fake-bucket
+    host: `+server.Listener.Addr().String()+`
+    use_ssl: false
+    region: fake-region
+`)
+			writeFile("config/private.yml", "blobstore: {options: {access_key_id: fake-key-id, secret_access_key: fake-secret}}\n")
+
+			release, err := read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Jobs[0].TarPath).To(Equal(finalPath()))
+		})
+
+		It("returns error if s3 final blobstore credentials are incomplete", func() {
+			writeFile("config/final.yml", "blobstore: {provider: s3, options: {bucket_name: fake-bucket}}\n")
+			writeFile("config/private.yml", "blobstore: {options: {access_key_id: fake-key-id}}\n")
+
+			_, err := read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected s3 final blobstore to specify both 'access_key_id' and 'secret_access_key'"))
+		})
+
+		It("returns error and does not keep final build if downloaded blob does not match recorded SHA1", func() {
+			writeFile("config/final.yml", "blobstore: {provider: local, options: {blobstore_path: blobs-dir}}\n")
+			writeFile("blobs-dir/fake-blob-id", "other-tarball")
+
+			_, err := read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Downloading final jobs/warden"))
+			Expect(osFS.FileExists(finalPath())).To(BeFalse())
+		})
+
+		It("returns error if blob cannot be downloaded", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			defer server.Close()
+
+			writeFile("config/final.yml", "blobstore: {provider: dav, options: {endpoint: '"+server.URL+"'}}\n")
+
+			_, err := read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("404"))
+		})
+
+		It("returns error if final blobstore is not configured", func() {
+			_, err := read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected config/final.yml to specify final blobstore"))
+		})
+	})
+})
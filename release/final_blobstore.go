@@ -0,0 +1,378 @@
+package release
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// finalBlobstore fetches final job and package tarballs
+// uploaded by 'bosh finalize release' into release's blobstore.
+type finalBlobstore interface {
+	Get(blobID, dstPath string) error
+}
+
+type finalBlobstoreConfig struct {
+	Provider string                `yaml:"provider"`
+	Options  finalBlobstoreOptions `yaml:"options"`
+}
+
+type finalBlobstoreOptions struct {
+	// local
+	BlobstorePath string `yaml:"blobstore_path"`
+
+	// dav
+	Endpoint string `yaml:"endpoint"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+
+	// s3
+	BucketName string `yaml:"bucket_name"`
+	Host       string `yaml:"host"`
+	Port       int    `yaml:"port"`
+	UseSSL     *bool  `yaml:"use_ssl"`
+	Region     string `yaml:"region"`
+
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+}
+
+type finalBlobstoreConfigFile struct {
+	Blobstore finalBlobstoreConfig `yaml:"blobstore"`
+}
+
+// newFinalBlobstore returns blobstore configured in config/final.yml;
+// credentials are taken from config/private.yml if it is present.
+func newFinalBlobstore(dir string, fs boshsys.FileSystem) (finalBlobstore, error) {
+	finalConfig, err := readFinalBlobstoreConfig(filepath.Join(dir, "config", "final.yml"), fs)
+	if err != nil {
+		return nil, err
+	}
+
+	privateConfig, err := readFinalBlobstoreConfig(filepath.Join(dir, "config", "private.yml"), fs)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := finalConfig.Options
+
+	if privateConfig.Options.User != "" {
+		opts.User = privateConfig.Options.User
+		opts.Password = privateConfig.Options.Password
+	}
+
+	if privateConfig.Options.AccessKeyID != "" {
+		opts.AccessKeyID = privateConfig.Options.AccessKeyID
+		opts.SecretAccessKey = privateConfig.Options.SecretAccessKey
+	}
+
+	switch finalConfig.Provider {
+	case "local":
+		if opts.BlobstorePath == "" {
+			return nil, bosherr.Error("Expected local final blobstore to specify 'blobstore_path'")
+		}
+
+		path := opts.BlobstorePath
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		return localFinalBlobstore{path: path, fs: fs}, nil
+
+	case "dav":
+		if opts.Endpoint == "" {
+			return nil, bosherr.Error("Expected dav final blobstore to specify 'endpoint'")
+		}
+
+		return davFinalBlobstore{
+			endpoint: strings.TrimSuffix(opts.Endpoint, "/"),
+			user:     opts.User,
+			password: opts.Password,
+			fs:       fs,
+		}, nil
+
+	case "s3":
+		if opts.BucketName == "" {
+			return nil, bosherr.Error("Expected s3 final blobstore to specify 'bucket_name'")
+		}
+
+		if (opts.AccessKeyID == "") != (opts.SecretAccessKey == "") {
+			return nil, bosherr.Error("Expected s3 final blobstore to specify both 'access_key_id' and 'secret_access_key'")
+		}
+
+		region := opts.Region
+
+		if region == "" {
+			region = "us-east-1"
+		}
+
+		return s3FinalBlobstore{
+			endpoint:        opts.s3Endpoint(),
+			region:          region,
+			accessKeyID:     opts.AccessKeyID,
+			secretAccessKey: opts.SecretAccessKey,
+			fs:              fs,
+		}, nil
+
+	case "":
+		return nil, bosherr.Error("Expected config/final.yml to specify final blobstore")
+
+	default:
+		return nil, bosherr.Errorf("Unknown final blobstore provider '%s'", finalConfig.Provider)
+	}
+}
+
+func readFinalBlobstoreConfig(path string, fs boshsys.FileSystem) (finalBlobstoreConfig, error) {
+	var file finalBlobstoreConfigFile
+
+	if !fs.FileExists(path) {
+		return file.Blobstore, nil
+	}
+
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return file.Blobstore, bosherr.WrapErrorf(err, "Reading %s", path)
+	}
+
+	err = candiedyaml.Unmarshal(bytes, &file)
+	if err != nil {
+		return file.Blobstore, bosherr.WrapErrorf(err, "Parsing %s", path)
+	}
+
+	return file.Blobstore, nil
+}
+
+// s3Endpoint returns path-style bucket URL, e.g. https://s3.amazonaws.com/bucket
+func (o finalBlobstoreOptions) s3Endpoint() string {
+	scheme := "https"
+
+	if o.UseSSL != nil && !*o.UseSSL {
+		scheme = "http"
+	}
+
+	host := o.Host
+
+	if host == "" {
+		host = "s3.amazonaws.com"
+
+		if o.Region != "" {
+			host = "s3." + o.Region + ".amazonaws.com"
+		}
+	}
+
+	if o.Port > 0 {
+		host = fmt.Sprintf("%s:%d", host, o.Port)
+	}
+
+	return scheme + "://" + host + "/" + o.BucketName
+}
+
+type localFinalBlobstore struct {
+	path string
+	fs   boshsys.FileSystem
+}
+
+func (b localFinalBlobstore) Get(blobID, dstPath string) error {
+	err := b.fs.CopyFile(filepath.Join(b.path, blobID), dstPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying blob '%s'", blobID)
+	}
+
+	return nil
+}
+
+type davFinalBlobstore struct {
+	endpoint string
+	user     string
+	password string
+	fs       boshsys.FileSystem
+}
+
+// Get downloads blob from a location used by bosh dav blobstore clients,
+// e.g. http://endpoint/a4/<blob-id> where a4 is a prefix of SHA1 of blob ID
+func (b davFinalBlobstore) Get(blobID, dstPath string) error {
+	sum := sha1.Sum([]byte(blobID))
+	prefix := hex.EncodeToString(sum[:])[0:2]
+
+	url := b.endpoint + "/" + prefix + "/" + blobID
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building request for '%s'", url)
+	}
+
+	if b.user != "" {
+		req.SetBasicAuth(b.user, b.password)
+	}
+
+	return downloadFinalBlob(req, dstPath, b.fs)
+}
+
+// s3FinalBlobstore downloads blobs anonymously since final release
+// blobstores are expected to be publicly readable; requests are signed
+// (AWS Signature Version 4) when credentials are configured.
+type s3FinalBlobstore struct {
+	endpoint        string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	fs              boshsys.FileSystem
+}
+
+func (b s3FinalBlobstore) Get(blobID, dstPath string) error {
+	url := b.endpoint + "/" + blobID
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building request for '%s'", url)
+	}
+
+	if b.accessKeyID != "" {
+		b.sign(req, time.Now().UTC())
+	}
+
+	return downloadFinalBlob(req, dstPath, b.fs)
+}
+
+// sign adds Authorization header for a request without body
+// as described in AWS Signature Version 4 documentation for S3
+func (b s3FinalBlobstore) sign(req *http.Request, now time.Time) {
+	const (
+		algorithm     = "AWS4-HMAC-SHA256"
+		payloadHash   = "UNSIGNED-PAYLOAD"
+		signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	)
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + b.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := strings.Join([]string{
+		algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + b.secretAccessKey)
+
+	for _, part := range []string{date, b.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, b.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+const (
+	// finalBlobReadTimeout limits how long a download may stall;
+	// there is no limit on total duration since blobs may be large
+	finalBlobReadTimeout = 60 * time.Second
+)
+
+var finalBlobHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: finalBlobReadTimeout,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+func downloadFinalBlob(req *http.Request, dstPath string, fs boshsys.FileSystem) error {
+	url := req.URL.String()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	resp, err := finalBlobHTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Downloading '%s'", url)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return bosherr.Errorf("Expected to download '%s' but received status '%s'", url, resp.Status)
+	}
+
+	file, err := fs.OpenFile(dstPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating '%s'", dstPath)
+	}
+
+	defer file.Close()
+
+	body := &stallTimeoutReader{
+		reader: resp.Body,
+		timer:  time.AfterFunc(finalBlobReadTimeout, cancel),
+	}
+
+	defer body.timer.Stop()
+
+	_, err = io.Copy(file, body)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing '%s'", dstPath)
+	}
+
+	return nil
+}
+
+// stallTimeoutReader cancels download (via timer) when
+// no data was received within finalBlobReadTimeout
+type stallTimeoutReader struct {
+	reader io.Reader
+	timer  *time.Timer
+}
+
+func (r *stallTimeoutReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.timer.Reset(finalBlobReadTimeout)
+	return n, err
+}
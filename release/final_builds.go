@@ -0,0 +1,130 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+const finalBuildsLogTag = "finalBuilds"
+
+// finalBuilds keeps job and package tarballs of final releases
+// (e.g. .final_builds/packages/golang/<fingerprint>.tgz). Index files
+// reference blobs in the final blobstore configured in config/final.yml;
+// tarballs missing locally are downloaded and kept in .final_builds.
+type finalBuilds struct {
+	releaseDir string
+	fs         boshsys.FileSystem
+	logger     boshlog.Logger
+
+	// Lazily configured since most final builds are found locally
+	blobstore finalBlobstore
+}
+
+type finalBuildsIndexFile struct {
+	Builds map[string]finalBuildsIndexBuild `yaml:"builds"`
+}
+
+type finalBuildsIndexBuild struct {
+	BlobstoreID string `yaml:"blobstore_id"`
+	SHA1        string `yaml:"sha1"`
+}
+
+func newFinalBuilds(releaseDir string, fs boshsys.FileSystem, logger boshlog.Logger) *finalBuilds {
+	return &finalBuilds{releaseDir: releaseDir, fs: fs, logger: logger}
+}
+
+// Fetch returns path to a tarball with a given fingerprint, downloading it
+// from the final blobstore if necessary. Empty path is returned if the tarball
+// is not recorded in the final builds index.
+func (b *finalBuilds) Fetch(kind, name, fingerprint string) (string, error) {
+	path := filepath.Join(b.releaseDir, ".final_builds", kind, name, fingerprint+".tgz")
+
+	if b.fs.FileExists(path) {
+		return path, nil
+	}
+
+	file, err := b.readIndex(kind, name)
+	if err != nil {
+		return "", err
+	}
+
+	build, found := file.Builds[fingerprint]
+	if !found || build.BlobstoreID == "" {
+		return "", nil
+	}
+
+	if b.blobstore == nil {
+		b.blobstore, err = newFinalBlobstore(b.releaseDir, b.fs)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Configuring final blobstore")
+		}
+	}
+
+	b.logger.Debug(finalBuildsLogTag, "Downloading %s/%s from blob %s", kind, name, build.BlobstoreID)
+
+	err = b.download(build, path)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Downloading final %s/%s", kind, name)
+	}
+
+	return path, nil
+}
+
+// download fetches blob next to its final location and only moves it
+// into place after its SHA1 is verified so that partial downloads are never used.
+func (b *finalBuilds) download(build finalBuildsIndexBuild, path string) error {
+	digest, err := bputil.NewDigestFromString(build.SHA1)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing recorded SHA1")
+	}
+
+	err = b.fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating final builds directory")
+	}
+
+	downloadPath := path + ".download"
+
+	defer b.fs.RemoveAll(downloadPath)
+
+	err = b.blobstore.Get(build.BlobstoreID, downloadPath)
+	if err != nil {
+		return err
+	}
+
+	err = digest.Verify(downloadPath, b.fs)
+	if err != nil {
+		return err
+	}
+
+	return b.fs.Rename(downloadPath, path)
+}
+
+func (b *finalBuilds) readIndex(kind, name string) (finalBuildsIndexFile, error) {
+	var file finalBuildsIndexFile
+
+	path := filepath.Join(b.releaseDir, ".final_builds", kind, name, "index.yml")
+
+	if !b.fs.FileExists(path) {
+		return file, nil
+	}
+
+	bytes, err := b.fs.ReadFile(path)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Reading final builds index %s", path)
+	}
+
+	err = candiedyaml.Unmarshal(bytes, &file)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Parsing final builds index %s", path)
+	}
+
+	return file, nil
+}
@@ -0,0 +1,62 @@
+package release
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"sort"
+	"strings"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+// archiveFile is a file that is included into job or package tarball
+type archiveFile struct {
+	SrcPath string
+
+	// Relative path inside the tarball
+	Path string
+
+	Mode os.FileMode
+}
+
+type archiveFilesByPath []archiveFile
+
+func (s archiveFilesByPath) Len() int           { return len(s) }
+func (s archiveFilesByPath) Less(i, j int) bool { return s[i].Path < s[j].Path }
+func (s archiveFilesByPath) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// fingerprintFiles calculates fingerprint the same way as bosh-cli (v2 algorithm):
+// SHA1 of file paths, their SHA1s and modes followed by additional chunks
+// (e.g. package dependency names). Result does not depend on file timestamps.
+func fingerprintFiles(files []archiveFile, additionalChunks []string, fs boshsys.FileSystem) (string, error) {
+	chunks := []string{"v2"}
+
+	sortedFiles := append([]archiveFile{}, files...)
+	sort.Sort(archiveFilesByPath(sortedFiles))
+
+	for _, file := range sortedFiles {
+		digest, err := bputil.NewDigestFromFile(bputil.DigestAlgorithmSHA1, file.SrcPath, fs)
+		if err != nil {
+			return "", err
+		}
+
+		mode := "100644"
+		if file.Mode&0111 != 0 {
+			mode = "100755"
+		}
+
+		chunks = append(chunks, file.Path+digest.Value+mode)
+	}
+
+	sortedChunks := append([]string{}, additionalChunks...)
+	sort.Strings(sortedChunks)
+
+	chunks = append(chunks, strings.Join(sortedChunks, ","))
+
+	sum := sha1.Sum([]byte(strings.Join(chunks, "")))
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -1,85 +1,102 @@
 package release
 
 import (
-	"path/filepath"
-
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
-	bprelman "github.com/cppforlife/bosh-provisioner/release/manifest"
+	bptar "github.com/cppforlife/bosh-provisioner/tar"
 )
 
-const rawDirReaderLogTag = "DirReader"
+const rawDirReaderLogTag = "GitDirReader"
 
+// GitDirReader builds release from a release source directory
+// (e.g. git checkout) without relying on bosh CLI.
 type GitDirReader struct {
-	dir    string
-	fs     boshsys.FileSystem
-	logger boshlog.Logger
+	releaseName    string // e.g. room101
+	releaseVersion string // e.g. latest
+	dir            string
+
+	builder DirBuilder
+	fs      boshsys.FileSystem
+	logger  boshlog.Logger
+
+	// location to clean if successfully built
+	built BuiltRelease
 }
 
 func NewGitDirReader(
+	releaseName string,
+	releaseVersion string,
 	dir string,
+	compressor bptar.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
-) GitDirReader {
-	return GitDirReader{
-		dir:    dir,
-		fs:     fs,
-		logger: logger,
+) *GitDirReader {
+	return &GitDirReader{
+		releaseName:    releaseName,
+		releaseVersion: releaseVersion,
+		dir:            dir,
+
+		builder: NewDirBuilder(dir, compressor, fs, logger),
+		fs:      fs,
+		logger:  logger,
 	}
 }
 
-func (r GitDirReader) Read() (Release, error) {
+func (r *GitDirReader) Read() (Release, error) {
 	var release Release
 
-	var manifest bprelman.Manifest
-
-	jobMatches, err := r.fs.Glob(filepath.Join(r.dir, "jobs/*"))
-	if err != nil {
-		return release, bosherr.WrapError(err, "Globbing jobs/ directory")
-	}
+	version := r.releaseVersion
 
-	for _, jobMatch := range jobMatches {
-		job := bprelman.Job{
-			Name: filepath.Base(jobMatch),
+	if version == LatestVersion {
+		devVersion, err := nextDevVersion(r.dir, r.releaseName, r.fs)
+		if err != nil {
+			return release, bosherr.WrapError(err, "Computing dev version")
 		}
-		manifest.Release.Jobs = append(manifest.Release.Jobs, job)
+
+		version = devVersion
 	}
 
-	pkgMatches, err := r.fs.Glob(filepath.Join(r.dir, "packages/*"))
+	built, err := r.builder.Build(r.releaseName, version)
 	if err != nil {
-		return release, bosherr.WrapError(err, "Globbing packages/ directory")
+		return release, bosherr.WrapError(err, "Building release")
 	}
 
-	for _, pkgMatch := range pkgMatches {
-		pkg := bprelman.Package{
-			Name: filepath.Base(pkgMatch),
-		}
-		manifest.Release.Packages = append(manifest.Release.Packages, pkg)
-	}
+	r.built = built
 
-	r.logger.Debug(dirReaderLogTag, "Done building manifest %s", manifest)
+	r.logger.Debug(rawDirReaderLogTag, "Done building manifest %#v", built.Manifest)
 
-	release.populateFromManifest(manifest)
+	err = release.populateFromManifest(built.Manifest)
+	if err != nil {
+		closeErr := r.Close()
+		if closeErr != nil {
+			r.logger.Debug(rawDirReaderLogTag, "Failed to close release %v", closeErr)
+		}
 
-	r.populateReleaseDirPaths(&release)
+		return release, bosherr.WrapError(err, "Building release")
+	}
+
+	r.populateReleaseTarPaths(&release)
 
 	return release, nil
 }
 
-func (r GitDirReader) Close() error {
-	// Caller owns release directory; hence, nothing to clean up
-	return nil
+func (r *GitDirReader) Close() error {
+	if r.built.Path == "" {
+		return nil
+	}
+
+	return r.builder.CleanUp(r.built)
 }
 
-// populateReleaseDirPaths sets Path for each job/package in the release.
-func (r GitDirReader) populateReleaseDirPaths(release *Release) {
+// populateReleaseTarPaths sets TarPath for each job/package in the release.
+func (r GitDirReader) populateReleaseTarPaths(release *Release) {
 	for i, job := range release.Jobs {
-		release.Jobs[i].TarPath = filepath.Join(r.dir, "jobs", job.Name)
+		release.Jobs[i].TarPath = r.built.JobTarPaths[job.Name]
 	}
 
 	for _, pkg := range release.Packages {
-		pkg.TarPath = filepath.Join(r.dir, "packages", pkg.Name)
+		pkg.TarPath = r.built.PackageTarPaths[pkg.Name]
 	}
 }
@@ -0,0 +1,61 @@
+package release
+
+import (
+	"bytes"
+	"regexp"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// newGlobRegexp converts file glob used in package specs into regexp.
+// In addition to '*' and '?' supports '**' (any number of directories)
+// and '{a,b}' (alternatives) similarly to Ruby's Dir.glob.
+func newGlobRegexp(glob string) (*regexp.Regexp, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString("^")
+
+	inAlternatives := false
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+
+		switch {
+		case c == '*' && i+2 < len(glob) && glob[i+1] == '*' && glob[i+2] == '/':
+			buf.WriteString("(.*/)?")
+			i += 2
+
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			buf.WriteString(".*")
+			i++
+
+		case c == '*':
+			buf.WriteString("[^/]*")
+
+		case c == '?':
+			buf.WriteString("[^/]")
+
+		case c == '{' && !inAlternatives:
+			buf.WriteString("(")
+			inAlternatives = true
+
+		case c == '}' && inAlternatives:
+			buf.WriteString(")")
+			inAlternatives = false
+
+		case c == ',' && inAlternatives:
+			buf.WriteString("|")
+
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if inAlternatives {
+		return nil, bosherr.Errorf("Expected glob '%s' to close alternatives with '}'", glob)
+	}
+
+	buf.WriteString("$")
+
+	return regexp.Compile(buf.String())
+}
@@ -0,0 +1,444 @@
+package inspector
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+)
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// ReleaseDiff describes changes between two versions of a release.
+// Unchanged jobs and packages are not included.
+type ReleaseDiff struct {
+	Name string `json:"name"`
+
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+
+	Jobs     []JobDiff     `json:"jobs"`
+	Packages []PackageDiff `json:"packages"`
+}
+
+type JobDiff struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+
+	FromFingerprint string `json:"from_fingerprint,omitempty"`
+	ToFingerprint   string `json:"to_fingerprint,omitempty"`
+
+	// Runtime packages (or their dependencies) that were changed, added or removed
+	Packages []string `json:"packages,omitempty"`
+
+	AddedProperties   []string      `json:"added_properties,omitempty"`
+	RemovedProperties []string      `json:"removed_properties,omitempty"`
+	ChangedDefaults   []DefaultDiff `json:"changed_defaults,omitempty"`
+}
+
+type DefaultDiff struct {
+	Name string      `json:"name"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type PackageDiff struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+
+	FromFingerprint string `json:"from_fingerprint,omitempty"`
+	ToFingerprint   string `json:"to_fingerprint,omitempty"`
+
+	// Direct compile time dependencies
+	AddedDependencies   []string `json:"added_dependencies,omitempty"`
+	RemovedDependencies []string `json:"removed_dependencies,omitempty"`
+}
+
+// AffectedJob is a deployment job that uses changed release jobs;
+// its templates would be re-rendered and processes restarted.
+type AffectedJob struct {
+	Name      string   `json:"name"`
+	Templates []string `json:"templates"`
+}
+
+// NewReleaseDiff compares jobs by fingerprints, runtime packages and properties
+// and packages by fingerprints and dependencies.
+func NewReleaseDiff(from, to Release) ReleaseDiff {
+	diff := ReleaseDiff{
+		Name: to.Name,
+
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+
+		Jobs:     []JobDiff{},
+		Packages: []PackageDiff{},
+	}
+
+	fromPkgs := map[string]Package{}
+	toPkgs := map[string]Package{}
+
+	for _, pkg := range from.Packages {
+		fromPkgs[pkg.Name] = pkg
+	}
+
+	for _, pkg := range to.Packages {
+		toPkgs[pkg.Name] = pkg
+	}
+
+	changedPkgs := map[string]bool{}
+
+	for _, name := range unionNames(namesOfPackages(from.Packages), namesOfPackages(to.Packages)) {
+		pkgDiff, changed := newPackageDiff(name, fromPkgs, toPkgs)
+		if changed {
+			diff.Packages = append(diff.Packages, pkgDiff)
+			changedPkgs[name] = true
+		}
+	}
+
+	fromJobs := map[string]Job{}
+	toJobs := map[string]Job{}
+
+	for _, job := range from.Jobs {
+		fromJobs[job.Name] = job
+	}
+
+	for _, job := range to.Jobs {
+		toJobs[job.Name] = job
+	}
+
+	for _, name := range unionNames(namesOfJobs(from.Jobs), namesOfJobs(to.Jobs)) {
+		jobDiff, changed := newJobDiff(name, fromJobs, toJobs, fromPkgs, toPkgs, changedPkgs)
+		if changed {
+			diff.Jobs = append(diff.Jobs, jobDiff)
+		}
+	}
+
+	return diff
+}
+
+func newPackageDiff(name string, fromPkgs, toPkgs map[string]Package) (PackageDiff, bool) {
+	fromPkg, inFrom := fromPkgs[name]
+	toPkg, inTo := toPkgs[name]
+
+	diff := PackageDiff{
+		Name: name,
+
+		FromFingerprint: fromPkg.Fingerprint,
+		ToFingerprint:   toPkg.Fingerprint,
+	}
+
+	switch {
+	case !inFrom:
+		diff.Change = ChangeAdded
+	case !inTo:
+		diff.Change = ChangeRemoved
+	case fromPkg.Fingerprint != toPkg.Fingerprint:
+		diff.Change = ChangeChanged
+	default:
+		return diff, false
+	}
+
+	if inFrom && inTo {
+		fromDeps := namesOfDependencies(fromPkg.Dependencies)
+		toDeps := namesOfDependencies(toPkg.Dependencies)
+
+		diff.AddedDependencies = subtractNames(toDeps, fromDeps)
+		diff.RemovedDependencies = subtractNames(fromDeps, toDeps)
+	}
+
+	return diff, true
+}
+
+func newJobDiff(name string, fromJobs, toJobs map[string]Job, fromPkgs, toPkgs map[string]Package, changedPkgs map[string]bool) (JobDiff, bool) {
+	fromJob, inFrom := fromJobs[name]
+	toJob, inTo := toJobs[name]
+
+	diff := JobDiff{
+		Name: name,
+
+		FromFingerprint: fromJob.Fingerprint,
+		ToFingerprint:   toJob.Fingerprint,
+	}
+
+	if !inFrom {
+		diff.Change = ChangeAdded
+		return diff, true
+	}
+
+	if !inTo {
+		diff.Change = ChangeRemoved
+		return diff, true
+	}
+
+	// Package changes affect the job even if the job itself did not change;
+	// package fingerprints do not change when only their dependencies change
+	fromJobPkgs := packagesWithDependencies(fromJob.Packages, fromPkgs)
+	toJobPkgs := packagesWithDependencies(toJob.Packages, toPkgs)
+
+	for _, pkgName := range unionNames(fromJobPkgs, toJobPkgs) {
+		if changedPkgs[pkgName] || !containsName(fromJobPkgs, pkgName) || !containsName(toJobPkgs, pkgName) {
+			diff.Packages = append(diff.Packages, pkgName)
+		}
+	}
+
+	fromProps := map[string]Property{}
+	toProps := map[string]Property{}
+
+	for _, prop := range fromJob.Properties {
+		fromProps[prop.Name] = prop
+	}
+
+	for _, prop := range toJob.Properties {
+		toProps[prop.Name] = prop
+	}
+
+	for _, propName := range unionNames(namesOfProperties(fromJob.Properties), namesOfProperties(toJob.Properties)) {
+		fromProp, inFromProps := fromProps[propName]
+		toProp, inToProps := toProps[propName]
+
+		switch {
+		case !inFromProps:
+			diff.AddedProperties = append(diff.AddedProperties, propName)
+		case !inToProps:
+			diff.RemovedProperties = append(diff.RemovedProperties, propName)
+		case !reflect.DeepEqual(fromProp.Default, toProp.Default):
+			diff.ChangedDefaults = append(diff.ChangedDefaults, DefaultDiff{
+				Name: propName,
+				From: fromProp.Default,
+				To:   toProp.Default,
+			})
+		}
+	}
+
+	if fromJob.Fingerprint == toJob.Fingerprint && len(diff.Packages) == 0 {
+		return diff, false
+	}
+
+	diff.Change = ChangeChanged
+
+	return diff, true
+}
+
+// AffectedJobs returns deployment jobs that use changed or removed release jobs.
+func (d ReleaseDiff) AffectedJobs(jobs []bpdep.Job) []AffectedJob {
+	changedJobs := map[string]bool{}
+
+	for _, job := range d.Jobs {
+		if job.Change != ChangeAdded {
+			changedJobs[job.Name] = true
+		}
+	}
+
+	affectedJobs := []AffectedJob{}
+
+	for _, job := range jobs {
+		affectedJob := AffectedJob{Name: job.Name}
+
+		for _, template := range job.Templates {
+			if template.Release == nil || template.Release.Name != d.Name {
+				continue
+			}
+
+			if changedJobs[template.Name] {
+				affectedJob.Templates = append(affectedJob.Templates, template.Name)
+			}
+		}
+
+		if len(affectedJob.Templates) > 0 {
+			affectedJobs = append(affectedJobs, affectedJob)
+		}
+	}
+
+	return affectedJobs
+}
+
+// String returns human readable description of release changes
+func (d ReleaseDiff) String() string {
+	var lines []string
+
+	add := func(indent int, format string, args ...interface{}) {
+		lines = append(lines, strings.Repeat("  ", indent)+fmt.Sprintf(format, args...))
+	}
+
+	add(0, "Release %s: %s -> %s", d.Name, d.FromVersion, d.ToVersion)
+
+	add(0, "")
+	add(0, "Jobs:")
+
+	if len(d.Jobs) == 0 {
+		add(1, "no changes")
+	}
+
+	for _, job := range d.Jobs {
+		add(1, "%s %s%s", job.Change, job.Name, fingerprintsStr(job.Change, job.FromFingerprint, job.ToFingerprint))
+
+		if len(job.Packages) > 0 {
+			add(2, "packages: %s", strings.Join(job.Packages, ", "))
+		}
+
+		for _, name := range job.AddedProperties {
+			add(2, "added property %s", name)
+		}
+
+		for _, name := range job.RemovedProperties {
+			add(2, "removed property %s", name)
+		}
+
+		for _, def := range job.ChangedDefaults {
+			add(2, "changed default %s: %s -> %s", def.Name, formatValue(def.From), formatValue(def.To))
+		}
+	}
+
+	add(0, "")
+	add(0, "Packages:")
+
+	if len(d.Packages) == 0 {
+		add(1, "no changes")
+	}
+
+	for _, pkg := range d.Packages {
+		add(1, "%s %s%s", pkg.Change, pkg.Name, fingerprintsStr(pkg.Change, pkg.FromFingerprint, pkg.ToFingerprint))
+
+		for _, name := range pkg.AddedDependencies {
+			add(2, "added dependency %s", name)
+		}
+
+		for _, name := range pkg.RemovedDependencies {
+			add(2, "removed dependency %s", name)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func fingerprintsStr(change, from, to string) string {
+	switch change {
+	case ChangeAdded:
+		return fmt.Sprintf(" (fingerprint %s)", to)
+	case ChangeRemoved:
+		return fmt.Sprintf(" (fingerprint %s)", from)
+	case ChangeChanged:
+		if from == to {
+			return ""
+		}
+
+		return fmt.Sprintf(" (fingerprint %s -> %s)", from, to)
+	default:
+		return ""
+	}
+}
+
+// packagesWithDependencies returns names of given packages and all of their dependencies
+func packagesWithDependencies(names []string, pkgs map[string]Package) []string {
+	var result []string
+
+	seen := map[string]bool{}
+
+	var add func(name string)
+
+	add = func(name string) {
+		if seen[name] {
+			return
+		}
+
+		seen[name] = true
+		result = append(result, name)
+
+		for _, dep := range pkgs[name].Dependencies {
+			add(dep.Name)
+		}
+	}
+
+	for _, name := range names {
+		add(name)
+	}
+
+	return result
+}
+
+func namesOfJobs(jobs []Job) []string {
+	var names []string
+
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+
+	return names
+}
+
+func namesOfPackages(pkgs []Package) []string {
+	var names []string
+
+	for _, pkg := range pkgs {
+		names = append(names, pkg.Name)
+	}
+
+	return names
+}
+
+func namesOfProperties(props []Property) []string {
+	var names []string
+
+	for _, prop := range props {
+		names = append(names, prop.Name)
+	}
+
+	return names
+}
+
+func namesOfDependencies(deps []Dependency) []string {
+	var names []string
+
+	for _, dep := range deps {
+		names = append(names, dep.Name)
+	}
+
+	return names
+}
+
+// unionNames returns sorted unique names from both lists
+func unionNames(a, b []string) []string {
+	seen := map[string]bool{}
+
+	var names []string
+
+	for _, name := range append(append([]string{}, a...), b...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// subtractNames returns names from a that are not in b
+func subtractNames(a, b []string) []string {
+	var names []string
+
+	for _, name := range a {
+		if !containsName(b, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,180 @@
+package inspector_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdep "github.com/cppforlife/bosh-provisioner/deployment"
+	. "github.com/cppforlife/bosh-provisioner/release/inspector"
+)
+
+var _ = Describe("ReleaseDiff", func() {
+	var (
+		from Release
+		to   Release
+	)
+
+	BeforeEach(func() {
+		from = Release{
+			Name:    "room101",
+			Version: "1",
+			Jobs: []Job{
+				{
+					Name:        "warden",
+					Fingerprint: "warden-fp-1",
+					Packages:    []string{"warden"},
+					Properties: []Property{
+						{Name: "warden.port", Default: 7777},
+						{Name: "warden.pools"},
+					},
+				},
+				{Name: "winston", Fingerprint: "winston-fp-1", Packages: []string{"golang"}},
+				{Name: "old", Fingerprint: "old-fp-1"},
+			},
+			Packages: []Package{
+				{Name: "golang", Fingerprint: "golang-fp-1"},
+				{Name: "warden", Fingerprint: "warden-pkg-fp-1", Dependencies: []Dependency{{Name: "golang"}}},
+				{Name: "iptables", Fingerprint: "iptables-fp-1"},
+			},
+		}
+
+		to = Release{
+			Name:    "room101",
+			Version: "2",
+			Jobs: []Job{
+				{
+					Name:        "warden",
+					Fingerprint: "warden-fp-2",
+					Packages:    []string{"warden"},
+					Properties: []Property{
+						{Name: "warden.port", Default: 8888},
+						{Name: "warden.debug"},
+					},
+				},
+				{Name: "winston", Fingerprint: "winston-fp-1", Packages: []string{"golang"}},
+				{Name: "new", Fingerprint: "new-fp-1"},
+			},
+			Packages: []Package{
+				{Name: "golang", Fingerprint: "golang-fp-2"},
+				{Name: "warden", Fingerprint: "warden-pkg-fp-2", Dependencies: []Dependency{{Name: "aufs"}}},
+				{Name: "aufs", Fingerprint: "aufs-fp-1"},
+			},
+		}
+	})
+
+	Describe("NewReleaseDiff", func() {
+		It("returns added, removed and changed packages with dependency changes", func() {
+			diff := NewReleaseDiff(from, to)
+
+			Expect(diff.FromVersion).To(Equal("1"))
+			Expect(diff.ToVersion).To(Equal("2"))
+
+			Expect(diff.Packages).To(Equal([]PackageDiff{
+				{Name: "aufs", Change: ChangeAdded, ToFingerprint: "aufs-fp-1"},
+				{Name: "golang", Change: ChangeChanged, FromFingerprint: "golang-fp-1", ToFingerprint: "golang-fp-2"},
+				{Name: "iptables", Change: ChangeRemoved, FromFingerprint: "iptables-fp-1"},
+				{
+					Name:                "warden",
+					Change:              ChangeChanged,
+					FromFingerprint:     "warden-pkg-fp-1",
+					ToFingerprint:       "warden-pkg-fp-2",
+					AddedDependencies:   []string{"aufs"},
+					RemovedDependencies: []string{"golang"},
+				},
+			}))
+		})
+
+		It("returns added, removed and changed jobs including jobs with changed runtime packages", func() {
+			diff := NewReleaseDiff(from, to)
+
+			Expect(diff.Jobs).To(Equal([]JobDiff{
+				{Name: "new", Change: ChangeAdded, ToFingerprint: "new-fp-1"},
+				{Name: "old", Change: ChangeRemoved, FromFingerprint: "old-fp-1"},
+				{
+					Name:              "warden",
+					Change:            ChangeChanged,
+					FromFingerprint:   "warden-fp-1",
+					ToFingerprint:     "warden-fp-2",
+					Packages:          []string{"aufs", "golang", "warden"},
+					AddedProperties:   []string{"warden.debug"},
+					RemovedProperties: []string{"warden.pools"},
+					ChangedDefaults:   []DefaultDiff{{Name: "warden.port", From: 7777, To: 8888}},
+				},
+				{
+					Name:            "winston",
+					Change:          ChangeChanged,
+					FromFingerprint: "winston-fp-1",
+					ToFingerprint:   "winston-fp-1",
+					Packages:        []string{"golang"},
+				},
+			}))
+
+			str := diff.String()
+			Expect(str).To(ContainSubstring("Release room101: 1 -> 2"))
+			Expect(str).To(ContainSubstring("  changed warden (fingerprint warden-fp-1 -> warden-fp-2)"))
+			Expect(str).To(ContainSubstring("    changed default warden.port: 7777 -> 8888"))
+			Expect(str).To(ContainSubstring("  removed iptables (fingerprint iptables-fp-1)"))
+			Expect(str).To(ContainSubstring("    added dependency aufs"))
+		})
+
+		It("returns jobs whose runtime packages did not change but their dependencies did", func() {
+			from.Jobs = []Job{{Name: "nats", Fingerprint: "nats-fp-1", Packages: []string{"nats"}}}
+			to.Jobs = []Job{{Name: "nats", Fingerprint: "nats-fp-1", Packages: []string{"nats"}}}
+
+			from.Packages = []Package{
+				{Name: "nats", Fingerprint: "nats-pkg-fp-1", Dependencies: []Dependency{{Name: "ruby"}}},
+				{Name: "ruby", Fingerprint: "ruby-fp-1", Dependencies: []Dependency{{Name: "libyaml"}}},
+				{Name: "libyaml", Fingerprint: "libyaml-fp-1"},
+			}
+
+			to.Packages = []Package{
+				{Name: "nats", Fingerprint: "nats-pkg-fp-1", Dependencies: []Dependency{{Name: "ruby"}}},
+				{Name: "ruby", Fingerprint: "ruby-fp-1", Dependencies: []Dependency{{Name: "libyaml"}}},
+				{Name: "libyaml", Fingerprint: "libyaml-fp-2"},
+			}
+
+			diff := NewReleaseDiff(from, to)
+
+			Expect(diff.Jobs).To(Equal([]JobDiff{{
+				Name:            "nats",
+				Change:          ChangeChanged,
+				FromFingerprint: "nats-fp-1",
+				ToFingerprint:   "nats-fp-1",
+				Packages:        []string{"libyaml"},
+			}}))
+
+			jobs := []bpdep.Job{
+				{Name: "nats", Templates: []bpdep.Template{{Name: "nats", Release: &bpdep.Release{Name: "room101"}}}},
+			}
+
+			Expect(diff.AffectedJobs(jobs)).To(Equal([]AffectedJob{{Name: "nats", Templates: []string{"nats"}}}))
+		})
+
+		It("returns no changes for the same release", func() {
+			diff := NewReleaseDiff(from, from)
+			Expect(diff.Jobs).To(BeEmpty())
+			Expect(diff.Packages).To(BeEmpty())
+			Expect(diff.String()).To(ContainSubstring("Jobs:\n  no changes"))
+		})
+	})
+
+	Describe("AffectedJobs", func() {
+		It("returns deployment jobs that use changed or removed jobs from the release", func() {
+			room101 := &bpdep.Release{Name: "room101"}
+			other := &bpdep.Release{Name: "other"}
+
+			jobs := []bpdep.Job{
+				{Name: "api", Templates: []bpdep.Template{
+					{Name: "warden", Release: room101},
+					{Name: "new", Release: room101},
+					{Name: "old", Release: room101},
+				}},
+				{Name: "db", Templates: []bpdep.Template{{Name: "warden", Release: other}}},
+				{Name: "worker", Templates: []bpdep.Template{{Name: "new", Release: room101}}},
+			}
+
+			affected := NewReleaseDiff(from, to).AffectedJobs(jobs)
+			Expect(affected).To(Equal([]AffectedJob{{Name: "api", Templates: []string{"warden", "old"}}}))
+		})
+	})
+})
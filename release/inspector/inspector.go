@@ -0,0 +1,180 @@
+// Package inspector describes release jobs and packages without compiling them.
+package inspector
+
+import (
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bprel "github.com/cppforlife/bosh-provisioner/release"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+const inspectorLogTag = "Inspector"
+
+type Inspector struct {
+	releaseReaderFactory bprel.ReaderFactory
+	jobReaderFactory     bpreljob.ReaderFactory
+	logger               boshlog.Logger
+}
+
+func NewInspector(
+	releaseReaderFactory bprel.ReaderFactory,
+	jobReaderFactory bpreljob.ReaderFactory,
+	logger boshlog.Logger,
+) Inspector {
+	return Inspector{
+		releaseReaderFactory: releaseReaderFactory,
+		jobReaderFactory:     jobReaderFactory,
+		logger:               logger,
+	}
+}
+
+// Inspect reads release located at URL (see bprel.ReaderFactory)
+// and each of its job archives to describe their templates and properties.
+func (i Inspector) Inspect(name, version, url string) (Release, error) {
+	var result Release
+
+	relReader := i.releaseReaderFactory.NewReader(name, version, url, bputil.Digest{})
+
+	release, err := relReader.Read()
+	if err != nil {
+		return result, bosherr.WrapError(err, "Reading release")
+	}
+
+	defer func() {
+		err := relReader.Close()
+		if err != nil {
+			i.logger.Debug(inspectorLogTag, "Failed to close release %v", err)
+		}
+	}()
+
+	result = Release{
+		Name:    release.Name,
+		Version: release.Version,
+
+		CommitHash:         release.CommitHash,
+		UncommittedChanges: release.UncommittedChanges,
+
+		Jobs:     []Job{},
+		Packages: []Package{},
+	}
+
+	for _, relJob := range release.Jobs {
+		job, err := i.inspectJob(relJob)
+		if err != nil {
+			return result, bosherr.WrapErrorf(err, "Inspecting job %s", relJob.Name)
+		}
+
+		result.Jobs = append(result.Jobs, job)
+	}
+
+	for _, pkg := range release.Packages {
+		result.Packages = append(result.Packages, Package{
+			Name:        pkg.Name,
+			Version:     pkg.Version,
+			Fingerprint: pkg.Fingerprint,
+			SHA1:        pkg.SHA1,
+
+			Dependencies: newDependencies(pkg.Dependencies),
+		})
+	}
+
+	return result, nil
+}
+
+func (i Inspector) inspectJob(relJob bprel.Job) (Job, error) {
+	job := Job{
+		Name:        relJob.Name,
+		Version:     relJob.Version,
+		Fingerprint: relJob.Fingerprint,
+		SHA1:        relJob.SHA1,
+
+		Templates:  []Template{},
+		Scripts:    []string{},
+		Packages:   []string{},
+		Properties: []Property{},
+		Provides:   []Link{},
+		Consumes:   []Link{},
+	}
+
+	jobReader := i.jobReaderFactory.NewReader("file://" + relJob.TarPath)
+
+	readJob, err := jobReader.Read()
+	if err != nil {
+		return job, bosherr.WrapError(err, "Reading job")
+	}
+
+	defer func() {
+		err := jobReader.Close()
+		if err != nil {
+			i.logger.Debug(inspectorLogTag, "Failed to close job %v", err)
+		}
+	}()
+
+	job.Description = readJob.Description
+
+	for _, template := range readJob.Templates {
+		job.Templates = append(job.Templates, Template{
+			Src: template.SrcPathEnd,
+			Dst: template.DstPathEnd,
+		})
+	}
+
+	sort.Sort(templatesBySrc(job.Templates))
+
+	job.Scripts = append(job.Scripts, readJob.Scripts()...)
+	job.Monit = readJob.MonitTemplate != nil
+
+	for _, pkg := range readJob.Packages {
+		job.Packages = append(job.Packages, pkg.Name)
+	}
+
+	for _, prop := range readJob.Properties {
+		property := Property{
+			Name:        prop.Name,
+			Description: prop.Description,
+			Type:        prop.Type,
+			Default:     prop.Default,
+			Example:     prop.Example,
+		}
+
+		for _, ex := range prop.Examples {
+			property.Examples = append(property.Examples, PropertyExample{
+				Description: ex.Description,
+				Value:       ex.Value,
+			})
+		}
+
+		job.Properties = append(job.Properties, property)
+	}
+
+	sort.Sort(propertiesByName(job.Properties))
+
+	for _, link := range readJob.Provides {
+		job.Provides = append(job.Provides, Link{Name: link.Name, Type: link.Type, Properties: link.Properties})
+	}
+
+	for _, link := range readJob.Consumes {
+		job.Consumes = append(job.Consumes, Link{Name: link.Name, Type: link.Type, Optional: link.Optional})
+	}
+
+	return job, nil
+}
+
+// newDependencies returns full dependency tree;
+// package dependencies are known to not be circular.
+func newDependencies(pkgs []*bprel.Package) []Dependency {
+	deps := []Dependency{}
+
+	for _, pkg := range pkgs {
+		deps = append(deps, Dependency{
+			Name:         pkg.Name,
+			Dependencies: newDependencies(pkg.Dependencies),
+		})
+	}
+
+	return deps
+}
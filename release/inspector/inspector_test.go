@@ -0,0 +1,151 @@
+package inspector_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bpdload "github.com/cppforlife/bosh-provisioner/downloader"
+	bprel "github.com/cppforlife/bosh-provisioner/release"
+	. "github.com/cppforlife/bosh-provisioner/release/inspector"
+	bpreljob "github.com/cppforlife/bosh-provisioner/release/job"
+	bptar "github.com/cppforlife/bosh-provisioner/tar"
+)
+
+var _ = Describe("Inspector", func() {
+	var (
+		dir         string
+		tarballPath string
+		inspector   Inspector
+	)
+
+	writeFile := func(path, content string) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+
+		var err error
+
+		dir, err = ioutil.TempDir("", "inspector-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		writeFile("packages/golang/spec", "name: golang\nfiles: [golang/*]\n")
+		writeFile("packages/golang/packaging", "cp -a golang/* ${BOSH_INSTALL_TARGET}")
+		writeFile("src/golang/go", "go")
+
+		writeFile("packages/warden/spec", "name: warden\ndependencies: [golang]\nfiles: [warden/*]\n")
+		writeFile("packages/warden/packaging", "go build")
+		writeFile("src/warden/main.go", "package main")
+
+		writeFile("jobs/warden/spec", `
+name: warden
+description: Runs warden server
+templates:
+  warden_ctl.erb: bin/warden_ctl
+  config.yml.erb: config/config.yml
+  drain.erb: bin/drain
+  pre-start.erb: bin/pre-start
+packages: [warden]
+provides:
+- {name: warden, type: warden, properties: [warden.port]}
+properties:
+  warden.port:
+    description: Port to listen on
+    type: integer
+    default: 7777
+  warden.pools:
+    description: Network pools
+    example: {network: 10.254.0.0/22}
+`)
+		writeFile("jobs/warden/templates/warden_ctl.erb", "#!/bin/bash")
+		writeFile("jobs/warden/templates/config.yml.erb", "port: <%= p('warden.port') %>")
+		writeFile("jobs/warden/templates/drain.erb", "#!/bin/bash\necho 0")
+		writeFile("jobs/warden/templates/pre-start.erb", "#!/bin/bash")
+
+		compressor := bptar.NewArchiveCompressor(true, fs, logger)
+		extractor := bptar.NewArchiveExtractor(fs, logger)
+		downloader := bpdload.NewLocalFSDownloader(fs, logger)
+
+		created, err := bprel.NewDevReleaseCreator(dir, compressor, boshuuid.NewGenerator(), fs, logger).Create("room101")
+		Expect(err).ToNot(HaveOccurred())
+
+		tarballPath = created.TarballPath
+
+		inspector = NewInspector(
+			bprel.NewReaderFactory(bprel.ReaderConfig{}, nil, downloader, extractor, compressor, fs, logger),
+			bpreljob.NewReaderFactory(downloader, extractor, fs, logger),
+			logger,
+		)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Inspect", func() {
+		It("returns jobs with templates and properties and packages with dependencies", func() {
+			release, err := inspector.Inspect("", "", "file://"+tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(release.Name).To(Equal("room101"))
+			Expect(release.Version).To(Equal("0+dev.1"))
+
+			Expect(release.Jobs).To(HaveLen(1))
+
+			job := release.Jobs[0]
+			Expect(job.Description).To(Equal("Runs warden server"))
+			Expect(job.Templates).To(Equal([]Template{
+				{Src: "config.yml.erb", Dst: "config/config.yml"},
+				{Src: "drain.erb", Dst: "bin/drain"},
+				{Src: "pre-start.erb", Dst: "bin/pre-start"},
+				{Src: "warden_ctl.erb", Dst: "bin/warden_ctl"},
+			}))
+			Expect(job.Scripts).To(Equal([]string{"pre-start", "drain"}))
+			Expect(job.Monit).To(BeFalse())
+			Expect(job.Packages).To(Equal([]string{"warden"}))
+			Expect(job.Provides).To(Equal([]Link{{Name: "warden", Type: "warden", Properties: []string{"warden.port"}}}))
+
+			Expect(job.Properties).To(HaveLen(2))
+			Expect(job.Properties[0].Name).To(Equal("warden.pools"))
+			Expect(job.Properties[0].Example).To(Equal(map[string]interface{}{"network": "10.254.0.0/22"}))
+			Expect(job.Properties[1].Name).To(Equal("warden.port"))
+			Expect(job.Properties[1].Description).To(Equal("Port to listen on"))
+			Expect(job.Properties[1].Type).To(Equal("integer"))
+			Expect(job.Properties[1].Default).To(BeNumerically("==", 7777))
+
+			Expect(release.Packages).To(HaveLen(2))
+			Expect(release.Packages[1].Name).To(Equal("warden"))
+			Expect(release.Packages[1].Fingerprint).To(HaveLen(40))
+			Expect(release.Packages[1].Dependencies).To(Equal([]Dependency{
+				{Name: "golang", Dependencies: []Dependency{}},
+			}))
+
+			str := release.String()
+			Expect(str).To(ContainSubstring("Release room101/0+dev.1"))
+			Expect(str).To(ContainSubstring("      warden.port (integer)\n        Port to listen on\n        default: 7777"))
+			Expect(str).To(ContainSubstring("    -> golang"))
+
+			_, err = json.Marshal(release)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if release cannot be read", func() {
+			_, err := inspector.Inspect("", "", "file://"+filepath.Join(dir, "missing.tgz"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading release"))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Release struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	CommitHash         string `json:"commit_hash"`
+	UncommittedChanges bool   `json:"uncommitted_changes"`
+
+	Jobs     []Job     `json:"jobs"`
+	Packages []Package `json:"packages"`
+}
+
+type Job struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	Version     string `json:"version"`
+	Fingerprint string `json:"fingerprint"`
+	SHA1        string `json:"sha1"`
+
+	Templates []Template `json:"templates"`
+
+	// Lifecycle scripts rendered by templates, e.g. pre-start, drain
+	Scripts []string `json:"scripts"`
+
+	// False for jobs that do not run processes
+	Monit bool `json:"monit"`
+
+	// Runtime packages
+	Packages []string `json:"packages"`
+
+	Properties []Property `json:"properties"`
+
+	Provides []Link `json:"provides"`
+	Consumes []Link `json:"consumes"`
+}
+
+type Template struct {
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+type Property struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+
+	Default interface{} `json:"default,omitempty"`
+
+	Example  interface{}       `json:"example,omitempty"`
+	Examples []PropertyExample `json:"examples,omitempty"`
+}
+
+type PropertyExample struct {
+	Description string      `json:"description,omitempty"`
+	Value       interface{} `json:"value"`
+}
+
+type Link struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Optional   bool     `json:"optional,omitempty"`
+	Properties []string `json:"properties,omitempty"`
+}
+
+type Package struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Fingerprint string `json:"fingerprint"`
+	SHA1        string `json:"sha1"`
+
+	// Compile time dependencies including transitive ones
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+type Dependency struct {
+	Name         string       `json:"name"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+type templatesBySrc []Template
+
+func (s templatesBySrc) Len() int           { return len(s) }
+func (s templatesBySrc) Less(i, j int) bool { return s[i].Src < s[j].Src }
+func (s templatesBySrc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type propertiesByName []Property
+
+func (s propertiesByName) Len() int           { return len(s) }
+func (s propertiesByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+func (s propertiesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// String returns human readable description of the release
+func (r Release) String() string {
+	var lines []string
+
+	add := func(indent int, format string, args ...interface{}) {
+		lines = append(lines, strings.Repeat("  ", indent)+fmt.Sprintf(format, args...))
+	}
+
+	add(0, "Release %s/%s (commit %s%s)", r.Name, r.Version, r.CommitHash, r.uncommittedStr())
+
+	add(0, "")
+	add(0, "Jobs:")
+
+	for _, job := range r.Jobs {
+		add(1, "%s (fingerprint %s, sha1 %s)", job.Name, job.Fingerprint, job.SHA1)
+
+		if job.Description != "" {
+			add(2, "%s", job.Description)
+		}
+
+		add(2, "Templates:")
+
+		for _, template := range job.Templates {
+			add(3, "%s -> %s", template.Src, template.Dst)
+		}
+
+		if len(job.Scripts) > 0 {
+			add(2, "Scripts: %s", strings.Join(job.Scripts, ", "))
+		}
+
+		if !job.Monit {
+			add(2, "No monit file")
+		}
+
+		if len(job.Packages) > 0 {
+			add(2, "Packages: %s", strings.Join(job.Packages, ", "))
+		}
+
+		for _, link := range job.Provides {
+			add(2, "Provides: %s (%s)", link.Name, link.Type)
+		}
+
+		for _, link := range job.Consumes {
+			optionalStr := ""
+			if link.Optional {
+				optionalStr = ", optional"
+			}
+
+			add(2, "Consumes: %s (%s%s)", link.Name, link.Type, optionalStr)
+		}
+
+		if len(job.Properties) > 0 {
+			add(2, "Properties:")
+		}
+
+		for _, prop := range job.Properties {
+			if prop.Type != "" {
+				add(3, "%s (%s)", prop.Name, prop.Type)
+			} else {
+				add(3, "%s", prop.Name)
+			}
+
+			if prop.Description != "" {
+				add(4, "%s", prop.Description)
+			}
+
+			if prop.Default != nil {
+				add(4, "default: %s", formatValue(prop.Default))
+			}
+
+			if prop.Example != nil {
+				add(4, "example: %s", formatValue(prop.Example))
+			}
+
+			for _, ex := range prop.Examples {
+				add(4, "example: %s %s", formatValue(ex.Value), ex.Description)
+			}
+		}
+	}
+
+	add(0, "")
+	add(0, "Packages:")
+
+	for _, pkg := range r.Packages {
+		add(1, "%s (fingerprint %s, sha1 %s)", pkg.Name, pkg.Fingerprint, pkg.SHA1)
+		addDependencies(pkg.Dependencies, 2, add)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (r Release) uncommittedStr() string {
+	if r.UncommittedChanges {
+		return ", uncommitted changes"
+	}
+
+	return ""
+}
+
+func addDependencies(deps []Dependency, indent int, add func(int, string, ...interface{})) {
+	for _, dep := range deps {
+		add(indent, "-> %s", dep.Name)
+		addDependencies(dep.Dependencies, indent+1, add)
+	}
+}
+
+func formatValue(value interface{}) string {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(bytes)
+}
@@ -67,6 +67,10 @@ func (r DirReader) populateJobPaths(job *Job) {
 	// monit file is outside of templates/ directory
 	job.MonitTemplate.Path = filepath.Join(r.dir, "monit")
 
+	if !r.fs.FileExists(job.MonitTemplate.Path) {
+		job.MonitTemplate = nil
+	}
+
 	for i, template := range job.Templates {
 		job.Templates[i].Path = filepath.Join(
 			r.dir, "templates", template.SrcPathEnd)
@@ -0,0 +1,47 @@
+package job_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release/job"
+)
+
+var _ = Describe("DirReader", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		reader DirReader
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		reader = NewDirReader("/job", fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		fs.WriteFileString("/job/spec", `
+name: fake-job
+templates: {ctl.erb: bin/ctl}
+`)
+	})
+
+	Describe("Read", func() {
+		It("returns job with template paths within job directory", func() {
+			fs.WriteFileString("/job/monit", "fake-monit")
+
+			job, err := reader.Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.Name).To(Equal("fake-job"))
+			Expect(job.MonitTemplate.Path).To(Equal("/job/monit"))
+			Expect(job.Templates).To(HaveLen(1))
+			Expect(job.Templates[0].Path).To(Equal("/job/templates/ctl.erb"))
+		})
+
+		It("returns job without monit template if job does not include monit file", func() {
+			job, err := reader.Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.MonitTemplate).To(BeNil())
+			Expect(job.Templates).To(HaveLen(1))
+		})
+	})
+})
@@ -11,7 +11,8 @@ type Job struct {
 	Name        string
 	Description string
 
-	MonitTemplate Template
+	// Nil if job does not have monit file (e.g. job only runs lifecycle scripts)
+	MonitTemplate *Template
 
 	Templates []Template
 
@@ -21,6 +22,19 @@ type Job struct {
 	Packages []Package
 
 	Properties []Property
+
+	Provides []Link
+	Consumes []Link
+}
+
+type Link struct {
+	Name string
+	Type string
+
+	Optional bool
+
+	// Names of properties shared with consumers
+	Properties []string
 }
 
 type Template struct {
@@ -30,6 +44,26 @@ type Template struct {
 	Path string
 }
 
+// lifecycleScripts are run by the agent if job templates render them
+// (e.g. templates: {pre-start.erb: bin/pre-start})
+var lifecycleScripts = []string{"pre-start", "post-start", "drain", "post-deploy"}
+
+// Scripts returns names of lifecycle scripts rendered by the job.
+func (j Job) Scripts() []string {
+	var scripts []string
+
+	for _, script := range lifecycleScripts {
+		for _, template := range j.Templates {
+			if template.DstPathEnd == "bin/"+script {
+				scripts = append(scripts, script)
+				break
+			}
+		}
+	}
+
+	return scripts
+}
+
 type Package struct {
 	Name string
 }
@@ -37,6 +71,7 @@ type Package struct {
 type Property struct {
 	Name        string
 	Description string
+	Type        string
 
 	Default interface{}
 
@@ -55,6 +90,8 @@ func (j *Job) populateFromManifest(manifest bpreljobman.Manifest) {
 	j.populateTemplates(manifest.Job.TemplateNames)
 	j.populatePackages(manifest.Job.PackageNames)
 	j.populateProperties(manifest.Job.PropertyMappings)
+	j.Provides = j.buildLinks(manifest.Job.Provides)
+	j.Consumes = j.buildLinks(manifest.Job.Consumes)
 	j.Manifest = manifest
 }
 
@@ -64,7 +101,7 @@ func (j *Job) populateJob(manJob bpreljobman.Job) {
 }
 
 func (j *Job) populateTemplates(manTemplateNames bpreljobman.TemplateNames) {
-	j.MonitTemplate = Template{
+	j.MonitTemplate = &Template{
 		SrcPathEnd: "monit",
 		DstPathEnd: "monit",
 	}
@@ -90,6 +127,7 @@ func (j *Job) populateProperties(manPropMappings bpreljobman.PropertyMappings) {
 		property := Property{
 			Name:        propName,
 			Description: propDef.Description,
+			Type:        propDef.Type,
 
 			Default: propDef.Default,
 
@@ -106,3 +144,20 @@ func (j *Job) populateProperties(manPropMappings bpreljobman.PropertyMappings) {
 		j.Properties = append(j.Properties, property)
 	}
 }
+
+func (j *Job) buildLinks(manLinkDefs []bpreljobman.LinkDefinition) []Link {
+	var links []Link
+
+	for _, linkDef := range manLinkDefs {
+		links = append(links, Link{
+			Name: linkDef.Name,
+			Type: linkDef.Type,
+
+			Optional: linkDef.Optional,
+
+			Properties: linkDef.Properties,
+		})
+	}
+
+	return links
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/cloudfoundry-incubator/candiedyaml"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
 )
 
 type Manifest struct {
@@ -20,6 +22,22 @@ type Job struct {
 	PackageNames []string `yaml:"packages"`
 
 	PropertyMappings PropertyMappings `yaml:"properties"`
+
+	Provides []LinkDefinition `yaml:"provides"`
+	Consumes []LinkDefinition `yaml:"consumes"`
+}
+
+// LinkDefinition describes link provided or consumed by a job.
+type LinkDefinition struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+
+	// Only used by consumed links
+	Optional bool `yaml:"optional"`
+
+	// Only used by provided links; lists job properties
+	// that are shared with consumers
+	Properties []string `yaml:"properties"`
 }
 
 type TemplateNames map[string]string
@@ -29,6 +47,9 @@ type PropertyMappings map[string]PropertyDefinition
 type PropertyDefinition struct {
 	Description string `yaml:"description"`
 
+	// Informational only, e.g. certificate, password
+	Type string `yaml:"type"`
+
 	// Non-raw field is populated by the validator.
 	DefaultRaw interface{} `yaml:"default"`
 	Default    interface{}
@@ -70,6 +91,10 @@ func NewManifestFromBytes(bytes []byte) (Manifest, error) {
 
 	err = NewSyntaxValidator(&manifest).Validate()
 	if err != nil {
+		if verrs, ok := err.(bpvalid.Errors); ok {
+			err = verrs.WithLines(bpvalid.NewLineLocator(bytes))
+		}
+
 		return Manifest{}, bosherr.WrapError(err, "Validating manifest syntactically")
 	}
 
@@ -84,6 +109,9 @@ description: ...
 
 templates:
   dummy_ctl: bin/dummy_ctl
+  pre-start.erb: bin/pre-start
+  drain.erb: bin/drain
+  post-deploy.erb: bin/post-deploy
 
 packages:
 - dummy_package
@@ -92,6 +120,7 @@ packages:
 properties:
   dummy_value:
     description: Some value for the dummy job
+    type: integer
     default: 300
     example: ...
     examples:
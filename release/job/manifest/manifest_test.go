@@ -55,5 +55,53 @@ properties:
 				Expect(propDef.Default).To(Equal(""))
 			}
 		})
+
+		It("returns manifest with property definition type", func() {
+			manifestBytes := []byte(`
+properties:
+  key:
+    type: certificate
+`)
+
+			manifest, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Job.PropertyMappings["key"].Type).To(Equal("certificate"))
+		})
+
+		It("returns manifest with provided and consumed links", func() {
+			manifestBytes := []byte(`
+provides:
+- name: db
+  type: postgres
+  properties: [port, admin.username]
+consumes:
+- {name: backup, type: s3, optional: true}
+`)
+
+			manifest, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(manifest.Job.Provides).To(Equal([]LinkDefinition{
+				{Name: "db", Type: "postgres", Properties: []string{"port", "admin.username"}},
+			}))
+
+			Expect(manifest.Job.Consumes).To(Equal([]LinkDefinition{
+				{Name: "backup", Type: "s3", Optional: true},
+			}))
+		})
+
+		It("returns error if link is missing name or type", func() {
+			manifestBytes := []byte(`
+provides:
+- name: db
+consumes:
+- type: s3
+`)
+
+			_, err := NewManifestFromBytes(manifestBytes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("provides[0].type (line 3): Missing link type"))
+			Expect(err.Error()).To(ContainSubstring("consumes[0].name (line 5): Missing link name"))
+		})
 	})
 })
@@ -4,8 +4,10 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	bputil "github.com/cppforlife/bosh-provisioner/util"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
 )
 
+// SyntaxValidator returns all found problems together as bpvalid.Errors.
 type SyntaxValidator struct {
 	job *Job
 }
@@ -19,29 +21,44 @@ func NewSyntaxValidator(manifest *Manifest) SyntaxValidator {
 }
 
 func (v SyntaxValidator) Validate() error {
+	c := bpvalid.NewCollector()
+
 	for name, propDef := range v.job.PropertyMappings {
-		propDef, err := v.validatePropDef(propDef)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Property %s", name)
-		}
+		v.job.PropertyMappings[name] = v.validatePropDef(propDef, c.Key("properties").Key(name))
+	}
+
+	for i, linkDef := range v.job.Provides {
+		v.validateLinkDef(linkDef, c.Key("provides").Index(i))
+	}
 
-		v.job.PropertyMappings[name] = propDef
+	for i, linkDef := range v.job.Consumes {
+		v.validateLinkDef(linkDef, c.Key("consumes").Index(i))
 	}
 
-	return nil
+	return c.Errors()
+}
+
+func (v SyntaxValidator) validateLinkDef(linkDef LinkDefinition, c bpvalid.Collector) {
+	if linkDef.Name == "" {
+		c.Key("name").Add(bosherr.Error("Missing link name"))
+	}
+
+	if linkDef.Type == "" {
+		c.Key("type").Add(bosherr.Error("Missing link type"))
+	}
 }
 
-func (v SyntaxValidator) validatePropDef(propDef PropertyDefinition) (PropertyDefinition, error) {
+func (v SyntaxValidator) validatePropDef(propDef PropertyDefinition, c bpvalid.Collector) PropertyDefinition {
 	def, err := bputil.NewStringKeyed().ConvertInterface(propDef.DefaultRaw)
 	if err != nil {
-		return propDef, bosherr.WrapError(err, "Default")
+		c.Key("default").Add(err)
 	}
 
 	propDef.Default = def
 
 	ex, err := bputil.NewStringKeyed().ConvertInterface(propDef.ExampleRaw)
 	if err != nil {
-		return propDef, bosherr.WrapError(err, "Example")
+		c.Key("example").Add(err)
 	}
 
 	propDef.Example = ex
@@ -49,11 +66,11 @@ func (v SyntaxValidator) validatePropDef(propDef PropertyDefinition) (PropertyDe
 	for i, propExDef := range propDef.Examples {
 		val, err := bputil.NewStringKeyed().ConvertInterface(propExDef.ValueRaw)
 		if err != nil {
-			return propDef, bosherr.WrapErrorf(err, "Example[%d]", i)
+			c.Key("examples").Index(i).Key("value").Add(err)
 		}
 
 		propDef.Examples[i].Value = val
 	}
 
-	return propDef, nil
+	return propDef
 }
@@ -113,6 +113,10 @@ func (r TarReader) populateJobPaths(job *Job) {
 	job.MonitTemplate.Path = filepath.Join(
 		r.extractPath, job.MonitTemplate.SrcPathEnd)
 
+	if !r.fs.FileExists(job.MonitTemplate.Path) {
+		job.MonitTemplate = nil
+	}
+
 	for i, template := range job.Templates {
 		job.Templates[i].Path = filepath.Join(
 			r.extractPath, "templates", template.SrcPathEnd)
@@ -0,0 +1,57 @@
+package job_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release/job"
+)
+
+type fakeDownloader struct{}
+
+func (d fakeDownloader) Download(string) (string, error) { return "/job.tgz", nil }
+func (d fakeDownloader) CleanUp(string) error            { return nil }
+
+type fakeExtractor struct{}
+
+func (e fakeExtractor) Extract(string) (string, error) { return "/extracted-job", nil }
+func (e fakeExtractor) CleanUp(string) error           { return nil }
+
+var _ = Describe("TarReader", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		reader *TarReader
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		reader = NewTarReader("/job.tgz", fakeDownloader{}, fakeExtractor{}, fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		fs.WriteFileString("/extracted-job/job.MF", `
+name: fake-job
+templates: {ctl.erb: bin/ctl}
+`)
+	})
+
+	Describe("Read", func() {
+		It("returns job with template paths within extracted job", func() {
+			fs.WriteFileString("/extracted-job/monit", "fake-monit")
+
+			job, err := reader.Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.Name).To(Equal("fake-job"))
+			Expect(job.MonitTemplate.Path).To(Equal("/extracted-job/monit"))
+			Expect(job.Templates).To(HaveLen(1))
+			Expect(job.Templates[0].Path).To(Equal("/extracted-job/templates/ctl.erb"))
+		})
+
+		It("returns job without monit template if job does not include monit file", func() {
+			job, err := reader.Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.MonitTemplate).To(BeNil())
+			Expect(job.Templates).To(HaveLen(1))
+		})
+	})
+})
@@ -5,6 +5,8 @@ import (
 	"github.com/cloudfoundry-incubator/candiedyaml"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
 )
 
 type Manifest struct {
@@ -82,6 +84,10 @@ func NewManifestFromBytes(bytes []byte) (Manifest, error) {
 
 	err = NewSyntaxValidator(&manifest).Validate()
 	if err != nil {
+		if verrs, ok := err.(bpvalid.Errors); ok {
+			err = verrs.WithLines(bpvalid.NewLineLocator(bytes))
+		}
+
 		return manifest, bosherr.WrapError(err, "Validating manifest syntactically")
 	}
 
@@ -123,4 +123,45 @@ jobs:
 			}))
 		})
 	})
+
+	Describe("Bytes", func() {
+		It("returns manifest that can be read back", func() {
+			manifest := Manifest{
+				Release: Release{
+					Name:       "bosh",
+					Version:    "0+dev.3",
+					CommitHash: "bbe5476c",
+
+					Packages: []Package{{
+						Name:            "registry",
+						Version:         "dd1ba330bc44b3181b263383b8e4252d7051deca",
+						Fingerprint:     "dd1ba330bc44b3181b263383b8e4252d7051deca",
+						SHA1:            "6eaa6c961eac7bd994d1644ad405b3395420ecaf",
+						DependencyNames: []DependencyName{"ruby"},
+					}},
+
+					Jobs: []Job{{
+						Name:        "powerdns",
+						Version:     "0b80b3c198bf7abc6c81262013de494369fd25b6",
+						Fingerprint: "0b80b3c198bf7abc6c81262013de494369fd25b6",
+						SHA1:        "ab9709beab5be0fb62a2d1f3c88d06c9b4bdec65",
+					}},
+				},
+			}
+
+			bytes, err := manifest.Bytes()
+			Expect(err).ToNot(HaveOccurred())
+
+			readManifest, err := NewManifestFromBytes(bytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(readManifest.Release.Name).To(Equal("bosh"))
+			Expect(readManifest.Release.Version).To(Equal("0+dev.3"))
+			Expect(readManifest.Release.CommitHash).To(Equal("bbe5476c"))
+			Expect(readManifest.Release.Packages[0].Version).To(Equal(manifest.Release.Packages[0].Version))
+			Expect(readManifest.Release.Packages[0].SHA1).To(Equal(manifest.Release.Packages[0].SHA1))
+			Expect(readManifest.Release.Packages[0].DependencyNames).To(Equal([]DependencyName{"ruby"}))
+			Expect(readManifest.Release.Jobs[0].Fingerprint).To(Equal(manifest.Release.Jobs[0].Fingerprint))
+		})
+	})
 })
@@ -4,11 +4,13 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	bputil "github.com/cppforlife/bosh-provisioner/util"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
 )
 
 // SyntaxValidator parses and saves all manifest values to determine
 // their syntactic validity. Determining if individual values make sense
 // in a greater context (within a full release) is outside of scope.
+// All found problems are returned together as bpvalid.Errors.
 type SyntaxValidator struct {
 	release *Release
 }
@@ -22,113 +24,61 @@ func NewSyntaxValidator(manifest *Manifest) SyntaxValidator {
 }
 
 func (v SyntaxValidator) Validate() error {
+	c := bpvalid.NewCollector()
+
 	if v.release.Name == "" {
-		return bosherr.Error("Missing release name")
+		c.Key("name").Add(bosherr.Error("Missing release name"))
 	}
 
 	if v.release.Version == "" {
-		return bosherr.Error("Missing release version")
+		c.Key("version").Add(bosherr.Error("Missing release version"))
 	}
 
 	if v.release.CommitHash == "" {
-		return bosherr.Error("Missing release commit_hash")
+		c.Key("commit_hash").Add(bosherr.Error("Missing release commit_hash"))
 	}
 
-	for i, job := range v.release.Jobs {
-		err := v.validateJob(&v.release.Jobs[i])
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Job %s (%d)", job.Name, i)
-		}
-	}
+	for i := range v.release.Jobs {
+		job := &v.release.Jobs[i]
+		jobC := c.Key("jobs").Index(i)
 
-	for i, pkg := range v.release.Packages {
-		err := v.validatePkg(&v.release.Packages[i])
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Package %s (%d)", pkg.Name, i)
+		if job.Name == "" {
+			jobC.Key("name").Add(bosherr.Error("Missing name"))
 		}
-	}
-
-	return nil
-}
-
-func (v SyntaxValidator) validateJob(job *Job) error {
-	if job.Name == "" {
-		return bosherr.Error("Missing name")
-	}
-
-	if job.VersionRaw == "" {
-		return bosherr.Error("Missing version")
-	}
 
-	str, err := bputil.DecodePossibleBase64Str(job.VersionRaw)
-	if err != nil {
-		return bosherr.WrapError(err, "Decoding base64 encoded version")
+		job.Version = v.validateBase64Str(job.VersionRaw, "version", jobC)
+		job.Fingerprint = v.validateBase64Str(job.FingerprintRaw, "fingerprint", jobC)
+		job.SHA1 = v.validateBase64Str(job.SHA1Raw, "sha1", jobC)
 	}
 
-	job.Version = str
+	for i := range v.release.Packages {
+		pkg := &v.release.Packages[i]
+		pkgC := c.Key("packages").Index(i)
 
-	if job.FingerprintRaw == "" {
-		return bosherr.Error("Missing fingerprint")
-	}
-
-	str, err = bputil.DecodePossibleBase64Str(job.FingerprintRaw)
-	if err != nil {
-		return bosherr.WrapError(err, "Decoding base64 encoded fingerprint")
-	}
-
-	job.Fingerprint = str
-
-	if job.SHA1Raw == "" {
-		return bosherr.Error("Missing sha1")
-	}
+		if pkg.Name == "" {
+			pkgC.Key("name").Add(bosherr.Error("Missing name"))
+		}
 
-	str, err = bputil.DecodePossibleBase64Str(job.SHA1Raw)
-	if err != nil {
-		return bosherr.WrapError(err, "Decoding base64 encoded sha1")
+		pkg.Version = v.validateBase64Str(pkg.VersionRaw, "version", pkgC)
+		pkg.Fingerprint = v.validateBase64Str(pkg.FingerprintRaw, "fingerprint", pkgC)
+		pkg.SHA1 = v.validateBase64Str(pkg.SHA1Raw, "sha1", pkgC)
 	}
 
-	job.SHA1 = str
-
-	return nil
+	return c.Errors()
 }
 
-func (v SyntaxValidator) validatePkg(pkg *Package) error {
-	if pkg.Name == "" {
-		return bosherr.Error("Missing name")
-	}
-
-	if pkg.VersionRaw == "" {
-		return bosherr.Error("Missing version")
-	}
-
-	str, err := bputil.DecodePossibleBase64Str(pkg.VersionRaw)
-	if err != nil {
-		return bosherr.WrapError(err, "Decoding base64 encoded version")
-	}
-
-	pkg.Version = str
-
-	if pkg.FingerprintRaw == "" {
-		return bosherr.Error("Missing fingerprint")
+// validateBase64Str returns decoded value of a required, possibly base64 encoded key
+func (v SyntaxValidator) validateBase64Str(raw, key string, c bpvalid.Collector) string {
+	if raw == "" {
+		c.Key(key).Add(bosherr.Errorf("Missing %s", key))
+		return ""
 	}
 
-	str, err = bputil.DecodePossibleBase64Str(pkg.FingerprintRaw)
+	str, err := bputil.DecodePossibleBase64Str(raw)
 	if err != nil {
-		return bosherr.WrapError(err, "Decoding base64 encoded fingerprint")
-	}
-
-	pkg.Fingerprint = str
-
-	if pkg.SHA1Raw == "" {
-		return bosherr.Error("Missing sha1")
+		c.Key(key).Add(bosherr.WrapErrorf(err, "Decoding base64 encoded %s", key))
+		return ""
 	}
 
-	str, err = bputil.DecodePossibleBase64Str(pkg.SHA1Raw)
-	if err != nil {
-		return bosherr.WrapError(err, "Decoding base64 encoded sha1")
-	}
-
-	pkg.SHA1 = str
-
-	return nil
+	return str
 }
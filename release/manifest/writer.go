@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Written structures only include keys found in release.MF
+type writtenRelease struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+
+	CommitHash         string `yaml:"commit_hash"`
+	UncommittedChanges bool   `yaml:"uncommitted_changes"`
+
+	Jobs     []writtenJob     `yaml:"jobs"`
+	Packages []writtenPackage `yaml:"packages"`
+}
+
+type writtenJob struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Fingerprint string `yaml:"fingerprint"`
+	SHA1        string `yaml:"sha1"`
+}
+
+type writtenPackage struct {
+	Name         string           `yaml:"name"`
+	Version      string           `yaml:"version"`
+	Fingerprint  string           `yaml:"fingerprint"`
+	SHA1         string           `yaml:"sha1"`
+	Dependencies []DependencyName `yaml:"dependencies"`
+}
+
+// Bytes returns manifest in release.MF format using decoded
+// (not base64 encoded) versions, fingerprints and SHA1s.
+func (m Manifest) Bytes() ([]byte, error) {
+	rel := m.Release
+
+	written := writtenRelease{
+		Name:    rel.Name,
+		Version: rel.Version,
+
+		CommitHash:         rel.CommitHash,
+		UncommittedChanges: rel.UncommittedChanges,
+
+		Jobs:     []writtenJob{},
+		Packages: []writtenPackage{},
+	}
+
+	for _, job := range rel.Jobs {
+		written.Jobs = append(written.Jobs, writtenJob{
+			Name:        job.Name,
+			Version:     job.Version,
+			Fingerprint: job.Fingerprint,
+			SHA1:        job.SHA1,
+		})
+	}
+
+	for _, pkg := range rel.Packages {
+		deps := pkg.DependencyNames
+		if deps == nil {
+			deps = []DependencyName{}
+		}
+
+		written.Packages = append(written.Packages, writtenPackage{
+			Name:         pkg.Name,
+			Version:      pkg.Version,
+			Fingerprint:  pkg.Fingerprint,
+			SHA1:         pkg.SHA1,
+			Dependencies: deps,
+		})
+	}
+
+	bytes, err := candiedyaml.Marshal(written)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling release manifest")
+	}
+
+	return bytes, nil
+}
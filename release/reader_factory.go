@@ -7,49 +7,120 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	bpdload "github.com/cppforlife/bosh-provisioner/downloader"
+	bprelcache "github.com/cppforlife/bosh-provisioner/release/cache"
 	bptar "github.com/cppforlife/bosh-provisioner/tar"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
 )
 
 const (
 	readerFactoryDirPrefix    = "dir://"
 	readerFactoryDirGitPrefix = "dir+git://"
+	readerFactoryFilePrefix   = "file://"
 )
 
+type ReaderConfig struct {
+	// Archives of dev releases with uncommitted changes are not verified
+	// against release manifest since they might be rebuilt in place
+	SkipUncommittedVerification bool `json:"skip_uncommitted_verification"`
+
+	// Extracted release tarballs are cached in repos_dir/cache;
+	// least recently used releases are removed over this size (0 means no limit)
+	CacheMaxSizeMB int64 `json:"cache_max_size_mb"`
+
+	DisableCache bool `json:"disable_cache"`
+}
+
 type ReaderFactory struct {
+	config ReaderConfig
+
+	// Optional
+	cache bprelcache.Cache
+
 	downloader bpdload.Downloader
 	extractor  bptar.Extractor
+	compressor bptar.Compressor
 	fs         boshsys.FileSystem
 	logger     boshlog.Logger
 }
 
 func NewReaderFactory(
+	config ReaderConfig,
+	cache bprelcache.Cache,
 	downloader bpdload.Downloader,
 	extractor bptar.Extractor,
+	compressor bptar.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) ReaderFactory {
 	return ReaderFactory{
+		config: config,
+
+		cache: cache,
+
 		downloader: downloader,
 		extractor:  extractor,
+		compressor: compressor,
 		fs:         fs,
 		logger:     logger,
 	}
 }
 
-func (rf ReaderFactory) NewReader(name, version, url string) Reader {
-	if strings.HasPrefix(url, readerFactoryDirGitPrefix) {
+// NewReader returns reader for a release located at URL.
+// Version could be LatestVersion; in that case each reader resolves it on its own.
+// Digest (if not empty) is used to verify downloaded release tarballs.
+// Job and package archives are verified against the release manifest.
+func (rf ReaderFactory) NewReader(name, version, url string, digest bputil.Digest) Reader {
+	var reader Reader
+
+	switch {
+	case strings.HasPrefix(url, readerFactoryDirGitPrefix):
 		dir := url[len(readerFactoryDirGitPrefix):]
-		return NewGitDirReader(dir, rf.fs, rf.logger)
-	}
+		reader = NewGitDirReader(name, version, dir, rf.compressor, rf.fs, rf.logger)
 
-	if strings.HasPrefix(url, readerFactoryDirPrefix) {
+	case strings.HasPrefix(url, readerFactoryDirPrefix):
 		dir := url[len(readerFactoryDirPrefix):]
-		return NewDirReader(name, version, dir, rf.fs, rf.logger)
+		reader = NewDirReader(name, version, dir, rf.fs, rf.logger)
+
+	default:
+		reader = rf.newTarReader(name, version, url, digest)
 	}
 
-	return rf.NewTarReader(url)
+	return NewVerifyingReader(reader, rf.config.SkipUncommittedVerification, rf.fs, rf.logger)
 }
 
-func (rf ReaderFactory) NewTarReader(url string) Reader {
-	return NewTarReader(url, rf.downloader, rf.extractor, rf.fs, rf.logger)
+func (rf ReaderFactory) NewTarReader(url string, digest bputil.Digest) Reader {
+	return NewTarReader(url, digest, rf.downloader, rf.extractor, rf.fs, rf.logger)
+}
+
+func (rf ReaderFactory) newTarReader(name, version, url string, digest bputil.Digest) Reader {
+	cacheKey := rf.tarCacheKey(name, version, url, digest)
+
+	if rf.cache == nil || rf.config.DisableCache || cacheKey == "" {
+		return rf.NewTarReader(url, digest)
+	}
+
+	return NewCachedTarReader(url, digest, cacheKey, rf.cache, rf.downloader, rf.extractor, rf.fs, rf.logger)
+}
+
+// tarCacheKey returns key for an extracted release; final releases are identified
+// by name and version, others by URL. Digest is part of the key so that cached
+// releases are only used if they were verified against the same digest.
+// Tarballs without digests (other than final releases) are not cached
+// since contents at their URLs could change.
+func (rf ReaderFactory) tarCacheKey(name, version, url string, digest bputil.Digest) string {
+	var key string
+
+	if name != "" && isFinalVersion(version) {
+		key = "release:" + name + "/" + version
+	} else if digest.Empty() {
+		return ""
+	} else {
+		key = "url:" + url
+	}
+
+	if !digest.Empty() {
+		key += "#" + digest.String()
+	}
+
+	return key
 }
@@ -0,0 +1,89 @@
+package release_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+var _ = Describe("ReaderFactory", func() {
+	var (
+		tarPath    string
+		cache      *fakeCache
+		downloader *fakeDownloader
+		extractor  *fakeExtractor
+		factory    ReaderFactory
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+
+		file, err := ioutil.TempFile("", "reader-factory-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.Write([]byte("release-tarball"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(file.Close()).To(Succeed())
+
+		tarPath = file.Name()
+
+		cache = &fakeCache{paths: map[string]string{}}
+		downloader = &fakeDownloader{path: tarPath}
+		extractor = &fakeExtractor{}
+
+		factory = NewReaderFactory(ReaderConfig{}, cache, downloader, extractor, nil, fs, logger)
+	})
+
+	AfterEach(func() {
+		os.Remove(tarPath)
+	})
+
+	Describe("NewReader", func() {
+		read := func(name, version, url, digestStr string) {
+			var digest bputil.Digest
+
+			if digestStr != "" {
+				var err error
+				digest, err = bputil.NewDigestFromString(digestStr)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := factory.NewReader(name, version, url, digest).Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-extract-err"))
+		}
+
+		It("does not cache non-final release tarballs without digest", func() {
+			read("", "", "https://fake-url", "")
+			Expect(cache.foundKeys).To(BeEmpty())
+		})
+
+		It("caches release tarballs with digest by url and digest", func() {
+			read("", "", "https://fake-url", "7689ac159807bfcd91b7196b66359bd67b8697af")
+			Expect(cache.foundKeys).To(Equal([]string{"url:https://fake-url#7689ac159807bfcd91b7196b66359bd67b8697af"}))
+		})
+
+		It("caches final release tarballs by name and version", func() {
+			read("fake-release", "3", "https://fake-url", "")
+			Expect(cache.foundKeys).To(Equal([]string{"release:fake-release/3"}))
+		})
+
+		It("caches final release tarballs with digest by name, version and digest", func() {
+			cache.paths["release:fake-release/3"] = "/unverified-path"
+
+			read("fake-release", "3", "https://fake-url", "7689ac159807bfcd91b7196b66359bd67b8697af")
+			Expect(cache.foundKeys).To(Equal([]string{"release:fake-release/3#7689ac159807bfcd91b7196b66359bd67b8697af"}))
+			Expect(extractor.extractedPaths).To(Equal([]string{tarPath}))
+		})
+	})
+})
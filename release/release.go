@@ -1,6 +1,10 @@
 package release
 
 import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
 	bprelman "github.com/cppforlife/bosh-provisioner/release/manifest"
 )
 
@@ -44,62 +48,87 @@ type Package struct {
 // ResolvedPackageDependencies returns list of packages
 // in order such that each package at a higher index
 // only depends on packages at lower indecies.
-func (r Release) ResolvedPackageDependencies() []*Package {
+// Packages keep release order unless they are needed earlier by their dependents.
+// Returns error if dependencies are missing or circular.
+func (r Release) ResolvedPackageDependencies() ([]*Package, error) {
 	var resolvedPkgs []*Package
-	var pendingPkgs []*Package
 
-	for _, pkg := range r.Packages {
-		pkgRef := pkg
-		pendingPkgs = append(pendingPkgs, pkgRef)
-	}
+	resolved := map[*Package]bool{}
 
-	iterations := 0
+	// Packages that are currently being visited, in visiting order
+	var visitingPkgs []*Package
 
-	for len(pendingPkgs) > 0 {
-		pkg := pendingPkgs[0]
+	var visit func(pkg *Package) error
 
-		depsSatisfied := true
+	visit = func(pkg *Package) error {
+		if resolved[pkg] {
+			return nil
+		}
 
-		for _, depPkg := range pkg.Dependencies {
-			depSatisfied := false
+		for i, visitingPkg := range visitingPkgs {
+			if visitingPkg == pkg {
+				var names []string
 
-			for _, resPkg := range resolvedPkgs {
-				if depPkg.Name == resPkg.Name {
-					depSatisfied = true
-					break
+				for _, cyclePkg := range append(visitingPkgs[i:], pkg) {
+					names = append(names, cyclePkg.Name)
 				}
-			}
 
-			if !depSatisfied {
-				depsSatisfied = false
-				break
+				return bosherr.Errorf("Expected packages to not have circular dependencies: %s",
+					strings.Join(names, " -> "))
 			}
 		}
 
-		pendingPkgs = pendingPkgs[1:]
-		if depsSatisfied {
-			resolvedPkgs = append(resolvedPkgs, pkg)
-		} else {
-			pendingPkgs = append(pendingPkgs, pkg)
+		visitingPkgs = append(visitingPkgs, pkg)
+
+		for _, depPkg := range pkg.Dependencies {
+			if depPkg == nil {
+				return bosherr.Errorf("Expected dependencies of package '%s' to be present in the release", pkg.Name)
+			}
+
+			err := visit(depPkg)
+			if err != nil {
+				return err
+			}
 		}
 
-		if iterations > 100 {
-			panic("Failed to resolve package dependenices witin 100 iterations")
-		} else {
-			iterations++
+		visitingPkgs = visitingPkgs[:len(visitingPkgs)-1]
+
+		resolved[pkg] = true
+		resolvedPkgs = append(resolvedPkgs, pkg)
+
+		return nil
+	}
+
+	for _, pkg := range r.Packages {
+		err := visit(pkg)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return resolvedPkgs
+	return resolvedPkgs, nil
 }
 
 // populateFromManifest populates release information
-// interpreted from release manifest.
-func (r *Release) populateFromManifest(manifest bprelman.Manifest) {
+// interpreted from release manifest. Returns error if
+// package dependencies cannot be resolved.
+func (r *Release) populateFromManifest(manifest bprelman.Manifest) error {
 	r.populateRelease(manifest.Release)
-	r.populatePackages(manifest.Release.Packages)
+
+	err := r.populatePackages(manifest.Release.Packages)
+	if err != nil {
+		return err
+	}
+
 	r.populateJobs(manifest.Release.Jobs)
 	r.Manifest = manifest
+
+	_, err = r.ResolvedPackageDependencies()
+	if err != nil {
+		return bosherr.WrapError(err, "Resolving package dependencies")
+	}
+
+	return nil
 }
 
 func (r *Release) populateRelease(manRelease bprelman.Release) {
@@ -110,7 +139,7 @@ func (r *Release) populateRelease(manRelease bprelman.Release) {
 	r.UncommittedChanges = manRelease.UncommittedChanges
 }
 
-func (r *Release) populatePackages(manPkgs []bprelman.Package) {
+func (r *Release) populatePackages(manPkgs []bprelman.Package) error {
 	nameToPkg := map[bprelman.DependencyName]*Package{}
 
 	for _, manPkg := range manPkgs {
@@ -130,9 +159,16 @@ func (r *Release) populatePackages(manPkgs []bprelman.Package) {
 	// Connect compile time dependencies for packages
 	for i, manPkg := range manPkgs {
 		for _, depName := range manPkg.DependencyNames {
-			r.Packages[i].Dependencies = append(r.Packages[i].Dependencies, nameToPkg[depName])
+			depPkg, found := nameToPkg[depName]
+			if !found {
+				return bosherr.Errorf("Expected package '%s' dependency '%s' to be present in the release", manPkg.Name, depName)
+			}
+
+			r.Packages[i].Dependencies = append(r.Packages[i].Dependencies, depPkg)
 		}
 	}
+
+	return nil
 }
 
 func (r *Release) populateJobs(manJobs []bprelman.Job) {
@@ -0,0 +1,137 @@
+package release
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// releaseIndex reads versions of dev or final releases
+// created in a release directory (e.g. dev_releases/room101/index.yml).
+type releaseIndex struct {
+	dir  string // e.g. dev_releases
+	name string // e.g. room101
+	fs   boshsys.FileSystem
+}
+
+type releaseIndexFile struct {
+	Builds        map[string]releaseIndexBuild `yaml:"builds"`
+	FormatVersion string                       `yaml:"format-version"`
+}
+
+type releaseIndexBuild struct {
+	// Final versions are usually unquoted and parsed as numbers
+	Version interface{} `yaml:"version"`
+}
+
+const releaseIndexFormatVersion = "2"
+
+func newReleaseIndex(dir, name string, fs boshsys.FileSystem) releaseIndex {
+	return releaseIndex{dir: dir, name: name, fs: fs}
+}
+
+// Versions returns all versions of the release; index file that is
+// named after the release takes precedence over the old shared one.
+func (i releaseIndex) Versions() ([]string, error) {
+	var versions []string
+
+	file, err := i.readFile()
+	if err != nil {
+		return versions, err
+	}
+
+	for _, build := range file.Builds {
+		if build.Version != nil {
+			versions = append(versions, fmt.Sprintf("%v", build.Version))
+		}
+	}
+
+	return versions, nil
+}
+
+// AddVersion records new release version under a given build ID.
+// Index file is always written into a sub-directory named after the release.
+func (i releaseIndex) AddVersion(id, version string) error {
+	file, err := i.readFile()
+	if err != nil {
+		return err
+	}
+
+	if file.Builds == nil {
+		file.Builds = map[string]releaseIndexBuild{}
+	}
+
+	file.Builds[id] = releaseIndexBuild{Version: version}
+	file.FormatVersion = releaseIndexFormatVersion
+
+	bytes, err := candiedyaml.Marshal(file)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling release index")
+	}
+
+	path := filepath.Join(i.dir, i.name, "index.yml")
+
+	err = i.fs.WriteFile(path, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing release index %s", path)
+	}
+
+	return nil
+}
+
+func (i releaseIndex) readFile() (releaseIndexFile, error) {
+	var file releaseIndexFile
+
+	// bosh_cli now places index files into sub-directories named after a release
+	path := filepath.Join(i.dir, i.name, "index.yml")
+
+	if !i.fs.FileExists(path) {
+		path = filepath.Join(i.dir, "index.yml")
+
+		if !i.fs.FileExists(path) {
+			return file, nil
+		}
+	}
+
+	bytes, err := i.fs.ReadFile(path)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Reading release index %s", path)
+	}
+
+	err = candiedyaml.Unmarshal(bytes, &file)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Parsing release index %s", path)
+	}
+
+	return file, nil
+}
+
+// ManifestPaths returns possible locations of a release manifest for a version
+func (i releaseIndex) ManifestPaths(version string) []string {
+	// e.g. room101-0+dev.16.yml
+	fileName := i.name + "-" + version + ".yml"
+
+	return []string{
+		filepath.Join(i.dir, fileName),
+		filepath.Join(i.dir, i.name, fileName),
+	}
+}
+
+// nextDevVersion returns dev version that would be assigned
+// to the next dev release created in a release directory.
+func nextDevVersion(dir, name string, fs boshsys.FileSystem) (string, error) {
+	devVersions, err := newReleaseIndex(filepath.Join(dir, "dev_releases"), name, fs).Versions()
+	if err != nil {
+		return "", err
+	}
+
+	finalVersions, err := newReleaseIndex(filepath.Join(dir, "releases"), name, fs).Versions()
+	if err != nil {
+		return "", err
+	}
+
+	return NextDevVersion(devVersions, finalVersions), nil
+}
@@ -1,6 +1,8 @@
 package release_test
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -32,7 +34,8 @@ var _ = Describe("Release", func() {
 
 			release.Packages = []*Package{&pkg3, &pkg2, &pkg1}
 
-			pkgs := release.ResolvedPackageDependencies()
+			pkgs, err := release.ResolvedPackageDependencies()
+			Expect(err).ToNot(HaveOccurred())
 			Expect(pkgs).To(Equal([]*Package{&pkg1, &pkg2, &pkg3}))
 		})
 
@@ -47,8 +50,9 @@ var _ = Describe("Release", func() {
 
 			release.Packages = []*Package{&pkg3, &pkg2, &pkg1}
 
-			pkgs := release.ResolvedPackageDependencies()
-			Expect(pkgs).To(Equal([]*Package{&pkg2, &pkg1, &pkg3})) // or pkg1, pkg2, pkg3
+			pkgs, err := release.ResolvedPackageDependencies()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pkgs).To(Equal([]*Package{&pkg1, &pkg2, &pkg3}))
 		})
 
 		It("compiles BOSH release packages (example)", func() {
@@ -106,24 +110,72 @@ var _ = Describe("Release", func() {
 				&healthMonitor, // after ruby, libpq, postgres
 			}
 
-			pkgs := release.ResolvedPackageDependencies()
+			pkgs, err := release.ResolvedPackageDependencies()
+			Expect(err).ToNot(HaveOccurred())
 
 			Expect(pkgs).To(Equal([]*Package{
 				&nginx,
 				&genisoimage,
 				&powerdns,
-				&ruby,
+				&ruby, // moved before its first dependent
+				&blobstore,
 				&mysql,
 				&nats,
 				&common,
-				&redis,
 				&libpq,
-				&postgres,
-				&healthMonitor, // currently always appended to the end
-				&blobstore,
 				&director,
+				&redis,
 				&registry,
+				&postgres,
+				&healthMonitor,
 			}))
 		})
+
+		It("resolves deep dependency chains", func() {
+			var pkgs []*Package
+
+			for i := 0; i < 200; i++ {
+				pkg := &Package{Name: fmt.Sprintf("pkg-%d", i)}
+				if i > 0 {
+					pkg.Dependencies = []*Package{pkgs[0]}
+				}
+
+				// Each package depends on the previously created one
+				pkgs = append([]*Package{pkg}, pkgs...)
+			}
+
+			release.Packages = pkgs
+
+			resolvedPkgs, err := release.ResolvedPackageDependencies()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resolvedPkgs).To(HaveLen(200))
+			Expect(resolvedPkgs[0].Name).To(Equal("pkg-0"))
+			Expect(resolvedPkgs[199].Name).To(Equal("pkg-199"))
+		})
+
+		It("returns error with dependency cycle path", func() {
+			pkg1 := Package{Name: "pkg-1"}
+			pkg2 := Package{Name: "pkg-2", Dependencies: []*Package{&pkg1}}
+			pkg3 := Package{Name: "pkg-3", Dependencies: []*Package{&pkg2}}
+			pkg1.Dependencies = []*Package{&pkg3}
+
+			release.Packages = []*Package{&pkg2, &pkg3, &pkg1}
+
+			_, err := release.ResolvedPackageDependencies()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected packages to not have circular dependencies: pkg-2 -> pkg-1 -> pkg-3 -> pkg-2"))
+		})
+
+		It("returns error if package depends on itself", func() {
+			pkg1 := Package{Name: "pkg-1"}
+			pkg1.Dependencies = []*Package{&pkg1}
+
+			release.Packages = []*Package{&pkg1}
+
+			_, err := release.ResolvedPackageDependencies()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("pkg-1 -> pkg-1"))
+		})
 	})
 })
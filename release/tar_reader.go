@@ -8,22 +8,31 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	bpdload "github.com/cppforlife/bosh-provisioner/downloader"
+	bprelcache "github.com/cppforlife/bosh-provisioner/release/cache"
 	bprelman "github.com/cppforlife/bosh-provisioner/release/manifest"
 	bptar "github.com/cppforlife/bosh-provisioner/tar"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
 )
 
 const tarReaderLogTag = "TarReader"
 
 // TarReader reads .tgz release file and returns a Release.
+// If cache is configured, extracted releases are kept between reads.
 // See unpacked release directory layout at the end of the file.
 type TarReader struct {
-	url string
+	url    string
+	digest bputil.Digest
 
 	downloader bpdload.Downloader
 	extractor  bptar.Extractor
 	fs         boshsys.FileSystem
 	logger     boshlog.Logger
 
+	// Optional; cache owns cached extracted releases
+	cache    bprelcache.Cache
+	cacheKey string
+	cached   bool
+
 	// location to clean if successfully downloaded/extracted
 	downloadPath string
 	extractPath  string
@@ -31,13 +40,15 @@ type TarReader struct {
 
 func NewTarReader(
 	url string,
+	digest bputil.Digest,
 	downloader bpdload.Downloader,
 	extractor bptar.Extractor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) *TarReader {
 	return &TarReader{
-		url: url,
+		url:    url,
+		digest: digest,
 
 		downloader: downloader,
 		extractor:  extractor,
@@ -46,9 +57,54 @@ func NewTarReader(
 	}
 }
 
+func NewCachedTarReader(
+	url string,
+	digest bputil.Digest,
+	cacheKey string,
+	cache bprelcache.Cache,
+	downloader bpdload.Downloader,
+	extractor bptar.Extractor,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) *TarReader {
+	reader := NewTarReader(url, digest, downloader, extractor, fs, logger)
+	reader.cache = cache
+	reader.cacheKey = cacheKey
+	return reader
+}
+
 func (r *TarReader) Read() (Release, error) {
 	var release Release
 
+	if r.cache != nil {
+		cachedPath, found, err := r.cache.Find(r.cacheKey)
+		if err != nil {
+			return release, bosherr.WrapError(err, "Finding cached release")
+		}
+
+		if found {
+			r.extractPath = cachedPath
+			r.cached = true
+
+			release, err := r.readExtracted()
+			if err == nil {
+				return release, nil
+			}
+
+			// Cached release might have been partially removed or corrupted
+			r.logger.Debug(tarReaderLogTag,
+				"Failed to read cached release, downloading it again %v", err)
+
+			err = r.cache.Remove(r.cacheKey)
+			if err != nil {
+				return release, bosherr.WrapError(err, "Removing cached release")
+			}
+
+			r.extractPath = ""
+			r.cached = false
+		}
+	}
+
 	downloadPath, err := r.downloader.Download(r.url)
 	if err != nil {
 		return release, bosherr.WrapError(err, "Downloading release")
@@ -56,6 +112,20 @@ func (r *TarReader) Read() (Release, error) {
 
 	r.downloadPath = downloadPath
 
+	// Verify before extracting to avoid reading truncated or tampered releases
+	if !r.digest.Empty() {
+		err = r.digest.Verify(r.downloadPath, r.fs)
+		if err != nil {
+			cleanUpErr := r.downloader.CleanUp(r.downloadPath)
+			if cleanUpErr != nil {
+				r.logger.Debug(tarReaderLogTag,
+					"Failed to clean up downloaded release %v", cleanUpErr)
+			}
+
+			return release, bosherr.WrapError(err, "Verifying release")
+		}
+	}
+
 	extractPath, err := r.extractor.Extract(r.downloadPath)
 	if err != nil {
 		cleanUpErr := r.downloader.CleanUp(r.downloadPath)
@@ -69,6 +139,28 @@ func (r *TarReader) Read() (Release, error) {
 
 	r.extractPath = extractPath
 
+	if r.cache != nil {
+		cachedPath, err := r.cache.Save(r.cacheKey, r.extractPath)
+		if err != nil {
+			closeErr := r.Close()
+			if closeErr != nil {
+				r.logger.Debug(tarReaderLogTag,
+					"Failed to close release %v", closeErr)
+			}
+
+			return release, bosherr.WrapError(err, "Caching release")
+		}
+
+		r.extractPath = cachedPath
+		r.cached = true
+	}
+
+	return r.readExtracted()
+}
+
+func (r *TarReader) readExtracted() (Release, error) {
+	var release Release
+
 	manifestPath := filepath.Join(r.extractPath, "release.MF")
 
 	manifest, err := bprelman.NewManifestFromPath(manifestPath, r.fs)
@@ -84,7 +176,16 @@ func (r *TarReader) Read() (Release, error) {
 
 	r.logger.Debug(tarReaderLogTag, "Done building manifest %#v", manifest)
 
-	release.populateFromManifest(manifest)
+	err = release.populateFromManifest(manifest)
+	if err != nil {
+		closeErr := r.Close()
+		if closeErr != nil {
+			r.logger.Debug(tarReaderLogTag,
+				"Failed to close release %v", closeErr)
+		}
+
+		return release, bosherr.WrapError(err, "Building release")
+	}
 
 	r.populateReleaseTarPaths(&release)
 
@@ -92,16 +193,23 @@ func (r *TarReader) Read() (Release, error) {
 }
 
 func (r TarReader) Close() error {
-	dlErr := r.downloader.CleanUp(r.downloadPath)
-	if dlErr != nil {
-		r.logger.Debug(tarReaderLogTag,
-			"Failed to clean up downloaded release %v", dlErr)
+	var dlErr, exErr error
+
+	if r.downloadPath != "" {
+		dlErr = r.downloader.CleanUp(r.downloadPath)
+		if dlErr != nil {
+			r.logger.Debug(tarReaderLogTag,
+				"Failed to clean up downloaded release %v", dlErr)
+		}
 	}
 
-	exErr := r.extractor.CleanUp(r.extractPath)
-	if exErr != nil {
-		r.logger.Debug(tarReaderLogTag,
-			"Failed to clean up extracted release %v", exErr)
+	// Cached releases are removed by the cache
+	if !r.cached {
+		exErr = r.extractor.CleanUp(r.extractPath)
+		if exErr != nil {
+			r.logger.Debug(tarReaderLogTag,
+				"Failed to clean up extracted release %v", exErr)
+		}
 	}
 
 	if dlErr != nil {
@@ -0,0 +1,203 @@
+package release_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release"
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+)
+
+type fakeDownloader struct {
+	path         string
+	cleanedPaths []string
+}
+
+func (d *fakeDownloader) Download(string) (string, error) { return d.path, nil }
+
+func (d *fakeDownloader) CleanUp(path string) error {
+	d.cleanedPaths = append(d.cleanedPaths, path)
+	return nil
+}
+
+type fakeExtractor struct {
+	extractedPaths []string
+}
+
+func (e *fakeExtractor) Extract(path string) (string, error) {
+	e.extractedPaths = append(e.extractedPaths, path)
+	return "", errors.New("fake-extract-err")
+}
+
+func (e *fakeExtractor) CleanUp(string) error { return nil }
+
+type fakeCache struct {
+	paths       map[string]string
+	foundKeys   []string
+	savedPaths  []string
+	removedKeys []string
+}
+
+func (c *fakeCache) Find(key string) (string, bool, error) {
+	c.foundKeys = append(c.foundKeys, key)
+	path, found := c.paths[key]
+	return path, found, nil
+}
+
+func (c *fakeCache) Save(key, srcPath string) (string, error) {
+	c.savedPaths = append(c.savedPaths, srcPath)
+	return srcPath, nil
+}
+
+func (c *fakeCache) Remove(key string) error {
+	c.removedKeys = append(c.removedKeys, key)
+	delete(c.paths, key)
+	return nil
+}
+
+func (c *fakeCache) Clear() error { return nil }
+
+var _ = Describe("TarReader", func() {
+	var (
+		tarPath    string
+		downloader *fakeDownloader
+		extractor  *fakeExtractor
+		fs         boshsys.FileSystem
+		logger     boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		file, err := ioutil.TempFile("", "tar-reader-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.Write([]byte("release-tarball"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(file.Close()).To(Succeed())
+
+		tarPath = file.Name()
+
+		downloader = &fakeDownloader{path: tarPath}
+		extractor = &fakeExtractor{}
+	})
+
+	AfterEach(func() {
+		os.Remove(tarPath)
+	})
+
+	Describe("Read", func() {
+		read := func(digestStr string) error {
+			var digest bputil.Digest
+
+			if digestStr != "" {
+				var err error
+				digest, err = bputil.NewDigestFromString(digestStr)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := NewTarReader("http://fake-url", digest, downloader, extractor, fs, logger).Read()
+
+			return err
+		}
+
+		It("extracts release if sha1 digest matches", func() {
+			err := read("7689ac159807bfcd91b7196b66359bd67b8697af")
+			Expect(err.Error()).To(ContainSubstring("fake-extract-err"))
+			Expect(extractor.extractedPaths).To(Equal([]string{tarPath}))
+		})
+
+		It("extracts release if sha256 digest matches", func() {
+			err := read("sha256:611b7976b701f74450e891733541198f8ba26fc05376c02b9f409771522fcd10")
+			Expect(err.Error()).To(ContainSubstring("fake-extract-err"))
+			Expect(extractor.extractedPaths).To(Equal([]string{tarPath}))
+		})
+
+		It("returns error with expected and actual digests and does not extract release if digest does not match", func() {
+			err := read("0000000000000000000000000000000000000000")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"to have digest '0000000000000000000000000000000000000000' but was '7689ac159807bfcd91b7196b66359bd67b8697af'"))
+			Expect(extractor.extractedPaths).To(BeEmpty())
+			Expect(downloader.cleanedPaths).To(Equal([]string{tarPath}))
+		})
+
+		It("does not verify release if digest is not specified", func() {
+			err := read("")
+			Expect(err.Error()).To(ContainSubstring("fake-extract-err"))
+			Expect(extractor.extractedPaths).To(Equal([]string{tarPath}))
+		})
+	})
+
+	Describe("Read with cache", func() {
+		var (
+			extractedDir string
+			cache        *fakeCache
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			extractedDir, err = ioutil.TempDir("", "tar-reader-test-extracted")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(extractedDir, "release.MF"),
+				[]byte("name: room101\nversion: 3\ncommit_hash: abc123\n"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			cache = &fakeCache{paths: map[string]string{}}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(extractedDir)
+		})
+
+		It("reads cached release without downloading and extracting it", func() {
+			cache.paths["fake-key"] = extractedDir
+
+			reader := NewCachedTarReader("http://fake-url", bputil.Digest{}, "fake-key", cache, downloader, extractor, fs, logger)
+
+			release, err := reader.Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Version).To(Equal("3"))
+			Expect(extractor.extractedPaths).To(BeEmpty())
+
+			Expect(reader.Close()).To(Succeed())
+			Expect(fs.FileExists(extractedDir)).To(BeTrue())
+			Expect(downloader.cleanedPaths).To(BeEmpty())
+		})
+
+		It("removes cached release and downloads it again if cached release cannot be read", func() {
+			Expect(os.Remove(filepath.Join(extractedDir, "release.MF"))).To(Succeed())
+
+			cache.paths["fake-key"] = extractedDir
+
+			reader := NewCachedTarReader("http://fake-url", bputil.Digest{}, "fake-key", cache, downloader, extractor, fs, logger)
+
+			_, err := reader.Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-extract-err"))
+			Expect(cache.removedKeys).To(Equal([]string{"fake-key"}))
+			Expect(extractor.extractedPaths).To(Equal([]string{tarPath}))
+		})
+
+		It("does not save release into cache if it fails to be extracted", func() {
+			reader := NewCachedTarReader("http://fake-url", bputil.Digest{}, "fake-key", cache, downloader, extractor, fs, logger)
+
+			_, err := reader.Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-extract-err"))
+			Expect(cache.savedPaths).To(BeEmpty())
+		})
+	})
+})
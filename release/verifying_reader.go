@@ -0,0 +1,104 @@
+package release
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bputil "github.com/cppforlife/bosh-provisioner/util"
+	bpvalid "github.com/cppforlife/bosh-provisioner/validation"
+)
+
+const verifyingReaderLogTag = "VerifyingReader"
+
+// VerifyingReader checks that job and package archives of a read release
+// match SHA1s recorded in the release manifest so that corrupted
+// archives are not uploaded to the blobstore.
+type VerifyingReader struct {
+	reader Reader
+
+	// Releases with uncommitted changes might be rebuilt in place
+	skipUncommitted bool
+
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+func NewVerifyingReader(
+	reader Reader,
+	skipUncommitted bool,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) VerifyingReader {
+	return VerifyingReader{
+		reader: reader,
+
+		skipUncommitted: skipUncommitted,
+
+		fs:     fs,
+		logger: logger,
+	}
+}
+
+func (r VerifyingReader) Read() (Release, error) {
+	release, err := r.reader.Read()
+	if err != nil {
+		return release, err
+	}
+
+	if release.UncommittedChanges && r.skipUncommitted {
+		r.logger.Debug(verifyingReaderLogTag,
+			"Skipping verification of release %s/%s with uncommitted changes", release.Name, release.Version)
+		return release, nil
+	}
+
+	err = r.verify(release)
+	if err != nil {
+		closeErr := r.reader.Close()
+		if closeErr != nil {
+			r.logger.Debug(verifyingReaderLogTag, "Failed to close release %v", closeErr)
+		}
+
+		return release, bosherr.WrapError(err, "Verifying release archives")
+	}
+
+	return release, nil
+}
+
+func (r VerifyingReader) Close() error {
+	return r.reader.Close()
+}
+
+// verify returns all found mismatches together as bpvalid.Errors
+func (r VerifyingReader) verify(release Release) error {
+	c := bpvalid.NewCollector()
+
+	for _, job := range release.Jobs {
+		r.verifyArchive(job.TarPath, job.SHA1, c.Key("jobs").Key(job.Name))
+	}
+
+	for _, pkg := range release.Packages {
+		r.verifyArchive(pkg.TarPath, pkg.SHA1, c.Key("packages").Key(pkg.Name))
+	}
+
+	return c.Errors()
+}
+
+func (r VerifyingReader) verifyArchive(path, sha1 string, c bpvalid.Collector) {
+	if path == "" || !r.fs.FileExists(path) {
+		c.Add(bosherr.Errorf("Expected archive with SHA1 '%s' to exist", sha1))
+		return
+	}
+
+	// Newer releases record digests as 'sha256:<hex>'
+	digest, err := bputil.NewDigestFromString(sha1)
+	if err != nil {
+		c.Add(bosherr.WrapErrorf(err, "Parsing recorded SHA1 '%s'", sha1))
+		return
+	}
+
+	err = digest.Verify(path, r.fs)
+	if err != nil {
+		c.Add(err)
+	}
+}
@@ -0,0 +1,150 @@
+package release_test
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release"
+)
+
+func sha1Of(content string) string {
+	sum := sha1.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256Of(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+type fakeReader struct {
+	release Release
+	closed  bool
+}
+
+func (r *fakeReader) Read() (Release, error) { return r.release, nil }
+
+func (r *fakeReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+var _ = Describe("VerifyingReader", func() {
+	var (
+		dir    string
+		fs     boshsys.FileSystem
+		logger boshlog.Logger
+		reader *fakeReader
+	)
+
+	writeArchive := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+
+		dir, err = ioutil.TempDir("", "verifying-reader-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		reader = &fakeReader{
+			release: Release{
+				Name:    "room101",
+				Version: "0+dev.1",
+				Jobs: []Job{{
+					Name:    "warden",
+					SHA1:    sha1Of("other-job"),
+					TarPath: writeArchive("job.tgz", "job"),
+				}},
+				Packages: []*Package{{
+					Name:    "golang",
+					SHA1:    sha1Of("other-pkg"),
+					TarPath: writeArchive("pkg.tgz", "pkg"),
+				}},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Read", func() {
+		It("returns error listing all mismatched archives and closes release", func() {
+			_, err := NewVerifyingReader(reader, false, fs, logger).Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("2 problems found"))
+			Expect(err.Error()).To(ContainSubstring("jobs.warden: Expected file"))
+			Expect(err.Error()).To(ContainSubstring("to have digest '" + sha1Of("other-job") + "' but was '" + sha1Of("job") + "'"))
+			Expect(err.Error()).To(ContainSubstring("packages.golang: Expected file"))
+			Expect(reader.closed).To(BeTrue())
+		})
+
+		It("returns error if archive is missing", func() {
+			reader.release.Packages = nil
+			reader.release.Jobs[0].TarPath = ""
+
+			_, err := NewVerifyingReader(reader, false, fs, logger).Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs.warden: Expected archive with SHA1"))
+		})
+
+		It("returns error if recorded SHA1 cannot be parsed", func() {
+			reader.release.Packages = nil
+			reader.release.Jobs[0].SHA1 = "fake-job-sha1"
+
+			_, err := NewVerifyingReader(reader, false, fs, logger).Read()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs.warden: Parsing recorded SHA1 'fake-job-sha1'"))
+		})
+
+		It("returns release if archives match sha256 digests", func() {
+			reader.release.Jobs[0].SHA1 = sha256Of("job")
+			reader.release.Packages[0].SHA1 = sha256Of("pkg")
+
+			_, err := NewVerifyingReader(reader, false, fs, logger).Read()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns release if archives match", func() {
+			reader.release.Jobs[0].SHA1 = sha1Of("job")
+			reader.release.Packages[0].SHA1 = sha1Of("pkg")
+
+			release, err := NewVerifyingReader(reader, false, fs, logger).Read()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Name).To(Equal("room101"))
+			Expect(reader.closed).To(BeFalse())
+		})
+
+		Context("when release has uncommitted changes", func() {
+			BeforeEach(func() {
+				reader.release.UncommittedChanges = true
+			})
+
+			It("skips verification if configured", func() {
+				_, err := NewVerifyingReader(reader, true, fs, logger).Read()
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("verifies archives by default", func() {
+				_, err := NewVerifyingReader(reader, false, fs, logger).Read()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,131 @@
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LatestVersion can be used instead of an exact release version
+// to pick the most recent version available to a release reader.
+const LatestVersion = "latest"
+
+var (
+	versionSegmentsRegexp = regexp.MustCompile(`[.+-]`)
+	devVersionRegexp      = regexp.MustCompile(`^(.+)\+dev\.(\d+)$`)
+)
+
+// CompareVersions compares release versions segment by segment
+// (e.g. 12 < 12+dev.3 < 12.1 < 13, 0+dev.2 < 0+dev.10).
+// Dev versions are only compared by their dev number if their base versions are equal.
+// Returns -1, 0, or 1 if a is less, equal or greater than b.
+func CompareVersions(a, b string) int {
+	aBase, aDevNum := splitDevVersion(a)
+	bBase, bDevNum := splitDevVersion(b)
+
+	if result := compareBaseVersions(aBase, bBase); result != 0 {
+		return result
+	}
+
+	// Dev versions follow final version they are based on
+	return compareInts(aDevNum, bDevNum)
+}
+
+// splitDevVersion returns base version and dev number (e.g. 12+dev.3 -> 12, 3);
+// dev number is 0 for versions that are not dev versions.
+func splitDevVersion(version string) (string, int) {
+	if m := devVersionRegexp.FindStringSubmatch(version); m != nil {
+		devNum, _ := strconv.Atoi(m[2])
+		return m[1], devNum
+	}
+
+	return version, 0
+}
+
+func compareBaseVersions(a, b string) int {
+	aSegs := versionSegmentsRegexp.Split(a, -1)
+	bSegs := versionSegmentsRegexp.Split(b, -1)
+
+	for i := 0; i < len(aSegs) && i < len(bSegs); i++ {
+		if result := compareVersionSegments(aSegs[i], bSegs[i]); result != 0 {
+			return result
+		}
+	}
+
+	switch {
+	case len(aSegs) < len(bSegs):
+		return -1
+	case len(aSegs) > len(bSegs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareVersionSegments(a, b string) int {
+	aInt, aErr := strconv.Atoi(a)
+	bInt, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(aInt, bInt)
+	case aErr == nil:
+		return -1 // Numeric segments are ordered before other segments
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MaxVersion returns the greatest of given versions; empty if none given.
+func MaxVersion(versions []string) string {
+	var max string
+
+	for _, version := range versions {
+		if max == "" || CompareVersions(version, max) > 0 {
+			max = version
+		}
+	}
+
+	return max
+}
+
+// isFinalVersion returns true for exact versions that are not dev versions
+func isFinalVersion(version string) bool {
+	return version != "" && version != LatestVersion && !devVersionRegexp.MatchString(version)
+}
+
+// NextDevVersion returns dev version following the latest dev or final version
+// (e.g. 0+dev.2 -> 0+dev.3, 12 -> 12+dev.1, no versions -> 0+dev.1).
+func NextDevVersion(devVersions, finalVersions []string) string {
+	latestFinal := MaxVersion(finalVersions)
+	latestDev := MaxVersion(devVersions)
+
+	if latestDev != "" && (latestFinal == "" || CompareVersions(latestDev, latestFinal) > 0) {
+		if m := devVersionRegexp.FindStringSubmatch(latestDev); m != nil {
+			devNum, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%s+dev.%d", m[1], devNum+1)
+		}
+
+		return latestDev + "+dev.1"
+	}
+
+	if latestFinal != "" {
+		return latestFinal + "+dev.1"
+	}
+
+	return "0+dev.1"
+}
@@ -0,0 +1,54 @@
+package release_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/release"
+)
+
+var _ = Describe("Version", func() {
+	Describe("CompareVersions", func() {
+		It("compares numeric segments as numbers", func() {
+			Expect(CompareVersions("0+dev.2", "0+dev.10")).To(Equal(-1))
+			Expect(CompareVersions("10", "9")).To(Equal(1))
+			Expect(CompareVersions("1.2.3", "1.2.3")).To(Equal(0))
+		})
+
+		It("orders dev versions between final versions", func() {
+			Expect(CompareVersions("12", "12+dev.3")).To(Equal(-1))
+			Expect(CompareVersions("12+dev.3", "13")).To(Equal(-1))
+		})
+
+		It("compares dev numbers only if base versions are equal", func() {
+			Expect(CompareVersions("12.1", "12+dev.3")).To(Equal(1))
+			Expect(CompareVersions("12+dev.3", "12.1")).To(Equal(-1))
+			Expect(CompareVersions("12.1+dev.1", "12+dev.10")).To(Equal(1))
+		})
+	})
+
+	Describe("MaxVersion", func() {
+		It("returns greatest version", func() {
+			Expect(MaxVersion([]string{"0+dev.9", "0+dev.10", "0+dev.2"})).To(Equal("0+dev.10"))
+			Expect(MaxVersion([]string{"12.1", "12+dev.3", "12"})).To(Equal("12.1"))
+		})
+
+		It("returns empty string if there are no versions", func() {
+			Expect(MaxVersion(nil)).To(Equal(""))
+		})
+	})
+
+	Describe("NextDevVersion", func() {
+		It("bumps latest dev version", func() {
+			Expect(NextDevVersion([]string{"0+dev.1", "0+dev.2"}, nil)).To(Equal("0+dev.3"))
+		})
+
+		It("starts dev versions after latest final version", func() {
+			Expect(NextDevVersion([]string{"1+dev.4"}, []string{"1", "2"})).To(Equal("2+dev.1"))
+		})
+
+		It("returns first dev version if there are no releases", func() {
+			Expect(NextDevVersion(nil, nil)).To(Equal("0+dev.1"))
+		})
+	})
+})
@@ -0,0 +1,171 @@
+package tar
+
+import (
+	gotar "archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const archiveCompressorLogTag = "ArchiveCompressor"
+
+// ArchiveCompressor creates gzipped tarballs without relying on host's tar.
+// Entries are always added in lexical order; deterministic compressor
+// additionally drops modification times and owners so that archives
+// of the same directory contents have the same SHA1.
+type ArchiveCompressor struct {
+	deterministic bool
+
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+func NewArchiveCompressor(
+	deterministic bool,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) ArchiveCompressor {
+	return ArchiveCompressor{
+		deterministic: deterministic,
+
+		fs:     fs,
+		logger: logger,
+	}
+}
+
+func (c ArchiveCompressor) Compress(path string) (string, error) {
+	file, err := c.fs.TempFile("tar-ArchiveCompressor")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating compress destination")
+	}
+
+	compressPath := file.Name()
+
+	c.logger.Debug(archiveCompressorLogTag, "Compressing %s to %s", path, compressPath)
+
+	err = c.compress(path, file)
+
+	closeErr := file.Close()
+	if err == nil && closeErr != nil {
+		err = bosherr.WrapError(closeErr, "Closing compress destination")
+	}
+
+	if err != nil {
+		removeErr := c.fs.RemoveAll(compressPath)
+		if removeErr != nil {
+			c.logger.Debug(archiveCompressorLogTag, "Failed to remove compress destination %v", removeErr)
+		}
+
+		return "", bosherr.WrapErrorf(err, "Compressing %s", path)
+	}
+
+	return compressPath, nil
+}
+
+func (c ArchiveCompressor) CleanUp(path string) error {
+	return c.fs.RemoveAll(path)
+}
+
+func (c ArchiveCompressor) compress(path string, dst io.Writer) error {
+	gzipWriter := gzip.NewWriter(dst)
+	tarWriter := gotar.NewWriter(gzipWriter)
+
+	// Walk visits entries in lexical order
+	err := filepath.Walk(path, func(entryPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(path, entryPath)
+		if err != nil {
+			return err
+		}
+
+		// Same entry names as 'tar -C path -czf dst .'
+		name := "./"
+
+		if relPath != "." {
+			name += filepath.ToSlash(relPath)
+		}
+
+		return c.addEntry(tarWriter, entryPath, name, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Closing tar")
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Closing gzip")
+	}
+
+	return nil
+}
+
+func (c ArchiveCompressor) addEntry(tarWriter *gotar.Writer, path, name string, info os.FileInfo) error {
+	var linkTarget string
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+
+		linkTarget, err = os.Readlink(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading symlink '%s'", path)
+		}
+	}
+
+	header, err := gotar.FileInfoHeader(info, linkTarget)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building tar header for '%s'", path)
+	}
+
+	header.Name = name
+
+	if info.IsDir() && name != "./" {
+		header.Name += "/"
+	}
+
+	if c.deterministic {
+		header.ModTime = time.Unix(0, 0)
+		header.AccessTime = time.Time{}
+		header.ChangeTime = time.Time{}
+		header.Uid = 0
+		header.Gid = 0
+		header.Uname = ""
+		header.Gname = ""
+	}
+
+	err = tarWriter.WriteHeader(header)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing tar header for '%s'", path)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening '%s'", path)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(tarWriter, file)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing '%s' into tar", path)
+	}
+
+	return nil
+}
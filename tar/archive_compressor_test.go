@@ -0,0 +1,110 @@
+package tar_test
+
+import (
+	"crypto/sha1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/tar"
+)
+
+var _ = Describe("ArchiveCompressor", func() {
+	var (
+		dir    string
+		fs     boshsys.FileSystem
+		logger boshlog.Logger
+	)
+
+	writeFile := func(path, content string, mode os.FileMode) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), mode)).To(Succeed())
+		Expect(os.Chmod(path, mode)).To(Succeed())
+	}
+
+	sha1OfFile := func(path string) [20]byte {
+		bytes, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		return sha1.Sum(bytes)
+	}
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+
+		dir, err = ioutil.TempDir("", "archive-compressor-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		writeFile("bin/ctl", "#!/bin/bash", 0755)
+		writeFile("config/app.yml", "port: 80", 0644)
+		Expect(os.Symlink("../config/app.yml", filepath.Join(dir, "bin/link"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Compress", func() {
+		It("creates tarball that can be extracted with same contents, modes and symlinks", func() {
+			compressor := NewArchiveCompressor(false, fs, logger)
+
+			tarPath, err := compressor.Compress(dir)
+			Expect(err).ToNot(HaveOccurred())
+
+			defer compressor.CleanUp(tarPath)
+
+			extractor := NewArchiveExtractor(fs, logger)
+
+			extractPath, err := extractor.Extract(tarPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			defer extractor.CleanUp(extractPath)
+
+			info, err := os.Stat(filepath.Join(extractPath, "bin/ctl"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			target, err := os.Readlink(filepath.Join(extractPath, "bin/link"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal("../config/app.yml"))
+
+			content, err := fs.ReadFileString(filepath.Join(extractPath, "config/app.yml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("port: 80"))
+		})
+
+		It("creates identical tarballs for same contents if deterministic", func() {
+			compressor := NewArchiveCompressor(true, fs, logger)
+
+			firstPath, err := compressor.Compress(dir)
+			Expect(err).ToNot(HaveOccurred())
+
+			defer compressor.CleanUp(firstPath)
+
+			later := time.Now().Add(time.Hour)
+			Expect(os.Chtimes(filepath.Join(dir, "config/app.yml"), later, later)).To(Succeed())
+
+			secondPath, err := compressor.Compress(dir)
+			Expect(err).ToNot(HaveOccurred())
+
+			defer compressor.CleanUp(secondPath)
+
+			Expect(sha1OfFile(firstPath)).To(Equal(sha1OfFile(secondPath)))
+		})
+
+		It("returns error if directory does not exist", func() {
+			_, err := NewArchiveCompressor(true, fs, logger).Compress(filepath.Join(dir, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,324 @@
+package tar
+
+import (
+	gotar "archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const archiveExtractorLogTag = "ArchiveExtractor"
+
+// ArchiveExtractor extracts gzipped tarballs without relying on host's tar.
+// Since release tarballs might come from untrusted sources entries
+// that would end up outside of the extraction directory are rejected.
+type ArchiveExtractor struct {
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+func NewArchiveExtractor(
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) ArchiveExtractor {
+	return ArchiveExtractor{
+		fs:     fs,
+		logger: logger,
+	}
+}
+
+func (e ArchiveExtractor) Extract(path string) (string, error) {
+	extractPath, err := e.fs.TempDir("tar-ArchiveExtractor")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating extract destination")
+	}
+
+	e.logger.Debug(archiveExtractorLogTag, "Extract tar %s to %s", path, extractPath)
+
+	err = e.extract(path, extractPath)
+	if err != nil {
+		removeErr := e.fs.RemoveAll(extractPath)
+		if removeErr != nil {
+			e.logger.Debug(archiveExtractorLogTag, "Failed to remove extract destination %v", removeErr)
+		}
+
+		return "", bosherr.WrapErrorf(err, "Extracting tar %s", path)
+	}
+
+	return extractPath, nil
+}
+
+func (e ArchiveExtractor) CleanUp(path string) error {
+	return e.fs.RemoveAll(path)
+}
+
+func (e ArchiveExtractor) extract(path, dstPath string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening tar")
+	}
+
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading gzip")
+	}
+
+	defer gzipReader.Close()
+
+	tarReader := gotar.NewReader(gzipReader)
+
+	var symlinkPaths []string
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return bosherr.WrapError(err, "Reading tar entry")
+		}
+
+		err = e.extractEntry(tarReader, header, dstPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Extracting entry '%s'", header.Name)
+		}
+
+		if header.Typeflag == gotar.TypeSymlink {
+			symlinkPaths = append(symlinkPaths, header.Name)
+
+			// New symlink could also complete a chain started by a previous one
+			err = e.checkSymlinksResolveWithin(dstPath, symlinkPaths)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkSymlinksResolveWithin makes sure that extracted symlinks resolve within
+// destination before any other entry is extracted. Symlink targets are checked
+// as text when they are extracted; however, chains of symlinks
+// (e.g. 'q -> .', 'qq -> q/..') could still resolve outside of it.
+func (e ArchiveExtractor) checkSymlinksResolveWithin(dstPath string, names []string) error {
+	resolvedDstPath, err := filepath.EvalSymlinks(dstPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Resolving extract destination")
+	}
+
+	for _, name := range names {
+		path, err := pathWithin(dstPath, name)
+		if err != nil {
+			return err
+		}
+
+		resolvedPath, err := filepath.EvalSymlinks(path)
+		if os.IsNotExist(err) {
+			// Dangling symlinks cannot be followed
+			continue
+		} else if err != nil {
+			return bosherr.WrapErrorf(err, "Resolving symlink '%s'", name)
+		}
+
+		if resolvedPath != resolvedDstPath && !strings.HasPrefix(resolvedPath, resolvedDstPath+string(filepath.Separator)) {
+			return bosherr.Errorf("Expected symlink '%s' to resolve within destination", name)
+		}
+	}
+
+	return nil
+}
+
+func (e ArchiveExtractor) extractEntry(reader io.Reader, header *gotar.Header, dstPath string) error {
+	entryPath, err := pathWithin(dstPath, header.Name)
+	if err != nil {
+		return err
+	}
+
+	// Entries must not be written through previously extracted symlinks
+	err = e.checkNoSymlinks(dstPath, filepath.Dir(entryPath))
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(header.Mode).Perm()
+
+	switch header.Typeflag {
+	case gotar.TypeDir:
+		// MkdirAll and Chmod would follow previously extracted symlink
+		err := e.checkNoSymlinks(dstPath, entryPath)
+		if err != nil {
+			return err
+		}
+
+		return e.mkdir(entryPath, mode)
+
+	case gotar.TypeReg, gotar.TypeRegA:
+		err := e.mkdir(filepath.Dir(entryPath), os.FileMode(0755))
+		if err != nil {
+			return err
+		}
+
+		return e.writeFile(reader, entryPath, mode)
+
+	case gotar.TypeSymlink:
+		// Relative link targets are resolved against entry's directory
+		linkTarget := header.Linkname
+
+		if filepath.IsAbs(linkTarget) {
+			return bosherr.Errorf("Expected symlink target '%s' to be relative", linkTarget)
+		}
+
+		relTarget, err := filepath.Rel(dstPath, filepath.Join(filepath.Dir(entryPath), linkTarget))
+		if err != nil {
+			return bosherr.WrapError(err, "Resolving symlink target")
+		}
+
+		_, err = pathWithin(dstPath, relTarget)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking symlink target '%s'", linkTarget)
+		}
+
+		err = e.mkdir(filepath.Dir(entryPath), os.FileMode(0755))
+		if err != nil {
+			return err
+		}
+
+		err = e.removeExisting(entryPath)
+		if err != nil {
+			return err
+		}
+
+		return os.Symlink(linkTarget, entryPath)
+
+	case gotar.TypeLink:
+		linkPath, err := pathWithin(dstPath, header.Linkname)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking hard link target '%s'", header.Linkname)
+		}
+
+		err = e.checkNoSymlinks(dstPath, linkPath)
+		if err != nil {
+			return err
+		}
+
+		err = e.removeExisting(entryPath)
+		if err != nil {
+			return err
+		}
+
+		return os.Link(linkPath, entryPath)
+
+	case gotar.TypeXGlobalHeader:
+		return nil
+
+	default:
+		// Devices and fifos are not expected in release tarballs
+		e.logger.Debug(archiveExtractorLogTag, "Skipping entry '%s' with type '%c'", header.Name, header.Typeflag)
+		return nil
+	}
+}
+
+func (e ArchiveExtractor) mkdir(path string, mode os.FileMode) error {
+	// Directory must stay writable and traversable to extract its contents
+	err := os.MkdirAll(path, mode|0700)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating directory '%s'", path)
+	}
+
+	return os.Chmod(path, mode|0700)
+}
+
+func (e ArchiveExtractor) writeFile(reader io.Reader, path string, mode os.FileMode) error {
+	err := e.removeExisting(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating file '%s'", path)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing file '%s'", path)
+	}
+
+	// Mode given to OpenFile is affected by umask
+	return file.Chmod(mode)
+}
+
+// removeExisting removes file or symlink that was extracted earlier
+// from an entry with the same name; directories are kept.
+func (e ArchiveExtractor) removeExisting(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return bosherr.WrapErrorf(err, "Checking '%s'", path)
+	}
+
+	if info.IsDir() {
+		return bosherr.Errorf("Expected '%s' to not be a directory", path)
+	}
+
+	return os.Remove(path)
+}
+
+// checkNoSymlinks returns error if any existing path component
+// between root (exclusive) and path (inclusive) is a symlink.
+func (e ArchiveExtractor) checkNoSymlinks(root, path string) error {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return bosherr.WrapError(err, "Resolving relative path")
+	}
+
+	if rel == "." {
+		return nil
+	}
+
+	current := root
+
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return bosherr.WrapErrorf(err, "Checking '%s'", current)
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return bosherr.Errorf("Expected '%s' to not be a symlink", current)
+		}
+	}
+
+	return nil
+}
+
+// pathWithin returns absolute path for an archive entry name
+// making sure that it does not escape root directory.
+func pathWithin(root, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", bosherr.Errorf("Expected path '%s' to be relative", name)
+	}
+
+	path := filepath.Join(root, name)
+
+	if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return "", bosherr.Errorf("Expected path '%s' to be within destination", name)
+	}
+
+	return path, nil
+}
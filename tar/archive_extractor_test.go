@@ -0,0 +1,237 @@
+package tar_test
+
+import (
+	gotar "archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/tar"
+)
+
+type tarEntry struct {
+	Header  gotar.Header
+	Content string
+}
+
+func writeTarball(path string, entries []tarEntry) {
+	file, err := os.Create(path)
+	Expect(err).ToNot(HaveOccurred())
+
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := gotar.NewWriter(gzipWriter)
+
+	for _, entry := range entries {
+		header := entry.Header
+		header.Size = int64(len(entry.Content))
+
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+
+		Expect(tarWriter.WriteHeader(&header)).To(Succeed())
+
+		_, err := tarWriter.Write([]byte(entry.Content))
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Expect(tarWriter.Close()).To(Succeed())
+	Expect(gzipWriter.Close()).To(Succeed())
+}
+
+var _ = Describe("ArchiveExtractor", func() {
+	var (
+		dir       string
+		tarPath   string
+		fs        boshsys.FileSystem
+		extractor ArchiveExtractor
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		extractor = NewArchiveExtractor(fs, logger)
+
+		var err error
+
+		dir, err = ioutil.TempDir("", "archive-extractor-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		tarPath = filepath.Join(dir, "archive.tgz")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Extract", func() {
+		It("extracts files, directories and symlinks preserving modes", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "./", Typeflag: gotar.TypeDir, Mode: 0755}},
+				{Header: gotar.Header{Name: "./bin/", Typeflag: gotar.TypeDir, Mode: 0755}},
+				{Header: gotar.Header{Name: "./bin/ctl", Typeflag: gotar.TypeReg, Mode: 0755}, Content: "#!/bin/bash"},
+				{Header: gotar.Header{Name: "config/app.yml", Typeflag: gotar.TypeReg}, Content: "port: 80"},
+				{Header: gotar.Header{Name: "bin/link", Typeflag: gotar.TypeSymlink, Linkname: "../config/app.yml"}},
+			})
+
+			extractPath, err := extractor.Extract(tarPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			defer extractor.CleanUp(extractPath)
+
+			info, err := os.Stat(filepath.Join(extractPath, "bin/ctl"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			content, err := fs.ReadFileString(filepath.Join(extractPath, "bin/link"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("port: 80"))
+		})
+
+		It("returns error if entry escapes destination", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "ok", Typeflag: gotar.TypeReg}, Content: "ok"},
+				{Header: gotar.Header{Name: "../../escaped", Typeflag: gotar.TypeReg}, Content: "bad"},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected path '../../escaped' to be within destination"))
+		})
+
+		It("returns error if entry is absolute", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "/etc/escaped", Typeflag: gotar.TypeReg}, Content: "bad"},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("to be relative"))
+		})
+
+		It("returns error if symlink points outside of destination", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "link", Typeflag: gotar.TypeSymlink, Linkname: "../../etc"}},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking symlink target '../../etc'"))
+		})
+
+		It("returns error if symlink is absolute", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "link", Typeflag: gotar.TypeSymlink, Linkname: "/etc"}},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected symlink target '/etc' to be relative"))
+		})
+
+		It("returns error if chained symlinks resolve outside of destination", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "q", Typeflag: gotar.TypeSymlink, Linkname: "."}},
+				{Header: gotar.Header{Name: "qq", Typeflag: gotar.TypeSymlink, Linkname: "q/.."}},
+				{Header: gotar.Header{Name: "release.MF", Typeflag: gotar.TypeSymlink, Linkname: "qq/etc/passwd"}},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected symlink 'qq' to resolve within destination"))
+		})
+
+		It("returns error if symlink resolves outside of destination through symlink extracted later", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "qq", Typeflag: gotar.TypeSymlink, Linkname: "q/.."}},
+				{Header: gotar.Header{Name: "q", Typeflag: gotar.TypeSymlink, Linkname: "."}},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected symlink 'qq' to resolve within destination"))
+		})
+
+		It("does not change permissions outside of destination through chained symlinks", func() {
+			tempRoot := filepath.Join(dir, "tmp")
+			Expect(fs.ChangeTempRoot(tempRoot)).To(Succeed())
+			Expect(os.Chmod(tempRoot, 0700)).To(Succeed())
+
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "q", Typeflag: gotar.TypeSymlink, Linkname: "."}},
+				{Header: gotar.Header{Name: "qq", Typeflag: gotar.TypeSymlink, Linkname: "q/.."}},
+				{Header: gotar.Header{Name: "qq/", Typeflag: gotar.TypeDir, Mode: 0777}},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected symlink 'qq' to resolve within destination"))
+
+			info, err := os.Stat(tempRoot)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		})
+
+		It("returns error if directory entry is a previously extracted symlink", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "sub/", Typeflag: gotar.TypeDir, Mode: 0700}},
+				{Header: gotar.Header{Name: "link", Typeflag: gotar.TypeSymlink, Linkname: "sub"}},
+				{Header: gotar.Header{Name: "link/", Typeflag: gotar.TypeDir, Mode: 0777}},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("to not be a symlink"))
+		})
+
+		It("returns error if entry would be written through a previously extracted symlink", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "sub/", Typeflag: gotar.TypeDir, Mode: 0755}},
+				{Header: gotar.Header{Name: "link", Typeflag: gotar.TypeSymlink, Linkname: "sub"}},
+				{Header: gotar.Header{Name: "link/file", Typeflag: gotar.TypeReg}, Content: "bad"},
+			})
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("to not be a symlink"))
+		})
+
+		It("replaces previously extracted symlink instead of writing through it", func() {
+			writeTarball(tarPath, []tarEntry{
+				{Header: gotar.Header{Name: "target", Typeflag: gotar.TypeReg}, Content: "original"},
+				{Header: gotar.Header{Name: "file", Typeflag: gotar.TypeSymlink, Linkname: "target"}},
+				{Header: gotar.Header{Name: "file", Typeflag: gotar.TypeReg}, Content: "replaced"},
+			})
+
+			extractPath, err := extractor.Extract(tarPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			defer extractor.CleanUp(extractPath)
+
+			content, err := fs.ReadFileString(filepath.Join(extractPath, "target"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("original"))
+
+			content, err = fs.ReadFileString(filepath.Join(extractPath, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("replaced"))
+		})
+
+		It("returns error if file is not a gzipped tarball", func() {
+			Expect(ioutil.WriteFile(tarPath, []byte("not-a-tarball"), 0644)).To(Succeed())
+
+			_, err := extractor.Extract(tarPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading gzip"))
+		})
+	})
+})
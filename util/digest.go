@@ -0,0 +1,109 @@
+package util
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	DigestAlgorithmSHA1   = "sha1"
+	DigestAlgorithmSHA256 = "sha256"
+)
+
+// Digest is an expected checksum of a file.
+// Zero value indicates that digest was not specified.
+type Digest struct {
+	Algorithm string
+	Value     string
+}
+
+// NewDigestFromString parses digests in 'abc...' (SHA1)
+// or 'sha256:abc...' formats as used by BOSH manifests.
+func NewDigestFromString(str string) (Digest, error) {
+	algorithm := DigestAlgorithmSHA1
+	value := str
+
+	if strings.HasPrefix(str, DigestAlgorithmSHA256+":") {
+		algorithm = DigestAlgorithmSHA256
+		value = str[len(DigestAlgorithmSHA256)+1:]
+	}
+
+	digest := Digest{Algorithm: algorithm, Value: strings.ToLower(value)}
+
+	_, err := hex.DecodeString(digest.Value)
+	if err != nil || len(digest.Value) != digest.newHash().Size()*2 {
+		return Digest{}, bosherr.Errorf("Expected digest '%s' to be a hex encoded %s checksum", str, algorithm)
+	}
+
+	return digest, nil
+}
+
+func (d Digest) Empty() bool { return d.Value == "" }
+
+// String returns digest in the format accepted by NewDigestFromString;
+// empty if digest was not specified.
+func (d Digest) String() string {
+	if d.Empty() {
+		return ""
+	}
+
+	if d.Algorithm == DigestAlgorithmSHA1 {
+		return d.Value
+	}
+
+	return d.Algorithm + ":" + d.Value
+}
+
+// NewDigestFromFile calculates digest of file contents with a given algorithm
+func NewDigestFromFile(algorithm, path string, fs boshsys.FileSystem) (Digest, error) {
+	digest := Digest{Algorithm: algorithm}
+
+	file, err := fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return digest, bosherr.WrapErrorf(err, "Opening file %s", path)
+	}
+
+	defer file.Close()
+
+	h := digest.newHash()
+
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return digest, bosherr.WrapErrorf(err, "Calculating digest of file %s", path)
+	}
+
+	digest.Value = hex.EncodeToString(h.Sum(nil))
+
+	return digest, nil
+}
+
+// Verify returns error with expected and actual digests
+// if file contents do not match the digest.
+func (d Digest) Verify(path string, fs boshsys.FileSystem) error {
+	actual, err := NewDigestFromFile(d.Algorithm, path, fs)
+	if err != nil {
+		return err
+	}
+
+	if actual.Value != d.Value {
+		return bosherr.Errorf("Expected file %s to have digest '%s' but was '%s'", path, d, actual)
+	}
+
+	return nil
+}
+
+func (d Digest) newHash() hash.Hash {
+	if d.Algorithm == DigestAlgorithmSHA256 {
+		return sha256.New()
+	}
+
+	return sha1.New()
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	PrivateDirMode  = os.FileMode(0700)
+	PrivateFileMode = os.FileMode(0600)
+)
+
+// WritePrivateFile writes a file that is only accessible by its owner
+// (e.g. credentials). Missing parent directories are created as private;
+// mode of an existing file is tightened before contents are written.
+func WritePrivateFile(fs boshsys.FileSystem, path string, contents []byte) error {
+	err := fs.MkdirAll(filepath.Dir(path), PrivateDirMode)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating directory for %s", path)
+	}
+
+	file, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, PrivateFileMode)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening %s", path)
+	}
+
+	defer file.Close()
+
+	err = fs.Chmod(path, PrivateFileMode)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Changing mode of %s", path)
+	}
+
+	_, err = file.Write(contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", path)
+	}
+
+	return nil
+}
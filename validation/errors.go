@@ -0,0 +1,119 @@
+// Package validation collects problems found while validating
+// YAML documents (e.g. manifests) so that all of them
+// can be reported at once with their locations.
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is a single problem found at a path within a document,
+// e.g. jobs[2].networks[0].static_ips[1]
+type Error struct {
+	Path string
+
+	// Line is 0 if it's unknown
+	Line int
+
+	Err error
+}
+
+func (e Error) Error() string {
+	var location string
+
+	if e.Path != "" {
+		location = e.Path
+	}
+
+	if e.Line > 0 {
+		location += fmt.Sprintf(" (line %d)", e.Line)
+	}
+
+	if location == "" {
+		return e.Err.Error()
+	}
+
+	return strings.TrimLeft(location, " ") + ": " + e.Err.Error()
+}
+
+// Errors is a list of problems that is also an error itself.
+type Errors []Error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := []string{fmt.Sprintf("%d problems found:", len(e))}
+
+	for _, err := range e {
+		msgs = append(msgs, "  - "+err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// WithLines returns errors with lines determined by the locator.
+func (e Errors) WithLines(locator LineLocator) Errors {
+	return e.MapPaths(func(path string) string { return path }, locator)
+}
+
+// MapPaths returns errors with updated paths and lines;
+// useful when validated structure differs from the original document.
+func (e Errors) MapPaths(mapFunc func(string) string, locator LineLocator) Errors {
+	var result Errors
+
+	for _, err := range e {
+		err.Path = mapFunc(err.Path)
+		err.Line = locator.Line(err.Path)
+		result = append(result, err)
+	}
+
+	return result
+}
+
+// Collector accumulates errors under a path prefix.
+// Nested collectors share the same list of errors.
+type Collector struct {
+	path string
+	errs *Errors
+}
+
+func NewCollector() Collector {
+	return Collector{errs: &Errors{}}
+}
+
+// Key returns collector for a map key, e.g. jobs -> jobs.name
+func (c Collector) Key(name string) Collector {
+	path := name
+
+	if c.path != "" {
+		path = c.path + "." + name
+	}
+
+	return Collector{path: path, errs: c.errs}
+}
+
+// Index returns collector for an array item, e.g. jobs -> jobs[2]
+func (c Collector) Index(i int) Collector {
+	return Collector{path: fmt.Sprintf("%s[%d]", c.path, i), errs: c.errs}
+}
+
+func (c Collector) Path() string { return c.path }
+
+func (c Collector) Add(err error) {
+	*c.errs = append(*c.errs, Error{Path: c.path, Err: err})
+}
+
+// Errors returns nil if nothing was collected
+func (c Collector) Errors() error {
+	if len(*c.errs) == 0 {
+		return nil
+	}
+
+	return *c.errs
+}
+
+// HasErrors returns true if any errors were collected
+func (c Collector) HasErrors() bool { return len(*c.errs) > 0 }
@@ -0,0 +1,43 @@
+package validation_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/validation"
+)
+
+var _ = Describe("Collector", func() {
+	It("returns nil if there are no errors", func() {
+		Expect(NewCollector().Errors()).To(BeNil())
+	})
+
+	It("returns all errors added by nested collectors with their paths", func() {
+		c := NewCollector()
+		c.Key("name").Add(errors.New("fake-err1"))
+		c.Key("jobs").Index(2).Key("networks").Index(0).Add(errors.New("fake-err2"))
+
+		Expect(c.Errors()).To(Equal(Errors{
+			{Path: "name", Err: errors.New("fake-err1")},
+			{Path: "jobs[2].networks[0]", Err: errors.New("fake-err2")},
+		}))
+
+		Expect(c.Errors().Error()).To(Equal(
+			"2 problems found:\n  - name: fake-err1\n  - jobs[2].networks[0]: fake-err2"))
+	})
+})
+
+var _ = Describe("Errors", func() {
+	Describe("MapPaths", func() {
+		It("returns errors with mapped paths and lines", func() {
+			errs := Errors{{Path: "jobs[0]", Err: errors.New("fake-err")}}
+
+			mapFunc := func(path string) string { return "instance_groups[0]" }
+			locator := NewLineLocator([]byte("name: fake\ninstance_groups:\n- name: ig\n"))
+
+			Expect(errs.MapPaths(mapFunc, locator).Error()).To(Equal("instance_groups[0] (line 3): fake-err"))
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	keyRegexp         = regexp.MustCompile(`\A("[^"]*"|'[^']*'|[^\s#'"][^#]*?)\s*:(\s+|\z)`)
+	blockScalarRegexp = regexp.MustCompile(`\A[|>][-+0-9]*\s*(#.*)?\z`)
+)
+
+// LineLocator maps document paths (e.g. jobs[2].networks[0]) to line numbers.
+// It understands commonly used block style YAML; values written
+// in flow style (e.g. [a, b]) are attributed to their parent's line.
+type LineLocator struct {
+	lines map[string]int
+}
+
+type locatorFrame struct {
+	// Indentation of the line that opened this node
+	indent int
+	path   string
+
+	// Sequence items of a mapping value may be at the same indentation as its key
+	allowsItemsAtIndent bool
+	nextItemIdx         int
+}
+
+func NewLineLocator(bytes []byte) LineLocator {
+	locator := LineLocator{lines: map[string]int{}}
+
+	stack := []*locatorFrame{{indent: -1, allowsItemsAtIndent: true}}
+
+	blockScalarIndent := -1
+
+	for i, line := range strings.Split(string(bytes), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+
+		if strings.TrimSpace(trimmed) == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Skip contents of multi-line strings
+		if blockScalarIndent >= 0 {
+			if indent > blockScalarIndent {
+				continue
+			}
+
+			blockScalarIndent = -1
+		}
+
+		if trimmed == "---" || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+
+		lineNum := i + 1
+
+		for indent >= 0 && trimmed != "" {
+			isItem := trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+
+			for len(stack) > 1 {
+				top := stack[len(stack)-1]
+
+				if indent > top.indent || (indent == top.indent && isItem && top.allowsItemsAtIndent) {
+					break
+				}
+
+				stack = stack[:len(stack)-1]
+			}
+
+			parent := stack[len(stack)-1]
+
+			if isItem {
+				itemPath := fmt.Sprintf("%s[%d]", parent.path, parent.nextItemIdx)
+				parent.nextItemIdx++
+
+				locator.record(itemPath, lineNum)
+
+				stack = append(stack, &locatorFrame{indent: indent, path: itemPath})
+
+				// Item may start with a map key on the same line, e.g. '- name: web'
+				rest := strings.TrimPrefix(trimmed, "-")
+				restTrimmed := strings.TrimLeft(rest, " ")
+				indent += 1 + len(rest) - len(restTrimmed)
+				trimmed = restTrimmed
+
+				continue
+			}
+
+			matches := keyRegexp.FindStringSubmatch(trimmed)
+			if matches == nil {
+				break
+			}
+
+			key := strings.Trim(matches[1], `"'`)
+
+			keyPath := key
+			if parent.path != "" {
+				keyPath = parent.path + "." + key
+			}
+
+			locator.record(keyPath, lineNum)
+
+			value := strings.TrimSpace(trimmed[len(matches[0]):])
+
+			if value == "" || strings.HasPrefix(value, "#") {
+				stack = append(stack, &locatorFrame{indent: indent, path: keyPath, allowsItemsAtIndent: true})
+			} else if blockScalarRegexp.MatchString(value) {
+				blockScalarIndent = indent
+			}
+
+			break
+		}
+	}
+
+	return locator
+}
+
+func (l LineLocator) record(path string, line int) {
+	if _, found := l.lines[path]; !found {
+		l.lines[path] = line
+	}
+}
+
+// Line returns line of the path or its closest known parent; 0 if unknown.
+func (l LineLocator) Line(path string) int {
+	for path != "" {
+		if line, found := l.lines[path]; found {
+			return line
+		}
+
+		lastSep := strings.LastIndexAny(path, ".[")
+		if lastSep < 0 {
+			break
+		}
+
+		path = path[:lastSep]
+	}
+
+	return 0
+}
@@ -0,0 +1,60 @@
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cppforlife/bosh-provisioner/validation"
+)
+
+var _ = Describe("LineLocator", func() {
+	var locator LineLocator
+
+	BeforeEach(func() {
+		locator = NewLineLocator([]byte(`---
+name: fake-deployment # comment
+
+jobs:
+- name: job-1
+  properties:
+    cert: |
+      name: not-a-key
+  networks:
+  - name: net1
+    static_ips:
+    - 10.0.0.2
+    - 10.0.0.3
+- name: job-2
+  networks:
+    - name: net2
+      static_ips: [10.0.0.4]
+"quoted.key": val
+`))
+	})
+
+	Describe("Line", func() {
+		It("returns lines of map keys and array items", func() {
+			Expect(locator.Line("name")).To(Equal(2))
+			Expect(locator.Line("jobs")).To(Equal(4))
+			Expect(locator.Line("jobs[0]")).To(Equal(5))
+			Expect(locator.Line("jobs[0].name")).To(Equal(5))
+			Expect(locator.Line("jobs[0].networks[0].static_ips[1]")).To(Equal(13))
+			Expect(locator.Line("jobs[1]")).To(Equal(14))
+			Expect(locator.Line("jobs[1].networks[0].static_ips")).To(Equal(17))
+			Expect(locator.Line("quoted.key")).To(Equal(18))
+		})
+
+		It("ignores contents of multi-line strings", func() {
+			Expect(locator.Line("jobs[0].properties.cert.name")).To(Equal(7))
+			Expect(locator.Line("jobs[0].networks")).To(Equal(9))
+		})
+
+		It("returns line of the closest known parent for flow style values", func() {
+			Expect(locator.Line("jobs[1].networks[0].static_ips[0]")).To(Equal(17))
+		})
+
+		It("returns 0 for unknown paths", func() {
+			Expect(locator.Line("unknown")).To(Equal(0))
+		})
+	})
+})
@@ -133,6 +133,11 @@ func (p AgentProvisioner) Configure(instance bpdep.Instance) (bpagclient.Client,
 		return nil, bosherr.WrapError(err, "Building agent client")
 	}
 
+	err = bpagclient.NewCompatibilityChecker(p.logger).Check(agentClient, p.agentID(instance))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Checking agent compatibility")
+	}
+
 	return agentClient, nil
 }
 
@@ -263,10 +268,14 @@ func (p AgentProvisioner) placeInfSettings(instance bpdep.Instance) error {
 
 			"preconfigured": true,
 		}
+
+		if len(netConfig.DNS) > 0 {
+			netSettings[netAssoc.Network.Name]["dns"] = netConfig.DNS
+		}
 	}
 
 	settings := h{
-		"agent_id": fmt.Sprintf("agent-id-%s-%d", instance.JobName, instance.Index),
+		"agent_id": p.agentID(instance),
 
 		"vm": h{
 			"name": fmt.Sprintf("vm-name-%s-%d", instance.JobName, instance.Index),
@@ -296,6 +305,10 @@ func (p AgentProvisioner) placeInfSettings(instance bpdep.Instance) error {
 	return nil
 }
 
+func (p AgentProvisioner) agentID(instance bpdep.Instance) string {
+	return fmt.Sprintf("agent-id-%s-%d", instance.JobName, instance.Index)
+}
+
 func (p AgentProvisioner) buildAgentClient() (bpagclient.Client, error) {
 	agentClient, err := bpagclient.NewInsecureHTTPClientWithURI(p.agentProvisionerConfig.Mbus, p.logger)
 	if err != nil {